@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/auth"
+	"github.com/tunedev/bts2025/server/internal/calendar"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+const (
+	weddingTitle = "Diamond & Babatunde's Wedding"
+	weddingVenue = "Nelos Place, Ikeja"
+	// calendarEventLength is how long each event is shown for in calendar apps,
+	// as events only record when they start.
+	calendarEventLength = 3 * time.Hour
+	// calendarReminder is how long before each event calendar apps remind the guest.
+	calendarReminder = 24 * time.Hour
+)
+
+// rsvpCalendar builds the calendar file for an RSVP, with one entry per event
+// the party attends. Without any events it holds the wedding day as a whole.
+func (cfg *apiConfig) rsvpCalendar(rsvp database.RSVP) ([]byte, error) {
+	events, err := cfg.db.ListRSVPEvents(rsvp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("RSVP for %s, party of %d. Please bring the QR code from your confirmation email.", rsvp.GuestName, rsvp.NumberOfGuests)
+
+	entries := make([]calendar.Event, 0, len(events))
+	for _, event := range events {
+		var place []string
+		for _, part := range []string{event.Venue, event.Address} {
+			if part != "" {
+				place = append(place, part)
+			}
+		}
+		entries = append(entries, calendar.Event{
+			UID:         fmt.Sprintf("%s-%s@bts-wedding", event.ID, rsvp.ID),
+			Summary:     event.Name + " - " + weddingTitle,
+			Description: description,
+			Location:    strings.Join(place, ", "),
+			URL:         event.MapURL,
+			Start:       event.StartsAt,
+			End:         event.StartsAt.Add(calendarEventLength),
+			Reminder:    calendarReminder,
+		})
+	}
+	if len(entries) == 0 {
+		day := time.Date(cfg.eventDate.Year(), cfg.eventDate.Month(), cfg.eventDate.Day(), 0, 0, 0, 0, time.UTC)
+		entries = append(entries, calendar.Event{
+			UID:         fmt.Sprintf("%s@bts-wedding", rsvp.ID),
+			Summary:     weddingTitle,
+			Description: description,
+			Location:    weddingVenue,
+			Start:       day,
+			End:         day.AddDate(0, 0, 1),
+			AllDay:      true,
+			Reminder:    calendarReminder,
+		})
+	}
+
+	return calendar.New(entries, time.Now()), nil
+}
+
+// handlerRSVPCalendar serves an approved RSVP's calendar file. The signed ticket
+// from the guest's confirmation email is passed as the token query parameter.
+func (cfg *apiConfig) handlerRSVPCalendar(w http.ResponseWriter, r *http.Request) {
+	rsvpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid RSVP ID", err)
+		return
+	}
+
+	ticketID, err := auth.ValidateTicketToken(r.URL.Query().Get("token"), cfg.ticketKey.Public().(ed25519.PublicKey))
+	if err != nil || ticketID != rsvpID {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired ticket", err)
+		return
+	}
+
+	rsvp, err := cfg.db.GetRSVP(rsvpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVP", err)
+		return
+	}
+	if rsvp.ID == uuid.Nil {
+		respondWithError(w, http.StatusNotFound, "RSVP not found", nil)
+		return
+	}
+	if rsvp.Status != "APPROVED" {
+		respondWithError(w, http.StatusForbidden, "This RSVP has not been approved", nil)
+		return
+	}
+
+	ics, err := cfg.rsvpCalendar(rsvp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not build calendar", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", calendar.ContentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="wedding.ics"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(ics)
+}
@@ -18,9 +18,12 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 
-	pathToDB := os.Getenv("DB_PATH")
-	if pathToDB == "" {
-		log.Fatal("DB_PATH must be set")
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		dbURL = os.Getenv("DB_PATH")
+	}
+	if dbURL == "" {
+		log.Fatal("DB_URL must be set")
 	}
 
 	groomsEmail := os.Getenv("GROOMS_EMAIL")
@@ -34,7 +37,7 @@ func main() {
 	}
 
 	// Connect to the database
-	db, err := database.NewClient(pathToDB)
+	db, err := database.NewClient(dbURL)
 	if err != nil {
 		log.Fatalf("Couldn't connect to database: %v", err)
 	}
@@ -0,0 +1,67 @@
+// Command webhooksign signs a delivery webhook payload with EMAIL_WEBHOOK_SECRET
+// and prints a curl command that posts it to a local server, for testing
+// bounce handling without the mail provider.
+//
+//	go run ./cmd/webhooksign payload.json | sh
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/tunedev/bts2025/server/internal/email"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	secret := os.Getenv("EMAIL_WEBHOOK_SECRET")
+	if secret == "" {
+		log.Fatal("EMAIL_WEBHOOK_SECRET must be set")
+	}
+	verifier, err := email.NewWebhookVerifier(secret)
+	if err != nil {
+		log.Fatalf("Couldn't parse EMAIL_WEBHOOK_SECRET: %v", err)
+	}
+
+	// The payload is read from the file named on the command line, or stdin.
+	var body []byte
+	if len(os.Args) > 1 {
+		body, err = os.ReadFile(os.Args[1])
+	} else {
+		body, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		log.Fatalf("Couldn't read the payload: %v", err)
+	}
+
+	url := os.Getenv("WEBHOOK_URL")
+	if url == "" {
+		url = "http://localhost:" + os.Getenv("PORT") + "/api/webhooks/email"
+	}
+
+	random := make([]byte, 12)
+	rand.Read(random)
+	header := verifier.Sign("msg_"+hex.EncodeToString(random), body, time.Now())
+
+	fmt.Printf("curl -sS -X POST %s \\\n", shellQuote(url))
+	fmt.Printf("  -H 'Content-Type: application/json' \\\n")
+	for _, name := range []string{"svix-id", "svix-timestamp", "svix-signature"} {
+		fmt.Printf("  -H %s \\\n", shellQuote(name+": "+header.Get(name)))
+	}
+	fmt.Printf("  --data-binary %s\n", shellQuote(string(body)))
+}
+
+// shellQuote wraps s in single quotes for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -2,13 +2,14 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/tunedev/bts2025/server/internal/auth"     // Adjust import path
 	"github.com/tunedev/bts2025/server/internal/database" // Adjust import path
-	"github.com/tunedev/bts2025/server/internal/email"
 
 	"github.com/google/uuid"
 )
@@ -49,7 +50,7 @@ func (cfg *apiConfig) handlerLoginStart(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Send the OTP via your emailer utility
-	if err := cfg.mailer.SendLoginOTP(params.Email, otp); err != nil {
+	if _, err := cfg.mailer.SendLoginOTP(params.Email, otp); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Failed to send OTP email", err)
 		return
 	}
@@ -104,8 +105,29 @@ func (cfg *apiConfig) handlerCreateCategory(w http.ResponseWriter, r *http.Reque
 	}
 	params.CoupleID = coupleID
 
+	coupleDetails, _ := GetCoupleDetailsFromCtx(r.Context())
+	if params.Side == "" {
+		params.Side = coupleDetails.Side
+	}
+	if strings.TrimSpace(params.Name) == "" || params.MaxGuests < 0 {
+		respondWithError(w, http.StatusBadRequest, "A category needs a name and a non-negative max_guests", nil)
+		return
+	}
+	if params.InvitationToken == nil || *params.InvitationToken == "" {
+		token, err := auth.GenerateInvitationToken()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not generate invitation token", err)
+			return
+		}
+		params.InvitationToken = &token
+	}
+
 	category, err := cfg.db.CreateCategory(params)
 	if err != nil {
+		if errors.Is(err, database.ErrEventNotFound) {
+			respondWithError(w, http.StatusBadRequest, "event_ids must list existing events", err)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Could not create category", err)
 		return
 	}
@@ -133,6 +155,207 @@ func (cfg *apiConfig) handlerListCategories(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// handlerUpdateCategory edits a category owned by the logged-in couple.
+func (cfg *apiConfig) handlerUpdateCategory(w http.ResponseWriter, r *http.Request) {
+	category, ok := cfg.getOwnedCategory(w, r)
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Name      *string `json:"name"`
+		Side      *string `json:"side"`
+		MaxGuests *int    `json:"max_guests"`
+		// EventIDs replaces the events the invitation covers when present; an
+		// empty list invites the category to every event.
+		EventIDs []uuid.UUID `json:"event_ids"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	if params.Name != nil {
+		if strings.TrimSpace(*params.Name) == "" {
+			respondWithError(w, http.StatusBadRequest, "Category name cannot be empty", nil)
+			return
+		}
+		category.Name = *params.Name
+	}
+	if params.Side != nil {
+		if *params.Side != "BRIDE" && *params.Side != "GROOM" {
+			respondWithError(w, http.StatusBadRequest, "Side must be either BRIDE or GROOM", nil)
+			return
+		}
+		category.Side = *params.Side
+	}
+	if params.MaxGuests != nil {
+		if *params.MaxGuests < 0 {
+			respondWithError(w, http.StatusBadRequest, "max_guests cannot be negative", nil)
+			return
+		}
+		category.MaxGuests = *params.MaxGuests
+	}
+	if params.EventIDs != nil {
+		category.EventIDs = params.EventIDs
+	}
+
+	if _, err := cfg.db.UpdateCategory(category); err != nil {
+		if errors.Is(err, database.ErrCapacityBelowApproved) {
+			respondWithError(w, http.StatusConflict, "max_guests cannot be lower than the number of guests already approved", err)
+			return
+		}
+		if errors.Is(err, database.ErrEventNotFound) {
+			respondWithError(w, http.StatusBadRequest, "event_ids must list existing events", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not update category", err)
+		return
+	}
+	cfg.wakeOutbox()
+
+	updated, err := cfg.db.GetCategory(category.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve category", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    updated,
+		Message: "Updated category successfully",
+		Success: true,
+	})
+}
+
+// handlerRotateCategoryToken issues a new invitation token, invalidating the category's old links.
+func (cfg *apiConfig) handlerRotateCategoryToken(w http.ResponseWriter, r *http.Request) {
+	category, ok := cfg.getOwnedCategory(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := auth.GenerateInvitationToken()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not generate invitation token", err)
+		return
+	}
+
+	updated, err := cfg.db.RotateInvitationToken(category.ID, category.CoupleID, token)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not rotate invitation token", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    updated,
+		Message: "Rotated invitation token successfully",
+		Success: true,
+	})
+}
+
+// handlerDeleteCategory deletes a category. RSVPs attached to it must either be
+// reassigned (?rsvps=reassign&targetCategoryId=...) or rejected (?rsvps=reject).
+func (cfg *apiConfig) handlerDeleteCategory(w http.ResponseWriter, r *http.Request) {
+	category, ok := cfg.getOwnedCategory(w, r)
+	if !ok {
+		return
+	}
+
+	params := database.DeleteCategoryParams{
+		ID:       category.ID,
+		CoupleID: category.CoupleID,
+	}
+
+	switch policy := r.URL.Query().Get("rsvps"); policy {
+	case "reassign":
+		targetID, err := uuid.Parse(r.URL.Query().Get("targetCategoryId"))
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "A valid targetCategoryId is required to reassign RSVPs", err)
+			return
+		}
+		target, err := cfg.db.GetCategory(targetID)
+		if err != nil || target.ID == uuid.Nil || target.DeletedAt != nil || target.CoupleID != category.CoupleID || target.ID == category.ID {
+			respondWithError(w, http.StatusBadRequest, "The target category must be another of your categories", err)
+			return
+		}
+		params.ReassignTo = uuid.NullUUID{UUID: target.ID, Valid: true}
+	case "reject":
+	case "":
+		rsvps, err := cfg.db.ListRSVPsByCategory(category.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVPs", err)
+			return
+		}
+		if len(rsvps) > 0 {
+			respondWithError(w, http.StatusConflict, "This category has RSVPs; choose rsvps=reassign or rsvps=reject", nil)
+			return
+		}
+		invitees, err := cfg.db.CountGuestsByCategory(category.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not retrieve invitees", err)
+			return
+		}
+		if invitees > 0 {
+			respondWithError(w, http.StatusConflict, "This category has invitees; choose rsvps=reassign to move them or rsvps=reject to remove them", nil)
+			return
+		}
+	default:
+		respondWithError(w, http.StatusBadRequest, "rsvps must be either reassign or reject", nil)
+		return
+	}
+
+	result, err := cfg.db.DeleteCategory(params)
+	if err != nil {
+		if errors.Is(err, database.ErrCategoryFull) {
+			respondWithError(w, http.StatusConflict, "The target category does not have enough remaining spots for these RSVPs", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not delete category", err)
+		return
+	}
+
+	cfg.wakeOutbox()
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data: map[string]any{
+			"reassigned":      result.Reassigned,
+			"rejected":        len(result.Rejected),
+			"promoted":        len(result.Promoted),
+			"removedInvitees": result.RemovedInvitees,
+		},
+		Message: "Deleted category successfully",
+		Success: true,
+	})
+}
+
+// getOwnedCategory loads the category named in the path, responding with 404 if it
+// does not exist or belongs to another couple.
+func (cfg *apiConfig) getOwnedCategory(w http.ResponseWriter, r *http.Request) (database.GuestCategory, bool) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	categoryID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid category ID", err)
+		return database.GuestCategory{}, false
+	}
+
+	category, err := cfg.db.GetCategory(categoryID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve category", err)
+		return database.GuestCategory{}, false
+	}
+	if category.ID == uuid.Nil || category.DeletedAt != nil || category.CoupleID != coupleID {
+		respondWithError(w, http.StatusNotFound, "Category not found", nil)
+		return database.GuestCategory{}, false
+	}
+
+	return category, true
+}
+
+// handlerListRSVPs returns a page of the couple's RSVPs. It accepts the filters
+// understood by parseRSVPFilter plus sort, order, limit and the cursor returned
+// with the previous page.
 func (cfg *apiConfig) handlerListRSVPs(w http.ResponseWriter, r *http.Request) {
 	coupleDetails, ok := GetCoupleDetailsFromCtx(r.Context())
 	if !ok {
@@ -140,19 +363,113 @@ func (cfg *apiConfig) handlerListRSVPs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rsvps, err := cfg.db.ListAllRSVPs("", coupleDetails.Side)
+	filter, err := parseRSVPFilter(r, coupleDetails.Side)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	query := r.URL.Query()
+	params := database.ListRSVPsParams{
+		RSVPFilter: filter,
+		SortBy:     query.Get("sort"),
+		Cursor:     query.Get("cursor"),
+	}
+
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+	case "desc":
+		params.Descending = true
+	default:
+		respondWithError(w, http.StatusBadRequest, "order must be asc or desc", nil)
+		return
+	}
+
+	switch params.SortBy {
+	case "", "submitted_at", "guest_name", "number_of_guests":
+	default:
+		respondWithError(w, http.StatusBadRequest, "sort must be one of submitted_at, guest_name or number_of_guests", nil)
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		params.Limit, err = strconv.Atoi(limit)
+		if err != nil || params.Limit < 1 {
+			respondWithError(w, http.StatusBadRequest, "limit must be a positive number", err)
+			return
+		}
+	}
+
+	page, err := cfg.db.ListRSVPs(params)
 	if err != nil {
+		if errors.Is(err, database.ErrInvalidCursor) {
+			respondWithError(w, http.StatusBadRequest, "Invalid or stale cursor", err)
+			return
+		}
 		respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVPs", err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, responseStructure{
-		Data:    rsvps,
+		Data:    page,
 		Message: "Retrieved RSVP list successfully",
 		Success: true,
 	})
 }
 
+// parseRSVPFilter reads the status, categoryId, q, from and to query parameters.
+// Dates are either YYYY-MM-DD or RFC 3339; a bare "to" date includes that whole day.
+func parseRSVPFilter(r *http.Request, side string) (database.RSVPFilter, error) {
+	query := r.URL.Query()
+	filter := database.RSVPFilter{
+		Side:   side,
+		Status: strings.ToUpper(query.Get("status")),
+		Search: query.Get("q"),
+	}
+
+	switch filter.Status {
+	case "", "PENDING", "APPROVED", "REJECTED", "WAITLISTED":
+	default:
+		return database.RSVPFilter{}, errors.New("status must be one of PENDING, APPROVED, REJECTED or WAITLISTED")
+	}
+
+	if categoryID := query.Get("categoryId"); categoryID != "" {
+		id, err := uuid.Parse(categoryID)
+		if err != nil {
+			return database.RSVPFilter{}, errors.New("invalid categoryId")
+		}
+		filter.CategoryID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, _, err := parseFilterDate(from)
+		if err != nil {
+			return database.RSVPFilter{}, errors.New("from must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+		}
+		filter.SubmittedFrom = &t
+	}
+	if to := query.Get("to"); to != "" {
+		t, dateOnly, err := parseFilterDate(to)
+		if err != nil {
+			return database.RSVPFilter{}, errors.New("to must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.SubmittedTo = &t
+	}
+
+	return filter, nil
+}
+
+func parseFilterDate(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
 func (cfg *apiConfig) handlerApproveRSVP(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		RSVPID     uuid.UUID `json:"rsvpId"`
@@ -171,45 +488,182 @@ func (cfg *apiConfig) handlerApproveRSVP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	newStatusSuffix := ""
-
-	if params.Action == "APPROVE" {
-		if !rsvp.CategoryID.Valid {
-			if params.CategoryID == uuid.Nil {
-				respondWithError(w, http.StatusBadRequest, "A category must be assigned to approve this RSVP", nil)
+	switch params.Action {
+	case "APPROVE":
+		if !rsvp.CategoryID.Valid && params.CategoryID == uuid.Nil {
+			respondWithError(w, http.StatusBadRequest, "A category must be assigned to approve this RSVP", nil)
+			return
+		}
+		categoryID := uuid.NullUUID{UUID: params.CategoryID, Valid: params.CategoryID != uuid.Nil}
+		_, err = cfg.db.ApproveRSVP(rsvp.ID, categoryID)
+		if err != nil {
+			if errors.Is(err, database.ErrCategoryFull) {
+				respondWithError(w, http.StatusConflict, "This category does not have enough remaining spots for this RSVP", err)
 				return
 			}
-			if err := cfg.db.AssignCategoryToRSVP(rsvp.ID, params.CategoryID); err != nil {
-				respondWithError(w, http.StatusInternalServerError, "Could not assign category", err)
+			if errors.Is(err, database.ErrEventFull) {
+				respondWithError(w, http.StatusConflict, "One of this RSVP's events does not have enough remaining spots", err)
 				return
 			}
+			respondWithError(w, http.StatusInternalServerError, "Failed to update RSVP status", err)
+			return
+		}
+	case "REJECT":
+		_, _, err = cfg.db.RejectRSVP(rsvp.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Failed to update RSVP status", err)
+			return
 		}
-		newStatusSuffix = "D"
-	} else {
-		newStatusSuffix = "ED"
+	default:
+		respondWithError(w, http.StatusBadRequest, "Action must be either APPROVE or REJECT", nil)
+		return
 	}
 
-	newStatus := params.Action + newStatusSuffix
-	if err := cfg.db.UpdateRSVPStatus(rsvp.ID, newStatus); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Failed to update RSVP status", err)
+	// The guest's email, and any promoted guests' confirmations, were queued
+	// with the status change.
+	cfg.wakeOutbox()
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    map[string]any{"message": "RSVP status updated successfully."},
+		Message: "Approved RSVP successfully",
+		Success: true,
+	})
+}
+
+// handlerListWaitlist returns the waitlist for the couple's side, ordered per category.
+func (cfg *apiConfig) handlerListWaitlist(w http.ResponseWriter, r *http.Request) {
+	coupleDetails, ok := GetCoupleDetailsFromCtx(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
 		return
 	}
 
-	switch newStatus {
-	case "APPROVED":
-		cfg.mailer.SendRSVPConfirmed(rsvp.Email, email.SendRSVPConfirmedParam{
-			GuestName:      rsvp.GuestName,
-			Phone:          rsvp.Phone,
-			NumberOfGuests: rsvp.NumberOfGuests,
-			RSVPID:         rsvp.ID.String(),
-		})
-	case "REJECTED":
-		cfg.mailer.SendRSVPRejected(rsvp.Email, rsvp.GuestName)
+	entries, err := cfg.db.ListWaitlist(coupleDetails.Side)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve waitlist", err)
+		return
 	}
 
 	respondWithJSON(w, http.StatusOK, responseStructure{
-		Data:    map[string]any{"message": "RSVP status updated successfully."},
-		Message: "Approved RSVP successfully",
+		Data:    entries,
+		Message: "Retrieved waitlist successfully",
 		Success: true,
 	})
 }
+
+// handlerUpdateRSVPPartySize changes the number of guests on an RSVP.
+func (cfg *apiConfig) handlerUpdateRSVPPartySize(w http.ResponseWriter, r *http.Request) {
+	rsvpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid RSVP ID", err)
+		return
+	}
+
+	type parameters struct {
+		NumberOfGuests int `json:"numberOfGuests"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+	if params.NumberOfGuests < 1 {
+		respondWithError(w, http.StatusBadRequest, "At least one guest is required", nil)
+		return
+	}
+
+	if _, ok := cfg.getSideRSVP(w, r, rsvpID); !ok {
+		return
+	}
+
+	rsvp, _, err := cfg.db.UpdateRSVPPartySize(rsvpID, params.NumberOfGuests)
+	if err != nil {
+		if errors.Is(err, database.ErrTooManyAttendees) {
+			respondWithError(w, http.StatusConflict, "This RSVP names more attendees than that party size", err)
+			return
+		}
+		if errors.Is(err, database.ErrExceedsAllotment) {
+			respondWithError(w, http.StatusBadRequest, "This guest's invitation does not allow that many guests", err)
+			return
+		}
+		if errors.Is(err, database.ErrTableFull) {
+			respondWithError(w, http.StatusConflict, "This party's table does not have enough free seats", err)
+			return
+		}
+		if errors.Is(err, database.ErrCategoryFull) {
+			respondWithError(w, http.StatusConflict, "This category does not have enough remaining spots for this RSVP", err)
+			return
+		}
+		if errors.Is(err, database.ErrEventFull) {
+			respondWithError(w, http.StatusConflict, "One of this RSVP's events does not have enough remaining spots", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not update RSVP", err)
+		return
+	}
+	cfg.wakeOutbox()
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    rsvp,
+		Message: "Updated RSVP successfully",
+		Success: true,
+	})
+}
+
+// handlerDeleteRSVP removes an RSVP, releasing any seats it held to the waitlist.
+func (cfg *apiConfig) handlerDeleteRSVP(w http.ResponseWriter, r *http.Request) {
+	rsvpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid RSVP ID", err)
+		return
+	}
+
+	if _, ok := cfg.getSideRSVP(w, r, rsvpID); !ok {
+		return
+	}
+
+	promoted, err := cfg.db.DeleteRSVP(rsvpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete RSVP", err)
+		return
+	}
+	cfg.wakeOutbox()
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    map[string]any{"promoted": len(promoted)},
+		Message: "Deleted RSVP successfully",
+		Success: true,
+	})
+}
+
+// getSideRSVP loads an RSVP, responding with 404 unless it is in a category on
+// the signed-in couple's side, the same RSVPs the couple's listings show.
+func (cfg *apiConfig) getSideRSVP(w http.ResponseWriter, r *http.Request, rsvpID uuid.UUID) (database.RSVP, bool) {
+	coupleDetails, ok := GetCoupleDetailsFromCtx(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
+		return database.RSVP{}, false
+	}
+
+	rsvp, err := cfg.db.GetRSVP(rsvpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVP", err)
+		return database.RSVP{}, false
+	}
+	if rsvp.ID == uuid.Nil || !rsvp.CategoryID.Valid {
+		respondWithError(w, http.StatusNotFound, "RSVP not found", nil)
+		return database.RSVP{}, false
+	}
+
+	category, err := cfg.db.GetCategory(rsvp.CategoryID.UUID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve category", err)
+		return database.RSVP{}, false
+	}
+	if category.Side != coupleDetails.Side {
+		respondWithError(w, http.StatusNotFound, "RSVP not found", nil)
+		return database.RSVP{}, false
+	}
+
+	return rsvp, true
+}
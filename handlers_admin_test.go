@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+func TestHandlerLoginStart(t *testing.T) {
+	cfg, transport := newTestConfig(t)
+	couple, err := cfg.db.CreateCouple(database.CreateCoupleParams{Name: "Diamond", Email: "diamond@example.com", Side: "BRIDE"})
+	if err != nil {
+		t.Fatalf("CreateCouple: %v", err)
+	}
+
+	loginStart := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/admin/login/start", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		cfg.handlerLoginStart(w, r)
+		return w
+	}
+
+	t.Run("unknown email", func(t *testing.T) {
+		transport.Reset()
+		if w := loginStart(`{"email":"nobody@example.com"}`); w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+		if msgs := transport.Messages(); len(msgs) != 0 {
+			t.Errorf("sent %d emails, want none", len(msgs))
+		}
+	})
+
+	t.Run("sends a working code", func(t *testing.T) {
+		transport.Reset()
+		if w := loginStart(`{"email":"Diamond@Example.com"}`); w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+
+		msgs := transport.Messages()
+		if len(msgs) != 1 {
+			t.Fatalf("sent %d emails, want 1", len(msgs))
+		}
+		msg := msgs[0]
+		if len(msg.To) != 1 || msg.To[0] != couple.Email {
+			t.Errorf("sent to %v, want %s", msg.To, couple.Email)
+		}
+
+		otp := regexp.MustCompile(`\b\d{6}\b`).FindString(msg.Text)
+		if otp == "" {
+			t.Fatalf("no code in the email text:\n%s", msg.Text)
+		}
+		if !strings.Contains(msg.HTML, otp) {
+			t.Errorf("HTML body does not contain the code %s", otp)
+		}
+		got, err := cfg.db.VerifyOTPForCouple(couple.Email, otp)
+		if err != nil || got.ID != couple.ID {
+			t.Errorf("VerifyOTPForCouple(%s) = %v, %v, want couple %s", otp, got.ID, err, couple.ID)
+		}
+	})
+}
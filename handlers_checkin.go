@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/auth"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+// handlerCheckIn records a guest's arrival from the ticket scanned off their QR code.
+func (cfg *apiConfig) handlerCheckIn(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Ticket        string `json:"ticket"`
+		GuestsArrived int    `json:"guestsArrived"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	rsvpID, err := auth.ValidateTicketToken(params.Ticket, cfg.ticketKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired ticket", err)
+		return
+	}
+
+	rsvp, err := cfg.db.GetRSVP(rsvpID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVP", err)
+		return
+	}
+	if rsvp.ID == uuid.Nil {
+		respondWithError(w, http.StatusNotFound, "RSVP not found", nil)
+		return
+	}
+
+	if rsvp.Status != "APPROVED" {
+		respondWithError(w, http.StatusForbidden, "This RSVP has not been approved", nil)
+		return
+	}
+
+	existing, err := cfg.db.GetCheckInByRSVP(rsvp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve check-in", err)
+		return
+	}
+	if existing.ID != uuid.Nil {
+		respondWithError(w, http.StatusConflict, "This guest has already checked in", nil)
+		return
+	}
+
+	if params.GuestsArrived == 0 {
+		params.GuestsArrived = rsvp.NumberOfGuests
+	}
+	if params.GuestsArrived < 1 || params.GuestsArrived > rsvp.NumberOfGuests {
+		respondWithError(w, http.StatusBadRequest, "Number of arriving guests must be between 1 and the RSVP party size", nil)
+		return
+	}
+
+	checkIn, err := cfg.db.CreateCheckIn(database.CreateCheckInParams{
+		RSVPID:        rsvp.ID,
+		GuestsArrived: params.GuestsArrived,
+		CheckedInBy:   coupleID,
+	})
+	if err != nil {
+		if database.IsUniqueConstraintError(err) {
+			respondWithError(w, http.StatusConflict, "This guest has already checked in", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not record check-in", err)
+		return
+	}
+
+	// The table is a convenience for ushers; failing to look it up shouldn't undo the check-in.
+	tables, err := cfg.db.GetRSVPTableNumbers(rsvp.ID)
+	if err != nil {
+		cfg.logger.Error("failed to look up table numbers", "rsvp_id", rsvp.ID, "error", err)
+	}
+
+	respondWithJSON(w, http.StatusCreated, responseStructure{
+		Data: map[string]any{
+			"guestName":      rsvp.GuestName,
+			"numberOfGuests": rsvp.NumberOfGuests,
+			"guestsArrived":  checkIn.GuestsArrived,
+			"checkedInAt":    checkIn.CheckedInAt,
+			"tableNumber":    formatTableNumbers(tables),
+		},
+		Message: "Guest checked in successfully",
+		Success: true,
+	})
+}
+
+// handlerCheckInStats reports live arrival numbers for the door team.
+func (cfg *apiConfig) handlerCheckInStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := cfg.db.GetCheckInStats()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve check-in stats", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    stats,
+		Message: "Check-in stats retrieved successfully",
+		Success: true,
+	})
+}
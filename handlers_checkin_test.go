@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tunedev/bts2025/server/internal/auth"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+func TestHandlerCheckIn(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	couple, category := newTestCouple(t, cfg, "BRIDE")
+
+	ticket := func(rsvp database.RSVP) string {
+		token, err := auth.MakeTicketToken(rsvp.ID, cfg.ticketKey, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatalf("MakeTicketToken: %v", err)
+		}
+		return token
+	}
+	checkIn := func(ticket string, guestsArrived int) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"ticket":%q,"guestsArrived":%d}`, ticket, guestsArrived)
+		r := asCouple(httptest.NewRequest(http.MethodPost, "/api/checkin", strings.NewReader(body)), couple)
+		w := httptest.NewRecorder()
+		cfg.handlerCheckIn(w, r)
+		return w
+	}
+
+	approved := newTestRSVP(t, cfg, category, "APPROVED", 3)
+	wholeParty := newTestRSVP(t, cfg, category, "APPROVED", 2)
+	newTestRSVP(t, cfg, category, "APPROVED", 4) // never arrives
+	pending := newTestRSVP(t, cfg, category, "PENDING", 2)
+	waitlisted := newTestRSVP(t, cfg, category, "WAITLISTED", 1)
+
+	tests := []struct {
+		name          string
+		ticket        string
+		guestsArrived int
+		wantCode      int
+	}{
+		{"invalid ticket", "not-a-ticket", 1, http.StatusUnauthorized},
+		{"pending RSVP", ticket(pending), 1, http.StatusForbidden},
+		{"waitlisted RSVP", ticket(waitlisted), 1, http.StatusForbidden},
+		{"no guests arrived", ticket(approved), -1, http.StatusBadRequest},
+		{"more guests than the party", ticket(approved), 4, http.StatusBadRequest},
+		{"part of the party", ticket(approved), 2, http.StatusCreated},
+		{"second scan", ticket(approved), 1, http.StatusConflict},
+		{"whole party by default", ticket(wholeParty), 0, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := checkIn(tt.ticket, tt.guestsArrived)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+
+	for _, rsvp := range []database.RSVP{approved, wholeParty} {
+		got, err := cfg.db.GetCheckInByRSVP(rsvp.ID)
+		if err != nil {
+			t.Fatalf("GetCheckInByRSVP: %v", err)
+		}
+		if got.GuestsArrived != 2 {
+			t.Errorf("%s: %d guests arrived, want 2", rsvp.GuestName, got.GuestsArrived)
+		}
+	}
+
+	t.Run("stats", func(t *testing.T) {
+		r := asCouple(httptest.NewRequest(http.MethodGet, "/api/checkin/stats", nil), couple)
+		w := httptest.NewRecorder()
+		cfg.handlerCheckInStats(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+
+		var resp struct {
+			Data database.CheckInStats `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		want := database.CheckInStats{ApprovedParties: 3, ApprovedGuests: 9, CheckedInParties: 2, GuestsArrived: 4}
+		if resp.Data != want {
+			t.Errorf("stats = %+v, want %+v", resp.Data, want)
+		}
+	})
+}
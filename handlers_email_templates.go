@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+	"github.com/tunedev/bts2025/server/internal/email"
+)
+
+// emailTemplateResponse is an editable email with the couple's wording, if they
+// have changed it, alongside the built-in wording.
+type emailTemplateResponse struct {
+	email.TemplateInfo
+	// Override is the couple's wording, or nil while the built-in one is used.
+	Override *emailTemplateOverride `json:"override"`
+}
+
+type emailTemplateOverride struct {
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newEmailTemplateResponse(info email.TemplateInfo, override database.EmailTemplate) emailTemplateResponse {
+	response := emailTemplateResponse{TemplateInfo: info}
+	if override.ID != uuid.Nil {
+		response.Override = &emailTemplateOverride{
+			Subject:   override.Subject,
+			Body:      override.Body,
+			UpdatedAt: override.UpdatedAt,
+		}
+	}
+	return response
+}
+
+// handlerListEmailTemplates lists the emails the couple can reword.
+func (cfg *apiConfig) handlerListEmailTemplates(w http.ResponseWriter, r *http.Request) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	infos, err := email.EditableTemplates()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not load email templates", err)
+		return
+	}
+	overrides, err := cfg.db.ListEmailTemplates(coupleID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve email templates", err)
+		return
+	}
+	byName := map[string]database.EmailTemplate{}
+	for _, override := range overrides {
+		byName[override.Name] = override
+	}
+
+	templates := make([]emailTemplateResponse, len(infos))
+	for i, info := range infos {
+		templates[i] = newEmailTemplateResponse(info, byName[info.Name])
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    templates,
+		Message: "Retrieved email templates successfully",
+		Success: true,
+	})
+}
+
+// handlerGetEmailTemplate returns one email template.
+func (cfg *apiConfig) handlerGetEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	info, ok := editableTemplate(w, r.PathValue("name"))
+	if !ok {
+		return
+	}
+	override, err := cfg.db.GetEmailTemplate(coupleID, info.Name)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve email template", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    newEmailTemplateResponse(info, override),
+		Message: "Retrieved email template successfully",
+		Success: true,
+	})
+}
+
+// handlerSaveEmailTemplate replaces the wording of an email for the couple's
+// guests. The subject and body are Go templates and may only use the fields
+// listed for the template.
+func (cfg *apiConfig) handlerSaveEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	info, ok := editableTemplate(w, r.PathValue("name"))
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	if err := email.ValidateTemplate(info.Name, params.Subject, params.Body); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid template: "+err.Error(), err)
+		return
+	}
+
+	saved, err := cfg.db.SaveEmailTemplate(database.SaveEmailTemplateParams{
+		CoupleID: coupleID,
+		Name:     info.Name,
+		Subject:  params.Subject,
+		Body:     params.Body,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not save email template", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    newEmailTemplateResponse(info, saved),
+		Message: "Email template saved",
+		Success: true,
+	})
+}
+
+// handlerResetEmailTemplate drops the couple's wording so the built-in email is
+// sent again.
+func (cfg *apiConfig) handlerResetEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	info, ok := editableTemplate(w, r.PathValue("name"))
+	if !ok {
+		return
+	}
+	if _, err := cfg.db.DeleteEmailTemplate(coupleID, info.Name); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not reset email template", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    newEmailTemplateResponse(info, database.EmailTemplate{}),
+		Message: "Email template reset to the default",
+		Success: true,
+	})
+}
+
+// handlerPreviewEmailTemplate renders an email with sample data. A subject or
+// body in the request is previewed before saving; otherwise the couple's
+// current wording, or the built-in one, is used.
+func (cfg *apiConfig) handlerPreviewEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	info, ok := editableTemplate(w, r.PathValue("name"))
+	if !ok {
+		return
+	}
+
+	type parameters struct {
+		Subject *string `json:"subject"`
+		Body    *string `json:"body"`
+	}
+	params := parameters{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+			return
+		}
+	}
+
+	subject, body := info.Subject, info.Body
+	override, err := cfg.db.GetEmailTemplate(coupleID, info.Name)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve email template", err)
+		return
+	}
+	if override.ID != uuid.Nil {
+		subject, body = override.Subject, override.Body
+	}
+	if params.Subject != nil {
+		subject = *params.Subject
+	}
+	if params.Body != nil {
+		body = *params.Body
+	}
+
+	preview, err := email.PreviewTemplate(info.Name, subject, body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid template: "+err.Error(), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    preview,
+		Message: "Rendered email preview",
+		Success: true,
+	})
+}
+
+// editableTemplate looks up the named template, responding with 404 if the
+// couple cannot edit it.
+func editableTemplate(w http.ResponseWriter, name string) (email.TemplateInfo, bool) {
+	info, err := email.EditableTemplate(name)
+	if err != nil {
+		if errors.Is(err, email.ErrUnknownTemplate) {
+			respondWithError(w, http.StatusNotFound, "Email template not found", err)
+			return email.TemplateInfo{}, false
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not load email template", err)
+		return email.TemplateInfo{}, false
+	}
+	return info, true
+}
+
+// coupleMailer returns a Mailer that sends the couple's own wording of any
+// email they have reworded.
+func (cfg *apiConfig) coupleMailer(coupleID uuid.UUID) (email.Mailer, error) {
+	templates, err := cfg.db.ListEmailTemplates(coupleID)
+	if err != nil {
+		return email.Mailer{}, err
+	}
+	if len(templates) == 0 {
+		return cfg.mailer, nil
+	}
+
+	overrides := email.Overrides{}
+	for _, template := range templates {
+		overrides[template.Name] = email.TemplateOverride{Subject: template.Subject, Body: template.Body}
+	}
+	return cfg.mailer.WithOverrides(overrides), nil
+}
+
+// categoryMailer returns the Mailer for guests invited through a category,
+// which uses the wording of the couple who owns it. Guests without a category
+// get the built-in emails.
+func (cfg *apiConfig) categoryMailer(categoryID uuid.NullUUID) (email.Mailer, error) {
+	if !categoryID.Valid {
+		return cfg.mailer, nil
+	}
+	category, err := cfg.db.GetCategory(categoryID.UUID)
+	if err != nil {
+		return email.Mailer{}, err
+	}
+	if category.ID == uuid.Nil {
+		return cfg.mailer, nil
+	}
+	return cfg.coupleMailer(category.CoupleID)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+// handlerListEvents returns every event with its approved head count.
+func (cfg *apiConfig) handlerListEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := cfg.db.ListEvents()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve events", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    events,
+		Message: "Retrieved events successfully",
+		Success: true,
+	})
+}
+
+// handlerCreateEvent adds an event, such as the church service or the reception.
+// Leaving out capacity means the event has no head count of its own.
+func (cfg *apiConfig) handlerCreateEvent(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name     string    `json:"name"`
+		Venue    string    `json:"venue"`
+		StartsAt time.Time `json:"startsAt"`
+		Address  string    `json:"address"`
+		MapURL   string    `json:"mapUrl"`
+		Capacity *int      `json:"capacity"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format (startsAt must be an RFC 3339 time)", err)
+		return
+	}
+
+	event := database.Event{
+		Name:     strings.TrimSpace(params.Name),
+		Venue:    strings.TrimSpace(params.Venue),
+		StartsAt: params.StartsAt,
+		Address:  strings.TrimSpace(params.Address),
+		MapURL:   strings.TrimSpace(params.MapURL),
+		Capacity: params.Capacity,
+	}
+	if err := validateEvent(event); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	created, err := cfg.db.CreateEvent(database.EventParams{
+		Name:     event.Name,
+		Venue:    event.Venue,
+		StartsAt: event.StartsAt,
+		Address:  event.Address,
+		MapURL:   event.MapURL,
+		Capacity: event.Capacity,
+	})
+	if err != nil {
+		if database.IsUniqueConstraintError(err) {
+			respondWithError(w, http.StatusConflict, "An event with this name already exists", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not create event", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, responseStructure{
+		Data:    created,
+		Message: "Event created successfully",
+		Success: true,
+	})
+}
+
+// handlerUpdateEvent changes an event's details. Fields left out are unchanged;
+// a capacity of null removes the event's own head count.
+func (cfg *apiConfig) handlerUpdateEvent(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name     *string         `json:"name"`
+		Venue    *string         `json:"venue"`
+		StartsAt *time.Time      `json:"startsAt"`
+		Address  *string         `json:"address"`
+		MapURL   *string         `json:"mapUrl"`
+		Capacity json.RawMessage `json:"capacity"`
+	}
+
+	event, ok := cfg.getEvent(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format (startsAt must be an RFC 3339 time)", err)
+		return
+	}
+
+	if params.Name != nil {
+		event.Name = strings.TrimSpace(*params.Name)
+	}
+	if params.Venue != nil {
+		event.Venue = strings.TrimSpace(*params.Venue)
+	}
+	if params.StartsAt != nil {
+		event.StartsAt = *params.StartsAt
+	}
+	if params.Address != nil {
+		event.Address = strings.TrimSpace(*params.Address)
+	}
+	if params.MapURL != nil {
+		event.MapURL = strings.TrimSpace(*params.MapURL)
+	}
+	if len(params.Capacity) > 0 {
+		event.Capacity = nil
+		if err := json.Unmarshal(params.Capacity, &event.Capacity); err != nil {
+			respondWithError(w, http.StatusBadRequest, "capacity must be a whole number or null", err)
+			return
+		}
+	}
+	if err := validateEvent(event); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	if _, err := cfg.db.UpdateEvent(event); err != nil {
+		if errors.Is(err, database.ErrEventCapacityBelowApproved) {
+			respondWithError(w, http.StatusConflict, "capacity cannot be lower than the number of guests already approved for this event", err)
+			return
+		}
+		if database.IsUniqueConstraintError(err) {
+			respondWithError(w, http.StatusConflict, "An event with this name already exists", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not update event", err)
+		return
+	}
+	cfg.wakeOutbox()
+
+	updated, err := cfg.db.GetEvent(event.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve event", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    updated,
+		Message: "Event updated successfully",
+		Success: true,
+	})
+}
+
+// handlerDeleteEvent removes an event that no RSVP attends and no category lists.
+func (cfg *apiConfig) handlerDeleteEvent(w http.ResponseWriter, r *http.Request) {
+	event, ok := cfg.getEvent(w, r)
+	if !ok {
+		return
+	}
+
+	if err := cfg.db.DeleteEvent(event.ID); err != nil {
+		if errors.Is(err, database.ErrEventInUse) {
+			respondWithError(w, http.StatusConflict, "Guests are attending this event or categories are invited to it", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not delete event", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Message: "Event deleted successfully",
+		Success: true,
+	})
+}
+
+// getEvent loads the event named in the {id} path segment, responding with an
+// error if it does not exist.
+func (cfg *apiConfig) getEvent(w http.ResponseWriter, r *http.Request) (database.Event, bool) {
+	eventID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid event ID", err)
+		return database.Event{}, false
+	}
+
+	event, err := cfg.db.GetEvent(eventID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve event", err)
+		return database.Event{}, false
+	}
+	if event.ID == uuid.Nil {
+		respondWithError(w, http.StatusNotFound, "Event not found", nil)
+		return database.Event{}, false
+	}
+
+	return event, true
+}
+
+// validateEvent checks the details an admin entered for an event.
+func validateEvent(event database.Event) error {
+	if event.Name == "" {
+		return errors.New("event name is required")
+	}
+	if event.StartsAt.IsZero() {
+		return errors.New("startsAt is required")
+	}
+	if event.Capacity != nil && *event.Capacity < 0 {
+		return errors.New("capacity cannot be negative")
+	}
+	if event.MapURL != "" {
+		u, err := url.Parse(event.MapURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("mapUrl must be an http or https link")
+		}
+	}
+	return nil
+}
+
+// guestEvents is the view of events shown to guests, with start times in the
+// wedding's time zone.
+func (cfg *apiConfig) guestEvents(events []database.Event) []map[string]any {
+	views := make([]map[string]any, len(events))
+	for i, event := range events {
+		views[i] = map[string]any{
+			"id":       event.ID,
+			"name":     event.Name,
+			"venue":    event.Venue,
+			"startsAt": event.StartsAt.In(cfg.eventLocation),
+			"address":  event.Address,
+			"mapUrl":   event.MapURL,
+		}
+	}
+	return views
+}
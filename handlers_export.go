@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tunedev/bts2025/server/internal/database"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// exportColumn is one column that can be requested in a guest list export.
+type exportColumn struct {
+	key    string
+	header string
+	value  func(row database.ExportRow) any
+}
+
+// exportColumns lists the available export columns in their default order.
+var exportColumns = []exportColumn{
+	{"guest_name", "Guest Name", func(row database.ExportRow) any { return row.GuestName }},
+	{"email", "Email", func(row database.ExportRow) any { return row.Email }},
+	{"phone", "Phone", func(row database.ExportRow) any { return row.Phone }},
+	{"category", "Category", func(row database.ExportRow) any { return row.CategoryName }},
+	{"side", "Side", func(row database.ExportRow) any { return row.CategorySide }},
+	{"status", "Status", func(row database.ExportRow) any { return row.Status }},
+	{"number_of_guests", "Party Size", func(row database.ExportRow) any { return row.NumberOfGuests }},
+	{"events", "Events", func(row database.ExportRow) any { return strings.Join(row.Events, "; ") }},
+	{"attendees", "Attendees", func(row database.ExportRow) any { return formatAttendees(row.Attendees) }},
+	{"meal_choices", "Meal Choices", func(row database.ExportRow) any { return formatMealChoices(row.Attendees) }},
+	{"dietary_notes", "Dietary Notes", func(row database.ExportRow) any { return formatDietaryNotes(row.Attendees) }},
+	{"submitted_at", "Submitted At", func(row database.ExportRow) any { return row.SubmittedAt.UTC() }},
+}
+
+// formatAttendees lists attendee names in one cell, marking children.
+func formatAttendees(attendees []database.Attendee) string {
+	names := make([]string, len(attendees))
+	for i, attendee := range attendees {
+		names[i] = attendee.Name
+		if attendee.AgeGroup == database.AgeGroupChild {
+			names[i] += " (child)"
+		}
+	}
+	return strings.Join(names, "; ")
+}
+
+// formatMealChoices lists the meal chosen by each attendee who picked one.
+func formatMealChoices(attendees []database.Attendee) string {
+	var choices []string
+	for _, attendee := range attendees {
+		if attendee.MealOption != "" {
+			choices = append(choices, attendee.Name+": "+attendee.MealOption)
+		}
+	}
+	return strings.Join(choices, "; ")
+}
+
+// formatDietaryNotes summarizes the dietary restrictions and allergies of the
+// attendees who have any, e.g. "Ada: vegan, allergy: peanuts".
+func formatDietaryNotes(attendees []database.Attendee) string {
+	var notes []string
+	for _, attendee := range attendees {
+		details := attendee.DietaryRestrictions
+		if attendee.AllergyNotes != "" {
+			details = append(details[:len(details):len(details)], "allergy: "+attendee.AllergyNotes)
+		}
+		if len(details) > 0 {
+			notes = append(notes, attendee.Name+": "+strings.Join(details, ", "))
+		}
+	}
+	return strings.Join(notes, "; ")
+}
+
+// exportWriter receives the export one row at a time.
+type exportWriter interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// handlerExportRSVPs streams the filtered guest list as CSV or XLSX. It accepts
+// the same filters as handlerListRSVPs, a format of csv (default) or xlsx, and a
+// comma-separated columns list to pick and order the columns.
+func (cfg *apiConfig) handlerExportRSVPs(w http.ResponseWriter, r *http.Request) {
+	coupleDetails, ok := GetCoupleDetailsFromCtx(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
+		return
+	}
+
+	filter, err := parseRSVPFilter(r, coupleDetails.Side)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	columns, err := parseExportColumns(r.URL.Query().Get("columns"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "csv"
+	}
+
+	filename := "guest-list-" + time.Now().UTC().Format("20060102")
+	var out exportWriter
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+		out = newCSVExportWriter(w)
+	case "xlsx":
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.xlsx"`)
+		xlsx, err := newXLSXExportWriter(w)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not start export", err)
+			return
+		}
+		defer xlsx.file.Close()
+		out = xlsx
+	default:
+		respondWithError(w, http.StatusBadRequest, "format must be csv or xlsx", nil)
+		return
+	}
+
+	headers := make([]any, len(columns))
+	for i, col := range columns {
+		headers[i] = col.header
+	}
+	if err := out.WriteRow(headers); err != nil {
+		cfg.logger.Error("Failed to write export header", "error", err)
+		return
+	}
+
+	err = cfg.db.StreamRSVPs(filter, func(row database.ExportRow) error {
+		values := make([]any, len(columns))
+		for i, col := range columns {
+			values[i] = col.value(row)
+		}
+		return out.WriteRow(values)
+	})
+	if err != nil {
+		if format == "xlsx" {
+			// Nothing has been sent yet: the workbook is only written on Close.
+			w.Header().Del("Content-Disposition")
+			respondWithError(w, http.StatusInternalServerError, "Could not export RSVPs", err)
+			return
+		}
+		// The CSV response has already started, so the client just sees a truncated file.
+		cfg.logger.Error("Failed to stream guest list export", "format", format, "error", err)
+		return
+	}
+
+	if err := out.Close(); err != nil {
+		cfg.logger.Error("Failed to finish guest list export", "format", format, "error", err)
+	}
+}
+
+// parseExportColumns resolves a comma-separated column list, defaulting to every column.
+func parseExportColumns(raw string) ([]exportColumn, error) {
+	if strings.TrimSpace(raw) == "" {
+		return exportColumns, nil
+	}
+
+	byKey := map[string]exportColumn{}
+	for _, col := range exportColumns {
+		byKey[col.key] = col
+	}
+
+	var columns []exportColumn
+	for _, key := range strings.Split(raw, ",") {
+		key = strings.TrimSpace(key)
+		col, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown export column %q", key)
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
+
+// spreadsheetSafe stops guest-supplied text in a CSV from being interpreted as
+// a formula when opened in a spreadsheet application. Phone numbers such as
+// "+234 801 234 5678" are left alone.
+func spreadsheetSafe(s string) string {
+	if s == "" || !strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return s
+	}
+	if strings.Trim(s, "+-0123456789 ()") == "" {
+		return s
+	}
+	return "'" + s
+}
+
+// csvExportWriter writes rows with encoding/csv, flushing periodically so rows
+// reach the client as they are produced.
+type csvExportWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func newCSVExportWriter(w http.ResponseWriter) *csvExportWriter {
+	return &csvExportWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvExportWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case string:
+			record[i] = spreadsheetSafe(v)
+		case int:
+			record[i] = strconv.Itoa(v)
+		case time.Time:
+			record[i] = v.Format(time.RFC3339)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+
+	c.rows++
+	if c.rows%500 == 0 {
+		c.w.Flush()
+		return c.w.Error()
+	}
+	return nil
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// xlsxExportWriter writes rows through excelize's stream writer, which spills to
+// a temporary file instead of keeping the whole sheet in memory.
+type xlsxExportWriter struct {
+	out       http.ResponseWriter
+	file      *excelize.File
+	stream    *excelize.StreamWriter
+	dateStyle int
+	row       int
+}
+
+func newXLSXExportWriter(w http.ResponseWriter) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	dateStyle, err := file.NewStyle(&excelize.Style{NumFmt: 22})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxExportWriter{out: w, file: file, stream: stream, dateStyle: dateStyle}, nil
+}
+
+func (x *xlsxExportWriter) WriteRow(values []any) error {
+	x.row++
+	cells := make([]any, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case time.Time:
+			cells[i] = excelize.Cell{StyleID: x.dateStyle, Value: v}
+		default:
+			cells[i] = v
+		}
+	}
+
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+// Close writes the finished workbook to the response. The caller still has to
+// close x.file to remove excelize's temporary files.
+func (x *xlsxExportWriter) Close() error {
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.out)
+	return err
+}
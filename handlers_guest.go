@@ -0,0 +1,393 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/auth"
+	"github.com/tunedev/bts2025/server/internal/database"
+	"github.com/tunedev/bts2025/server/internal/email"
+)
+
+// handlerRSVPManageStart emails a guest a one-time code for managing their RSVP.
+func (cfg *apiConfig) handlerRSVPManageStart(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	params.Email = strings.ToLower(strings.TrimSpace(params.Email))
+
+	// The response is the same whether or not the email has an RSVP so that
+	// the endpoint cannot be used to discover who has been invited.
+	response := responseStructure{
+		Data:    map[string]any{"message": "If we have an RSVP for that email, a sign-in code is on its way."},
+		Message: "OTP Sent successfully",
+		Success: true,
+	}
+
+	rsvp, err := cfg.db.GetRSVPByEmail(params.Email)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Database error", err)
+		return
+	}
+	if rsvp.ID == uuid.Nil {
+		respondWithJSON(w, http.StatusOK, response)
+		return
+	}
+
+	otp, err := auth.GenerateOTP()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not generate OTP", err)
+		return
+	}
+
+	expiry := time.Now().Add(30 * time.Minute)
+	if err := cfg.db.StoreOTPForRSVP(rsvp.ID, otp, expiry); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not save OTP", err)
+		return
+	}
+
+	link := ""
+	if cfg.siteURL != "" {
+		link = cfg.siteURL + "/rsvp/manage?" + url.Values{"email": {rsvp.Email}, "code": {otp}}.Encode()
+	}
+
+	mailer, err := cfg.categoryMailer(rsvp.CategoryID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Database error", err)
+		return
+	}
+	if _, err := mailer.SendRSVPManageLink(rsvp.Email, rsvp.GuestName, otp, link); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to send OTP email", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerRSVPManageVerify exchanges a guest's one-time code for a session token.
+func (cfg *apiConfig) handlerRSVPManageVerify(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Email string `json:"email"`
+		OTP   string `json:"otp"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	params.Email = strings.ToLower(strings.TrimSpace(params.Email))
+	rsvp, err := cfg.db.VerifyOTPForRSVP(params.Email, params.OTP)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidOTP) {
+			respondWithError(w, http.StatusUnauthorized, "Invalid or expired OTP", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Database error", err)
+		return
+	}
+
+	token, err := auth.MakeGuestJWT(rsvp.ID, cfg.jwtSecret, 24*time.Hour)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create session token", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    map[string]any{"token": token},
+		Success: true,
+		Message: "Login is successful",
+	})
+}
+
+// handlerRSVPManageGet shows the signed-in guest their RSVP.
+func (cfg *apiConfig) handlerRSVPManageGet(w http.ResponseWriter, r *http.Request) {
+	rsvp, ok := GetGuestRSVPFromCtx(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
+		return
+	}
+
+	payload, err := cfg.guestRSVPPayload(rsvp)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVP", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    payload,
+		Message: "RSVP retrieved successfully",
+		Success: true,
+	})
+}
+
+// handlerRSVPManageUpdate lets a guest change their party size, phone number or
+// the events they will attend.
+func (cfg *apiConfig) handlerRSVPManageUpdate(w http.ResponseWriter, r *http.Request) {
+	rsvp, ok := GetGuestRSVPFromCtx(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
+		return
+	}
+
+	type parameters struct {
+		NumberOfGuests *int    `json:"numberOfGuests"`
+		Phone          *string `json:"phone"`
+		// Events replaces the events the party attends when present.
+		Events []uuid.UUID `json:"events"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	if rsvp.Status == "REJECTED" {
+		respondWithError(w, http.StatusForbidden, "This RSVP can no longer be changed", nil)
+		return
+	}
+
+	// Everything is checked before anything is written, and the changes are
+	// then made together, so that a rejected request changes nothing.
+	update := database.UpdateGuestRSVPParams{Events: params.Events}
+	if params.Phone != nil {
+		phone := strings.TrimSpace(*params.Phone)
+		if phone == "" {
+			respondWithError(w, http.StatusBadRequest, "Phone number cannot be empty", nil)
+			return
+		}
+		if phone != rsvp.Phone {
+			update.Phone = &phone
+		}
+	}
+	if params.NumberOfGuests != nil && *params.NumberOfGuests != rsvp.NumberOfGuests {
+		if *params.NumberOfGuests < 1 {
+			respondWithError(w, http.StatusBadRequest, "At least one guest is required.", nil)
+			return
+		}
+		update.NumberOfGuests = params.NumberOfGuests
+	}
+
+	var before []database.Event
+	if params.Events != nil {
+		var err error
+		before, err = cfg.db.ListRSVPEvents(rsvp.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVP", err)
+			return
+		}
+	}
+
+	if _, _, err := cfg.db.UpdateGuestRSVP(rsvp.ID, update); err != nil {
+		switch {
+		case database.IsUniqueConstraintError(err):
+			respondWithError(w, http.StatusConflict, "This phone number has already been used to RSVP.", err)
+		case errors.Is(err, database.ErrTooManyAttendees):
+			respondWithError(w, http.StatusConflict, "Your RSVP names more attendees than that.", err)
+		case errors.Is(err, database.ErrExceedsAllotment):
+			respondWithError(w, http.StatusBadRequest, "Your invitation does not allow that many guests.", err)
+		case errors.Is(err, database.ErrTableFull):
+			respondWithError(w, http.StatusConflict, "There isn't room at your table for that many guests; please contact the couple.", err)
+		case errors.Is(err, database.ErrCategoryFull):
+			respondWithError(w, http.StatusConflict, "There aren't enough remaining spots for that many guests.", err)
+		case errors.Is(err, database.ErrEventNotInvited):
+			respondWithError(w, http.StatusBadRequest, "Your invitation does not include one of the chosen events.", err)
+		case errors.Is(err, database.ErrNoEventsChosen):
+			respondWithError(w, http.StatusBadRequest, "Please choose at least one event to attend.", err)
+		case errors.Is(err, database.ErrEventFull):
+			respondWithError(w, http.StatusConflict, "One of your events has no remaining spots for your party.", err)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Could not update your RSVP.", err)
+		}
+		return
+	}
+
+	var changes []string
+	if update.Phone != nil {
+		changes = append(changes, "changed their phone number to "+*update.Phone)
+	}
+	if update.NumberOfGuests != nil {
+		changes = append(changes, fmt.Sprintf("changed their party size from %d to %d", rsvp.NumberOfGuests, *update.NumberOfGuests))
+	}
+	if params.Events != nil {
+		after, err := cfg.db.ListRSVPEvents(rsvp.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVP", err)
+			return
+		}
+		if names := eventNames(after); names != eventNames(before) {
+			changes = append(changes, "changed the events they will attend to "+names)
+		}
+	}
+
+	updated, err := cfg.db.GetRSVP(rsvp.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVP", err)
+		return
+	}
+
+	if len(changes) > 0 {
+		if updated.Status == "APPROVED" {
+			// Re-send the ticket email so the guest has their current party details.
+			err := cfg.db.QueueEmail(database.QueueEmailParams{
+				Kind:      database.EmailRSVPConfirmed,
+				Recipient: updated.Email,
+				RSVPID:    uuid.NullUUID{UUID: updated.ID, Valid: true},
+				Payload:   database.RSVPEmailPayload{GuestName: updated.GuestName},
+			})
+			if err != nil {
+				cfg.logger.Error("failed to queue RSVP confirmation", "rsvp_id", updated.ID, "error", err)
+			}
+		}
+		cfg.notifyCoupleOfChange(updated, strings.Join(changes, " and "))
+	}
+	// Promotions into seats this change freed were queued along with it.
+	cfg.wakeOutbox()
+
+	payload, err := cfg.guestRSVPPayload(updated)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVP", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    payload,
+		Message: "RSVP updated successfully",
+		Success: true,
+	})
+}
+
+// handlerRSVPManageCancel lets a guest withdraw their RSVP.
+func (cfg *apiConfig) handlerRSVPManageCancel(w http.ResponseWriter, r *http.Request) {
+	rsvp, ok := GetGuestRSVPFromCtx(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
+		return
+	}
+
+	if _, err := cfg.db.DeleteRSVP(rsvp.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not cancel your RSVP.", err)
+		return
+	}
+
+	rsvp.Status = "CANCELLED"
+	cfg.notifyCoupleOfChange(rsvp, "cancelled their RSVP")
+	cfg.wakeOutbox()
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    map[string]any{"message": "Your RSVP has been cancelled."},
+		Message: "RSVP cancelled successfully",
+		Success: true,
+	})
+}
+
+// guestRSVPPayload builds the view of an RSVP shown to the guest who owns it.
+func (cfg *apiConfig) guestRSVPPayload(rsvp database.RSVP) (map[string]any, error) {
+	payload := map[string]any{
+		"name":           rsvp.GuestName,
+		"email":          rsvp.Email,
+		"phone":          rsvp.Phone,
+		"numberOfGuests": rsvp.NumberOfGuests,
+		"status":         rsvp.Status,
+		"submittedAt":    rsvp.SubmittedAt,
+	}
+
+	attendees, err := cfg.db.ListAttendees(rsvp.ID)
+	if err != nil {
+		return nil, err
+	}
+	payload["attendees"] = attendees
+
+	events, err := cfg.db.ListRSVPEvents(rsvp.ID)
+	if err != nil {
+		return nil, err
+	}
+	payload["events"] = cfg.guestEvents(events)
+
+	if rsvp.CategoryID.Valid {
+		category, err := cfg.db.GetCategory(rsvp.CategoryID.UUID)
+		if err != nil {
+			return nil, err
+		}
+		payload["categoryName"] = category.Name
+
+		invited, err := cfg.db.ListCategoryEvents(category.ID)
+		if err != nil {
+			return nil, err
+		}
+		payload["invitedEvents"] = cfg.guestEvents(invited)
+		if !category.DefaultCategory {
+			approvedCount, err := cfg.db.GetApprovedGuestCount(category.ID)
+			if err != nil {
+				return nil, err
+			}
+			payload["remainingGuests"] = category.MaxGuests - approvedCount
+		}
+	}
+
+	if rsvp.Status == "WAITLISTED" {
+		position, err := cfg.db.GetWaitlistPosition(rsvp)
+		if err != nil {
+			return nil, err
+		}
+		payload["waitlistPosition"] = position
+	}
+
+	return payload, nil
+}
+
+// notifyCoupleOfChange queues an email to the couple who owns an RSVP's category
+// about a guest's change.
+func (cfg *apiConfig) notifyCoupleOfChange(rsvp database.RSVP, change string) {
+	if !rsvp.CategoryID.Valid {
+		return
+	}
+
+	category, err := cfg.db.GetCategory(rsvp.CategoryID.UUID)
+	if err != nil || category.ID == uuid.Nil {
+		cfg.logger.Error("failed to look up category for couple notification", "rsvp_id", rsvp.ID, "error", err)
+		return
+	}
+	couple, err := cfg.db.GetCouple(category.CoupleID)
+	if err != nil || couple.ID == uuid.Nil {
+		cfg.logger.Error("failed to look up couple for notification", "rsvp_id", rsvp.ID, "error", err)
+		return
+	}
+
+	err = cfg.db.QueueEmail(database.QueueEmailParams{
+		Kind:      database.EmailRSVPChanged,
+		Recipient: couple.Email,
+		RSVPID:    uuid.NullUUID{UUID: rsvp.ID, Valid: true},
+		Payload: email.SendRSVPChangedParam{
+			CoupleName:     couple.Name,
+			GuestName:      rsvp.GuestName,
+			Change:         change,
+			Status:         rsvp.Status,
+			NumberOfGuests: rsvp.NumberOfGuests,
+		},
+	})
+	if err != nil {
+		cfg.logger.Error("failed to queue couple notification", "rsvp_id", rsvp.ID, "error", err)
+	}
+}
+
+// eventNames lists event names for a change notification, e.g. "Church, Reception".
+func eventNames(events []database.Event) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = event.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/auth"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+// maxGuestCodeAttempts bounds retries when a generated code collides with an existing one.
+const maxGuestCodeAttempts = 5
+
+// handlerListGuests returns the invitees in the couple's side's categories.
+func (cfg *apiConfig) handlerListGuests(w http.ResponseWriter, r *http.Request) {
+	coupleDetails, ok := GetCoupleDetailsFromCtx(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
+		return
+	}
+
+	guests, err := cfg.db.ListGuests(coupleDetails.Side)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve invitees", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    guests,
+		Message: "Retrieved invitees successfully",
+		Success: true,
+	})
+}
+
+// handlerCreateGuest adds an invitee with a personal code. A code is generated
+// unless one is supplied.
+func (cfg *apiConfig) handlerCreateGuest(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name          string    `json:"name"`
+		Email         string    `json:"email"`
+		Phone         string    `json:"phone"`
+		Code          string    `json:"code"`
+		AllottedSeats int       `json:"allottedSeats"`
+		CategoryID    uuid.UUID `json:"categoryId"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	createParams := database.CreateGuestParams{
+		Name:          strings.TrimSpace(params.Name),
+		AllottedSeats: params.AllottedSeats,
+		CategoryID:    params.CategoryID,
+	}
+	if createParams.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "Invitee name is required", nil)
+		return
+	}
+	if createParams.AllottedSeats == 0 {
+		createParams.AllottedSeats = 1
+	}
+	if createParams.AllottedSeats < 1 {
+		respondWithError(w, http.StatusBadRequest, "Allotted seats must be at least 1", nil)
+		return
+	}
+
+	var err error
+	if createParams.Email, err = optionalEmail(params.Email); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	if createParams.Phone, err = optionalPhone(params.Phone); err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	category, err := cfg.db.GetCategory(params.CategoryID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve category", err)
+		return
+	}
+	if category.ID == uuid.Nil || category.DeletedAt != nil || category.CoupleID != coupleID {
+		respondWithError(w, http.StatusBadRequest, "categoryId must be one of your categories", nil)
+		return
+	}
+
+	var guest database.Guest
+	if params.Code != "" {
+		createParams.Code = params.Code
+		guest, err = cfg.db.CreateGuest(createParams)
+		if database.IsUniqueConstraintError(err) {
+			respondWithError(w, http.StatusConflict, "This invitation code is already in use", err)
+			return
+		}
+	} else {
+		for attempt := 0; attempt < maxGuestCodeAttempts; attempt++ {
+			createParams.Code, err = auth.GenerateGuestCode()
+			if err != nil {
+				break
+			}
+			guest, err = cfg.db.CreateGuest(createParams)
+			if !database.IsUniqueConstraintError(err) {
+				break
+			}
+		}
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not create invitee", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, responseStructure{
+		Data:    guest,
+		Message: "Invitee created successfully",
+		Success: true,
+	})
+}
+
+// handlerUpdateGuest changes an invitee's details. Fields left out are unchanged.
+func (cfg *apiConfig) handlerUpdateGuest(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name          *string    `json:"name"`
+		Email         *string    `json:"email"`
+		Phone         *string    `json:"phone"`
+		AllottedSeats *int       `json:"allottedSeats"`
+		CategoryID    *uuid.UUID `json:"categoryId"`
+	}
+
+	guest, ok := cfg.getOwnedGuest(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	var err error
+	if params.Name != nil {
+		guest.Name = strings.TrimSpace(*params.Name)
+		if guest.Name == "" {
+			respondWithError(w, http.StatusBadRequest, "Invitee name is required", nil)
+			return
+		}
+	}
+	if params.Email != nil {
+		if guest.Email, err = optionalEmail(*params.Email); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+	if params.Phone != nil {
+		if guest.Phone, err = optionalPhone(*params.Phone); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+	if params.AllottedSeats != nil {
+		if *params.AllottedSeats < 1 {
+			respondWithError(w, http.StatusBadRequest, "Allotted seats must be at least 1", nil)
+			return
+		}
+		guest.AllottedSeats = *params.AllottedSeats
+	}
+	if params.CategoryID != nil && *params.CategoryID != guest.CategoryID {
+		if guest.RSVPID.Valid {
+			respondWithError(w, http.StatusConflict, "This invitee has already responded; move their RSVP instead", nil)
+			return
+		}
+		coupleID, _ := GetCoupleIDFromContext(r.Context())
+		category, err := cfg.db.GetCategory(*params.CategoryID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not retrieve category", err)
+			return
+		}
+		if category.ID == uuid.Nil || category.DeletedAt != nil || category.CoupleID != coupleID {
+			respondWithError(w, http.StatusBadRequest, "categoryId must be one of your categories", nil)
+			return
+		}
+		guest.CategoryID = category.ID
+	}
+
+	updated, err := cfg.db.UpdateGuest(guest)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not update invitee", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    updated,
+		Message: "Invitee updated successfully",
+		Success: true,
+	})
+}
+
+// handlerDeleteGuest removes an invitee. Any RSVP they submitted is kept.
+func (cfg *apiConfig) handlerDeleteGuest(w http.ResponseWriter, r *http.Request) {
+	guest, ok := cfg.getOwnedGuest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := cfg.db.DeleteGuest(guest.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete invitee", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Message: "Invitee deleted successfully",
+		Success: true,
+	})
+}
+
+// getOwnedGuest loads the invitee named in the {id} path segment, responding with
+// an error unless it belongs to one of the signed-in couple's categories.
+func (cfg *apiConfig) getOwnedGuest(w http.ResponseWriter, r *http.Request) (database.Guest, bool) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	guestID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid invitee ID", err)
+		return database.Guest{}, false
+	}
+
+	guest, err := cfg.db.GetGuest(guestID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve invitee", err)
+		return database.Guest{}, false
+	}
+	if guest.ID == uuid.Nil {
+		respondWithError(w, http.StatusNotFound, "Invitee not found", nil)
+		return database.Guest{}, false
+	}
+
+	category, err := cfg.db.GetCategory(guest.CategoryID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve category", err)
+		return database.Guest{}, false
+	}
+	if category.CoupleID != coupleID {
+		respondWithError(w, http.StatusNotFound, "Invitee not found", nil)
+		return database.Guest{}, false
+	}
+
+	return guest, true
+}
+
+// optionalEmail normalizes an email that may be left blank.
+func optionalEmail(raw string) (*string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	email, err := normalizeEmail(raw)
+	if err != nil {
+		return nil, errors.New("invitee " + err.Error())
+	}
+	return &email, nil
+}
+
+// optionalPhone normalizes a phone number that may be left blank.
+func optionalPhone(raw string) (*string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	phone, err := normalizePhone(raw)
+	if err != nil {
+		return nil, errors.New("invitee " + err.Error())
+	}
+	return &phone, nil
+}
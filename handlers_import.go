@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+const (
+	maxImportFileSize = 5 << 20
+	maxImportRows     = 5000
+)
+
+// importHeaderAliases maps the column headings accepted in an import file onto
+// the field they fill.
+var importHeaderAliases = map[string]string{
+	"name":             "name",
+	"guest_name":       "name",
+	"full_name":        "name",
+	"email":            "email",
+	"email_address":    "email",
+	"phone":            "phone",
+	"phone_number":     "phone",
+	"guests":           "guests",
+	"number_of_guests": "guests",
+	"party_size":       "guests",
+}
+
+// handlerImportRSVPs creates RSVPs in bulk from a CSV file with a header row of
+// name, email, phone and optionally guests. The file is sent either as the
+// "file" field of a multipart form or as the raw request body. Query parameters:
+// categoryId (required), dryRun=true to only report what would happen, and
+// notify=true to email imported guests as if they had submitted the form.
+func (cfg *apiConfig) handlerImportRSVPs(w http.ResponseWriter, r *http.Request) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+	query := r.URL.Query()
+
+	categoryID, err := uuid.Parse(query.Get("categoryId"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "A valid categoryId is required", err)
+		return
+	}
+	category, err := cfg.db.GetCategory(categoryID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve category", err)
+		return
+	}
+	if category.ID == uuid.Nil || category.DeletedAt != nil || category.CoupleID != coupleID {
+		respondWithError(w, http.StatusNotFound, "Category not found", nil)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(query.Get("dryRun"))
+	notify, _ := strconv.ParseBool(query.Get("notify"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+	var file io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		upload, _, err := r.FormFile("file")
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Upload the CSV in a form field named \"file\"", err)
+			return
+		}
+		defer upload.Close()
+		file = upload
+	}
+
+	rows, err := parseImportCSV(file)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	results, err := cfg.db.ImportRSVPs(category.ID, rows, dryRun, notify)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not import RSVPs", err)
+		return
+	}
+
+	summary := map[string]int{
+		database.ImportCreated: 0,
+		database.ImportSkipped: 0,
+		database.ImportInvalid: 0,
+	}
+	for _, result := range results {
+		summary[result.Outcome]++
+	}
+	if notify && !dryRun {
+		cfg.wakeOutbox()
+	}
+
+	message := "Guest list imported"
+	if dryRun {
+		message = "Dry run complete; nothing was saved"
+	}
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data: map[string]any{
+			"dryRun":  dryRun,
+			"summary": summary,
+			"rows":    results,
+		},
+		Message: message,
+		Success: true,
+	})
+}
+
+// parseImportCSV reads and normalizes the rows of an import file. Problems with
+// individual rows are recorded on the row; only an unreadable file is an error.
+func parseImportCSV(file io.Reader) ([]database.ImportRow, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("the CSV file is empty")
+		}
+		return nil, errors.New("could not read the CSV header row")
+	}
+
+	columns := map[string]int{}
+	for i, heading := range header {
+		heading = strings.TrimPrefix(heading, "\ufeff") // Excel's UTF-8 byte order mark
+		key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(heading)), " ", "_")
+		if field, ok := importHeaderAliases[key]; ok {
+			if _, dup := columns[field]; !dup {
+				columns[field] = i
+			}
+		}
+	}
+	for _, required := range []string{"name", "email", "phone"} {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.New("the CSV header must include name, email and phone columns")
+		}
+	}
+
+	var rows []database.ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, errors.New("import files are limited to " + strconv.Itoa(maxImportRows) + " rows")
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, normalizeImportRecord(line, record, columns))
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("the CSV file has no guest rows")
+	}
+	return rows, nil
+}
+
+func normalizeImportRecord(line int, record []string, columns map[string]int) database.ImportRow {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row := database.ImportRow{
+		Line:           line,
+		GuestName:      field("name"),
+		Email:          field("email"),
+		Phone:          field("phone"),
+		NumberOfGuests: 1,
+	}
+
+	if row.GuestName == "" {
+		row.Error = "name is required"
+		return row
+	}
+
+	email, err := normalizeEmail(row.Email)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Email = email
+
+	phone, err := normalizePhone(row.Phone)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	row.Phone = phone
+
+	if guests := field("guests"); guests != "" {
+		n, err := strconv.Atoi(guests)
+		if err != nil || n < 1 {
+			row.Error = "guests must be a whole number of at least 1"
+			return row
+		}
+		row.NumberOfGuests = n
+	}
+
+	return row
+}
+
+// normalizeEmail lower-cases and validates a bare email address.
+func normalizeEmail(raw string) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(raw))
+	if email == "" {
+		return "", errors.New("email is required")
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", errors.New("email is not a valid address")
+	}
+	return email, nil
+}
+
+// normalizePhone strips formatting characters from a phone number, turning a
+// leading 00 into +, and checks that 7 to 15 digits remain.
+func normalizePhone(raw string) (string, error) {
+	phone := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '\t':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+	if phone == "" {
+		return "", errors.New("phone is required")
+	}
+	if strings.HasPrefix(phone, "00") {
+		phone = "+" + phone[2:]
+	}
+
+	digits := strings.TrimPrefix(phone, "+")
+	if len(digits) < 7 || len(digits) > 15 || strings.Trim(digits, "0123456789") != "" {
+		return "", errors.New("phone must contain 7 to 15 digits")
+	}
+	return phone, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+// handlerListMealOptions returns every meal option, including withdrawn ones.
+func (cfg *apiConfig) handlerListMealOptions(w http.ResponseWriter, r *http.Request) {
+	options, err := cfg.db.ListMealOptions(false)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve meal options", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    options,
+		Message: "Retrieved meal options successfully",
+		Success: true,
+	})
+}
+
+// handlerPublicMealOptions returns the meal options guests can choose from.
+func (cfg *apiConfig) handlerPublicMealOptions(w http.ResponseWriter, r *http.Request) {
+	options, err := cfg.db.ListMealOptions(true)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve meal options", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    options,
+		Message: "Retrieved meal options successfully",
+		Success: true,
+	})
+}
+
+// handlerCreateMealOption adds a meal option to the menu.
+func (cfg *apiConfig) handlerCreateMealOption(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		respondWithError(w, http.StatusBadRequest, "Meal option name is required", nil)
+		return
+	}
+
+	option, err := cfg.db.CreateMealOption(database.CreateMealOptionParams{
+		Name:        name,
+		Description: strings.TrimSpace(params.Description),
+	})
+	if err != nil {
+		if database.IsUniqueConstraintError(err) {
+			respondWithError(w, http.StatusConflict, "A meal option with this name already exists", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not create meal option", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, responseStructure{
+		Data:    option,
+		Message: "Meal option created successfully",
+		Success: true,
+	})
+}
+
+// handlerUpdateMealOption renames a meal option or changes whether guests can
+// still choose it. Fields left out are unchanged.
+func (cfg *apiConfig) handlerUpdateMealOption(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Available   *bool   `json:"available"`
+	}
+
+	option, ok := cfg.getMealOption(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	if params.Name != nil {
+		option.Name = strings.TrimSpace(*params.Name)
+		if option.Name == "" {
+			respondWithError(w, http.StatusBadRequest, "Meal option name is required", nil)
+			return
+		}
+	}
+	if params.Description != nil {
+		option.Description = strings.TrimSpace(*params.Description)
+	}
+	if params.Available != nil {
+		option.Available = *params.Available
+	}
+
+	updated, err := cfg.db.UpdateMealOption(option)
+	if err != nil {
+		if database.IsUniqueConstraintError(err) {
+			respondWithError(w, http.StatusConflict, "A meal option with this name already exists", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not update meal option", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    updated,
+		Message: "Meal option updated successfully",
+		Success: true,
+	})
+}
+
+// handlerDeleteMealOption removes a meal option nobody has chosen yet.
+func (cfg *apiConfig) handlerDeleteMealOption(w http.ResponseWriter, r *http.Request) {
+	option, ok := cfg.getMealOption(w, r)
+	if !ok {
+		return
+	}
+
+	if err := cfg.db.DeleteMealOption(option.ID); err != nil {
+		if errors.Is(err, database.ErrMealOptionInUse) {
+			respondWithError(w, http.StatusConflict, "Guests have already chosen this meal option; mark it unavailable instead", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not delete meal option", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Message: "Meal option deleted successfully",
+		Success: true,
+	})
+}
+
+// getMealOption loads the meal option named in the {id} path segment,
+// responding with an error if it does not exist.
+func (cfg *apiConfig) getMealOption(w http.ResponseWriter, r *http.Request) (database.MealOption, bool) {
+	optionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid meal option ID", err)
+		return database.MealOption{}, false
+	}
+
+	option, err := cfg.db.GetMealOption(optionID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve meal option", err)
+		return database.MealOption{}, false
+	}
+	if option.ID == uuid.Nil {
+		respondWithError(w, http.StatusNotFound, "Meal option not found", nil)
+		return database.MealOption{}, false
+	}
+
+	return option, true
+}
+
+// handlerCateringReport returns approved meal choices and dietary needs per
+// category and side for the caterer.
+func (cfg *apiConfig) handlerCateringReport(w http.ResponseWriter, r *http.Request) {
+	report, err := cfg.db.GetCateringReport()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve catering report", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    report,
+		Message: "Catering report retrieved successfully",
+		Success: true,
+	})
+}
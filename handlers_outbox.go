@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+const (
+	defaultOutboxListLimit = 100
+	maxOutboxListLimit     = 500
+)
+
+// handlerListOutboxEmails lists the emails queued for and delivered to the
+// signed-in couple's guests, newest first. The optional status filter is one of
+// PENDING, SENT, SKIPPED or FAILED.
+func (cfg *apiConfig) handlerListOutboxEmails(w http.ResponseWriter, r *http.Request) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", "PENDING", "SENT", "SKIPPED", "FAILED":
+	default:
+		respondWithError(w, http.StatusBadRequest, "status must be one of PENDING, SENT, SKIPPED or FAILED", nil)
+		return
+	}
+
+	limit := defaultOutboxListLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxOutboxListLimit {
+			respondWithError(w, http.StatusBadRequest, "limit must be a number between 1 and "+strconv.Itoa(maxOutboxListLimit), err)
+			return
+		}
+		limit = n
+	}
+
+	emails, err := cfg.db.ListOutboxEmails(coupleID, status, limit)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve emails", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    emails,
+		Message: "Retrieved emails successfully",
+		Success: true,
+	})
+}
+
+// handlerResendOutboxEmail queues a FAILED email to one of the signed-in couple's
+// guests for another round of delivery attempts.
+func (cfg *apiConfig) handlerResendOutboxEmail(w http.ResponseWriter, r *http.Request) {
+	coupleID, _ := GetCoupleIDFromContext(r.Context())
+
+	emailID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid email ID", err)
+		return
+	}
+
+	existing, err := cfg.db.GetOutboxEmail(emailID, coupleID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve email", err)
+		return
+	}
+	if existing.ID == uuid.Nil {
+		respondWithError(w, http.StatusNotFound, "Email not found", nil)
+		return
+	}
+
+	queued, err := cfg.db.RetryOutboxEmail(emailID, coupleID)
+	if err != nil {
+		if errors.Is(err, database.ErrEmailNotFailed) {
+			respondWithError(w, http.StatusConflict, "Only failed emails can be re-sent", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not re-send email", err)
+		return
+	}
+	cfg.wakeOutbox()
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    queued,
+		Message: "Email queued for re-sending",
+		Success: true,
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+func TestHandlerOutboxIsScopedToCouple(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	bride, brideCategory := newTestCouple(t, cfg, "BRIDE")
+	groom, groomCategory := newTestCouple(t, cfg, "GROOM")
+
+	// Each approved RSVP queues its guest's confirmation email.
+	brideGuest := newTestRSVP(t, cfg, brideCategory, "APPROVED", 1)
+	groomGuest := newTestRSVP(t, cfg, groomCategory, "APPROVED", 1)
+
+	list := func(couple database.Couple) []database.OutboxEmail {
+		t.Helper()
+		r := asCouple(httptest.NewRequest(http.MethodGet, "/api/admin/emails", nil), couple)
+		w := httptest.NewRecorder()
+		cfg.handlerListOutboxEmails(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+		}
+		var resp struct {
+			Data []database.OutboxEmail `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		return resp.Data
+	}
+
+	brideEmails := list(bride)
+	if len(brideEmails) != 1 || brideEmails[0].RSVPID.UUID != brideGuest.ID {
+		t.Fatalf("bride's emails = %+v, want only the one to %s", brideEmails, brideGuest.Email)
+	}
+	groomEmails := list(groom)
+	if len(groomEmails) != 1 || groomEmails[0].RSVPID.UUID != groomGuest.ID {
+		t.Fatalf("groom's emails = %+v, want only the one to %s", groomEmails, groomGuest.Email)
+	}
+
+	tests := []struct {
+		name     string
+		couple   database.Couple
+		emailID  uuid.UUID
+		wantCode int
+	}{
+		{"another couple's email", groom, brideEmails[0].ID, http.StatusNotFound},
+		{"own email that has not failed", bride, brideEmails[0].ID, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/admin/emails/"+tt.emailID.String()+"/resend", nil)
+			r.SetPathValue("id", tt.emailID.String())
+			w := httptest.NewRecorder()
+			cfg.handlerResendOutboxEmail(w, asCouple(r, tt.couple))
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+}
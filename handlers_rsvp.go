@@ -2,30 +2,40 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/tunedev/bts2025/server/internal/database"
-	"github.com/tunedev/bts2025/server/internal/email"
 )
 
-// handlerGetCategoryMeta fetches public data for an RSVP link
+// handlerGetCategoryMeta fetches public data for an RSVP link, identified either
+// by a category invitation token or by an invitee's personal code.
 func (cfg *apiConfig) handlerGetCategoryMeta(w http.ResponseWriter, r *http.Request) {
 	token := r.URL.Query().Get("token")
-	if token == "" {
-		respondWithError(w, http.StatusBadRequest, "Invitation token is required", nil)
+	code := r.URL.Query().Get("code")
+	if token == "" && code == "" {
+		respondWithError(w, http.StatusBadRequest, "Invitation token or code is required", nil)
 		return
 	}
 
-	parsedToken, err := uuid.Parse(token)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid rsvp link", err)
-		return
+	var category database.GuestCategory
+	var guest database.Guest
+	var err error
+	if code != "" {
+		guest, err = cfg.db.GetGuestByCode(code)
+		if err != nil || guest.ID == uuid.Nil {
+			respondWithError(w, http.StatusNotFound, "invalid invitation code", err)
+			return
+		}
+		category, err = cfg.db.GetCategory(guest.CategoryID)
+	} else {
+		category, err = cfg.db.GetCategoryByInvitationToken(token)
 	}
-	category, err := cfg.db.GetCategory(parsedToken)
-	if err != nil {
+	if err != nil || category.ID == uuid.Nil {
 		respondWithError(w, http.StatusNotFound, "invalid rsvp link", err)
 		return
 	}
@@ -38,11 +48,23 @@ func (cfg *apiConfig) handlerGetCategoryMeta(w http.ResponseWriter, r *http.Requ
 	}
 	remainingSpots := category.MaxGuests - approvedCount
 
+	events, err := cfg.db.ListCategoryEvents(category.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve events", err)
+		return
+	}
+
 	// Prepare the data payload for the frontend
 	payload := map[string]interface{}{
 		"name":            category.Name,
 		"side":            category.Side,
 		"remainingGuests": remainingSpots,
+		"events":          cfg.guestEvents(events),
+	}
+	if guest.ID != uuid.Nil {
+		payload["guestName"] = guest.Name
+		payload["allottedSeats"] = guest.AllottedSeats
+		payload["responded"] = guest.RSVPID.Valid
 	}
 
 	respondWithJSON(w, http.StatusOK, responseStructure{
@@ -59,7 +81,13 @@ func (cfg *apiConfig) handlerSubmitRSVP(w http.ResponseWriter, r *http.Request)
 		Phone        string `json:"phone"`
 		Guests       int    `json:"guests"`
 		Token        string `json:"token"`
+		Code         string `json:"code"`
 		SelectedSide string `json:"selectedSide"`
+		// Attendees optionally names the people in the party.
+		Attendees []attendeeInput `json:"attendees"`
+		// Events lists the events the party will attend; left out, the party
+		// attends every event the invitation covers.
+		Events []uuid.UUID `json:"events"`
 	}
 
 	params := parameters{}
@@ -68,33 +96,79 @@ func (cfg *apiConfig) handlerSubmitRSVP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var categoryID uuid.NullUUID
-	var err error
-	status := "PENDING"
-
-	// Logic Branch 1: Guest used a direct invitation link with a token
-	if params.Token != "" {
-		parsedToken, err := uuid.Parse(params.Token)
-		if err != nil {
-			respondWithError(w, http.StatusBadRequest, "malformed request, unable to parse token to uuid", err)
+	// A personal code identifies a pre-invited guest: their details fill in any
+	// blanks and their allotment caps the party size.
+	var invitee database.Guest
+	if params.Code != "" {
+		guest, err := cfg.db.GetGuestByCode(params.Code)
+		if err != nil || guest.ID == uuid.Nil {
+			respondWithError(w, http.StatusNotFound, "Invalid invitation code.", err)
 			return
 		}
-		category, err := cfg.db.GetCategory(parsedToken)
-		if err != nil {
+		if guest.RSVPID.Valid {
+			respondWithError(w, http.StatusConflict, "This invitation has already been used to RSVP.", nil)
+			return
+		}
+		if params.Name == "" {
+			params.Name = guest.Name
+		}
+		if params.Email == "" && guest.Email != nil {
+			params.Email = *guest.Email
+		}
+		if params.Phone == "" && guest.Phone != nil {
+			params.Phone = *guest.Phone
+		}
+		if params.Guests == 0 {
+			params.Guests = guest.AllottedSeats
+		}
+		if params.Guests > guest.AllottedSeats {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("This invitation is for at most %d guests.", guest.AllottedSeats), nil)
+			return
+		}
+		invitee = guest
+	}
+
+	if params.Guests < 1 {
+		respondWithError(w, http.StatusBadRequest, "At least one guest is required.", nil)
+		return
+	}
+
+	// Emails are stored lower-cased, which is how guests are found when they
+	// sign in to manage their RSVP.
+	normalizedEmail, err := normalizeEmail(params.Email)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "A valid email address is required.", err)
+		return
+	}
+	params.Email = normalizedEmail
+
+	attendees, err := parseAttendees(params.Attendees, params.Guests)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	var categoryID uuid.NullUUID
+	// Invitation links are capacity-limited and approved automatically while
+	// seats remain; side-default RSVPs always wait for the couple's review.
+	capacityLimited := false
+
+	if invitee.ID != uuid.Nil {
+		// Personal invitations always use the invitee's own category.
+		categoryID = uuid.NullUUID{UUID: invitee.CategoryID, Valid: true}
+	} else if params.Token != "" {
+		// Guest used a direct invitation link with a token
+		category, err := cfg.db.GetCategoryByInvitationToken(params.Token)
+		if err != nil || category.ID == uuid.Nil {
 			respondWithError(w, http.StatusNotFound, "Invalid invitation link.", err)
 			return
 		}
 
 		categoryID = uuid.NullUUID{UUID: category.ID, Valid: true}
-		approvedCount, _ := cfg.db.GetApprovedGuestCount(category.ID)
-		if approvedCount+params.Guests > category.MaxGuests {
-			status = "PENDING"
-		} else {
-			status = "APPROVED"
-		}
+		capacityLimited = true
 	} else if params.SelectedSide != "" {
 		defaultSideCategory, err := cfg.db.GetCategoryBySideDefault(params.SelectedSide)
-		if err != nil {
+		if err != nil || defaultSideCategory.ID == uuid.Nil {
 			respondWithError(w, http.StatusBadRequest, "invalid side value sent", err)
 			return
 		}
@@ -102,7 +176,6 @@ func (cfg *apiConfig) handlerSubmitRSVP(w http.ResponseWriter, r *http.Request)
 			UUID:  defaultSideCategory.ID,
 			Valid: true,
 		}
-		status = "PENDING"
 	} else {
 		respondWithError(w, http.StatusBadRequest, "Missing required RSVP information.", err)
 		return
@@ -114,12 +187,41 @@ func (cfg *apiConfig) handlerSubmitRSVP(w http.ResponseWriter, r *http.Request)
 		Email:          params.Email,
 		Phone:          params.Phone,
 		CategoryID:     categoryID,
+		Attendees:      attendees,
+		Events:         params.Events,
 	}
 
-	newRSVP, err := cfg.db.CreateRSVP(rsvpParams, status)
+	var newRSVP database.RSVP
+	if invitee.ID != uuid.Nil {
+		newRSVP, err = cfg.db.CreateRSVPForGuest(invitee.ID, rsvpParams)
+	} else if capacityLimited {
+		newRSVP, err = cfg.db.CreateRSVPWithinCapacity(rsvpParams)
+	} else {
+		newRSVP, err = cfg.db.CreateRSVP(rsvpParams, "PENDING")
+	}
 	if err != nil {
 		log.Printf("Error creating RSVP: %v", err)
-		if isUniqueConstraintError(err) {
+		switch {
+		case errors.Is(err, database.ErrGuestAlreadyResponded):
+			respondWithError(w, http.StatusConflict, "This invitation has already been used to RSVP.", err)
+			return
+		case errors.Is(err, database.ErrExceedsAllotment):
+			respondWithError(w, http.StatusBadRequest, "This invitation does not allow that many guests.", err)
+			return
+		case errors.Is(err, database.ErrMealOptionUnavailable):
+			respondWithError(w, http.StatusBadRequest, "One of the chosen meals is no longer available.", err)
+			return
+		case errors.Is(err, database.ErrTooManyAttendees):
+			respondWithError(w, http.StatusBadRequest, "More attendees were named than the number of guests.", err)
+			return
+		case errors.Is(err, database.ErrEventNotInvited):
+			respondWithError(w, http.StatusBadRequest, "Your invitation does not include one of the chosen events.", err)
+			return
+		case errors.Is(err, database.ErrNoEventsChosen):
+			respondWithError(w, http.StatusBadRequest, "Please choose at least one event to attend.", err)
+			return
+		}
+		if database.IsUniqueConstraintError(err) {
 			respondWithError(w, http.StatusConflict, "This email or phone number has already been used to RSVP.", err)
 			return
 		}
@@ -127,26 +229,99 @@ func (cfg *apiConfig) handlerSubmitRSVP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	switch newRSVP.Status {
-	case "APPROVED":
-		cfg.mailer.SendRSVPConfirmed(newRSVP.Email, email.SendRSVPConfirmedParam{
-			GuestName:      newRSVP.GuestName,
-			Phone:          newRSVP.Phone,
-			RSVPID:         newRSVP.ID.String(),
-			NumberOfGuests: newRSVP.NumberOfGuests,
-		})
-	case "PENDING":
-		cfg.mailer.SendRSVPReceived(newRSVP.Email, newRSVP.GuestName)
+	// The email announcing the RSVP's status was queued along with it.
+	cfg.wakeOutbox()
+
+	payload := map[string]any{"status": newRSVP.Status}
+	if newRSVP.Status == "WAITLISTED" {
+		position, err := cfg.db.GetWaitlistPosition(newRSVP)
+		if err != nil {
+			cfg.logger.Error("failed to compute waitlist position", "rsvp_id", newRSVP.ID, "error", err)
+		}
+		payload["waitlistPosition"] = position
 	}
 
 	respondWithJSON(w, http.StatusCreated, responseStructure{
-		Data:    map[string]string{"status": newRSVP.Status},
+		Data:    payload,
 		Message: "Status retrieved successfully",
 		Success: true,
 	})
 }
 
-func isUniqueConstraintError(err error) bool {
+const (
+	maxAttendeeNameLength  = 100
+	maxDietaryRestrictions = 10
+	maxDietaryTagLength    = 40
+	maxAllergyNotesLength  = 500
+)
+
+// attendeeInput is one member of the party as submitted on the RSVP form.
+type attendeeInput struct {
+	Name                string   `json:"name"`
+	AgeGroup            string   `json:"ageGroup"`
+	DietaryRestrictions []string `json:"dietaryRestrictions"`
+	AllergyNotes        string   `json:"allergyNotes"`
+	// MealOptionID is one of the options listed at /api/rsvp/meal-options.
+	MealOptionID *uuid.UUID `json:"mealOptionId"`
+}
+
+// parseAttendees validates the attendees named on an RSVP for a party of
+// numberOfGuests. Age groups default to adult, and dietary restrictions are
+// lower-cased and de-duplicated tags.
+func parseAttendees(inputs []attendeeInput, numberOfGuests int) ([]database.AttendeeParams, error) {
+	if len(inputs) > numberOfGuests {
+		return nil, fmt.Errorf("%d attendees were named for a party of %d", len(inputs), numberOfGuests)
+	}
+
+	attendees := make([]database.AttendeeParams, 0, len(inputs))
+	for i, input := range inputs {
+		attendee := database.AttendeeParams{
+			Name:         strings.TrimSpace(input.Name),
+			AgeGroup:     strings.ToUpper(strings.TrimSpace(input.AgeGroup)),
+			AllergyNotes: strings.TrimSpace(input.AllergyNotes),
+		}
+
+		if attendee.Name == "" {
+			return nil, fmt.Errorf("attendee %d needs a name", i+1)
+		}
+		if len(attendee.Name) > maxAttendeeNameLength {
+			return nil, fmt.Errorf("attendee %d's name is too long", i+1)
+		}
+
+		switch attendee.AgeGroup {
+		case "":
+			attendee.AgeGroup = database.AgeGroupAdult
+		case database.AgeGroupAdult, database.AgeGroupChild:
+		default:
+			return nil, fmt.Errorf("attendee %d's age group must be adult or child", i+1)
+		}
+
+		if len(input.DietaryRestrictions) > maxDietaryRestrictions {
+			return nil, fmt.Errorf("attendee %d has too many dietary restrictions", i+1)
+		}
+		seen := map[string]bool{}
+		for _, tag := range input.DietaryRestrictions {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag == "" || seen[tag] {
+				continue
+			}
+			if len(tag) > maxDietaryTagLength || strings.Contains(tag, ",") {
+				return nil, fmt.Errorf("attendee %d has an invalid dietary restriction %q", i+1, tag)
+			}
+			seen[tag] = true
+			attendee.DietaryRestrictions = append(attendee.DietaryRestrictions, tag)
+		}
+
+		if len(attendee.AllergyNotes) > maxAllergyNotesLength {
+			return nil, fmt.Errorf("attendee %d's allergy notes are too long", i+1)
+		}
+
+		if input.MealOptionID != nil {
+			attendee.MealOptionID = uuid.NullUUID{UUID: *input.MealOptionID, Valid: true}
+		}
+
+		attendees = append(attendees, attendee)
+	}
 
-	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+	return attendees, nil
 }
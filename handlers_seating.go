@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+// handlerListSeatingTables returns every table with the guests seated at it.
+func (cfg *apiConfig) handlerListSeatingTables(w http.ResponseWriter, r *http.Request) {
+	tables, err := cfg.db.ListSeatingTables()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve tables", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    tables,
+		Message: "Retrieved tables successfully",
+		Success: true,
+	})
+}
+
+// handlerCreateSeatingTable adds a reception table.
+func (cfg *apiConfig) handlerCreateSeatingTable(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Number   int    `json:"number"`
+		Name     string `json:"name"`
+		Capacity int    `json:"capacity"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	if params.Number < 1 {
+		respondWithError(w, http.StatusBadRequest, "Table number must be at least 1", nil)
+		return
+	}
+	if params.Capacity < 1 {
+		respondWithError(w, http.StatusBadRequest, "Table capacity must be at least 1", nil)
+		return
+	}
+
+	table, err := cfg.db.CreateSeatingTable(database.CreateSeatingTableParams{
+		Number:   params.Number,
+		Name:     strings.TrimSpace(params.Name),
+		Capacity: params.Capacity,
+	})
+	if err != nil {
+		if database.IsUniqueConstraintError(err) {
+			respondWithError(w, http.StatusConflict, "A table with this number already exists", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not create table", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, responseStructure{
+		Data:    table,
+		Message: "Table created successfully",
+		Success: true,
+	})
+}
+
+// handlerUpdateSeatingTable renumbers, renames or resizes a table. Fields left
+// out are unchanged.
+func (cfg *apiConfig) handlerUpdateSeatingTable(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Number   *int    `json:"number"`
+		Name     *string `json:"name"`
+		Capacity *int    `json:"capacity"`
+	}
+
+	table, ok := cfg.getSeatingTable(w, r)
+	if !ok {
+		return
+	}
+
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	if params.Number != nil {
+		if *params.Number < 1 {
+			respondWithError(w, http.StatusBadRequest, "Table number must be at least 1", nil)
+			return
+		}
+		table.Number = *params.Number
+	}
+	if params.Name != nil {
+		table.Name = strings.TrimSpace(*params.Name)
+	}
+	if params.Capacity != nil {
+		if *params.Capacity < 1 {
+			respondWithError(w, http.StatusBadRequest, "Table capacity must be at least 1", nil)
+			return
+		}
+		table.Capacity = *params.Capacity
+	}
+
+	updated, err := cfg.db.UpdateSeatingTable(table)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrCapacityBelowSeated):
+			respondWithError(w, http.StatusConflict, "More guests are already seated at this table than that capacity", err)
+		case database.IsUniqueConstraintError(err):
+			respondWithError(w, http.StatusConflict, "A table with this number already exists", err)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "Could not update table", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    updated,
+		Message: "Table updated successfully",
+		Success: true,
+	})
+}
+
+// handlerDeleteSeatingTable removes a table, unseating everyone at it.
+func (cfg *apiConfig) handlerDeleteSeatingTable(w http.ResponseWriter, r *http.Request) {
+	table, ok := cfg.getSeatingTable(w, r)
+	if !ok {
+		return
+	}
+
+	if err := cfg.db.DeleteSeatingTable(table.ID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete table", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Message: "Table deleted successfully",
+		Success: true,
+	})
+}
+
+// handlerAssignRSVPTable seats a whole approved party at a table.
+func (cfg *apiConfig) handlerAssignRSVPTable(w http.ResponseWriter, r *http.Request) {
+	rsvpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid RSVP ID", err)
+		return
+	}
+
+	rsvp, err := cfg.db.GetRSVP(rsvpID)
+	if err != nil || rsvp.ID == uuid.Nil {
+		respondWithError(w, http.StatusNotFound, "RSVP not found", err)
+		return
+	}
+
+	table, ok := cfg.decodeSeatingTable(w, r)
+	if !ok {
+		return
+	}
+
+	if err := cfg.db.AssignRSVPToTable(table.ID, rsvp.ID); err != nil {
+		respondWithSeatingError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Message: "Party seated successfully",
+		Success: true,
+	})
+}
+
+// handlerUnassignRSVPTable removes a party, and any of its individually seated
+// attendees, from their tables.
+func (cfg *apiConfig) handlerUnassignRSVPTable(w http.ResponseWriter, r *http.Request) {
+	rsvpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid RSVP ID", err)
+		return
+	}
+
+	if err := cfg.db.UnseatRSVP(rsvpID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not unseat party", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Message: "Party unseated successfully",
+		Success: true,
+	})
+}
+
+// handlerAssignAttendeeTable seats one named attendee at a table, for parties
+// that are split across tables.
+func (cfg *apiConfig) handlerAssignAttendeeTable(w http.ResponseWriter, r *http.Request) {
+	attendeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid attendee ID", err)
+		return
+	}
+
+	table, ok := cfg.decodeSeatingTable(w, r)
+	if !ok {
+		return
+	}
+
+	if err := cfg.db.AssignAttendeeToTable(table.ID, attendeeID); err != nil {
+		respondWithSeatingError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Message: "Attendee seated successfully",
+		Success: true,
+	})
+}
+
+// handlerUnassignAttendeeTable removes an individually seated attendee from their table.
+func (cfg *apiConfig) handlerUnassignAttendeeTable(w http.ResponseWriter, r *http.Request) {
+	attendeeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid attendee ID", err)
+		return
+	}
+
+	if err := cfg.db.UnseatAttendee(attendeeID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not unseat attendee", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Message: "Attendee unseated successfully",
+		Success: true,
+	})
+}
+
+// seatingRosterTemplate renders one printable page per table.
+var seatingRosterTemplate = template.Must(template.New("roster").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Seating Roster</title>
+<style>
+  body { font-family: Georgia, 'Times New Roman', serif; margin: 2em; }
+  section { page-break-after: always; }
+  section:last-child { page-break-after: auto; }
+  h2 { margin-bottom: 0.2em; }
+  .seats { color: #555; margin-top: 0; }
+  table { border-collapse: collapse; width: 100%; }
+  th, td { border-bottom: 1px solid #ccc; padding: 6px 4px; text-align: left; }
+  td.count { text-align: right; }
+</style>
+</head>
+<body>
+{{range .}}<section>
+  <h2>Table {{.Number}}{{if .Name}} &middot; {{.Name}}{{end}}</h2>
+  <p class="seats">{{.SeatsTaken}} of {{.Capacity}} seats taken</p>
+  {{if .Assignments}}<table>
+    <tr><th>Guest</th><th>Category</th><th class="count">Seats</th></tr>
+    {{range .Assignments}}<tr>
+      <td>{{if .AttendeeName}}{{.AttendeeName}} <small>({{.GuestName}}'s party)</small>{{else}}{{.GuestName}}{{end}}</td>
+      <td>{{.CategoryName}}</td>
+      <td class="count">{{.Seats}}</td>
+    </tr>{{end}}
+  </table>{{else}}<p>No guests seated yet.</p>{{end}}
+</section>
+{{else}}<p>No tables have been set up yet.</p>
+{{end}}</body>
+</html>
+`))
+
+// handlerSeatingRoster renders a printable HTML roster with a page per table.
+func (cfg *apiConfig) handlerSeatingRoster(w http.ResponseWriter, r *http.Request) {
+	tables, err := cfg.db.ListSeatingTables()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve tables", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := seatingRosterTemplate.Execute(w, tables); err != nil {
+		cfg.logger.Error("failed to render seating roster", "error", err)
+	}
+}
+
+// getSeatingTable loads the table named in the {id} path segment, responding
+// with an error if it does not exist.
+func (cfg *apiConfig) getSeatingTable(w http.ResponseWriter, r *http.Request) (database.SeatingTable, bool) {
+	tableID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid table ID", err)
+		return database.SeatingTable{}, false
+	}
+
+	return cfg.lookupSeatingTable(w, tableID)
+}
+
+// decodeSeatingTable loads the table named by the tableId field of the request body.
+func (cfg *apiConfig) decodeSeatingTable(w http.ResponseWriter, r *http.Request) (database.SeatingTable, bool) {
+	type parameters struct {
+		TableID uuid.UUID `json:"tableId"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return database.SeatingTable{}, false
+	}
+
+	return cfg.lookupSeatingTable(w, params.TableID)
+}
+
+func (cfg *apiConfig) lookupSeatingTable(w http.ResponseWriter, id uuid.UUID) (database.SeatingTable, bool) {
+	table, err := cfg.db.GetSeatingTable(id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve table", err)
+		return database.SeatingTable{}, false
+	}
+	if table.ID == uuid.Nil {
+		respondWithError(w, http.StatusNotFound, "Table not found", nil)
+		return database.SeatingTable{}, false
+	}
+
+	return table, true
+}
+
+// respondWithSeatingError maps the errors returned when seating guests.
+func respondWithSeatingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrTableFull):
+		respondWithError(w, http.StatusConflict, "This table does not have enough free seats", err)
+	case errors.Is(err, database.ErrRSVPNotApproved):
+		respondWithError(w, http.StatusConflict, "Only approved RSVPs can be seated", err)
+	case errors.Is(err, database.ErrTableNotFound):
+		respondWithError(w, http.StatusNotFound, "Table not found", err)
+	case errors.Is(err, database.ErrAttendeeNotFound):
+		respondWithError(w, http.StatusNotFound, "Attendee not found", err)
+	case errors.Is(err, database.ErrPartySeatedTogether):
+		respondWithError(w, http.StatusConflict, "This party is seated together; unseat it before seating attendees individually", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "Could not seat guests", err)
+	}
+}
+
+// formatTableNumbers describes where a party sits, e.g. "7" or "3, 5".
+func formatTableNumbers(numbers []int) string {
+	parts := make([]string, len(numbers))
+	for i, number := range numbers {
+		parts[i] = strconv.Itoa(number)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+	"github.com/tunedev/bts2025/server/internal/seating"
+)
+
+// handlerListSeatingConstraints returns the must-sit-with and keep-apart pairs.
+func (cfg *apiConfig) handlerListSeatingConstraints(w http.ResponseWriter, r *http.Request) {
+	constraints, err := cfg.db.ListSeatingConstraints()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve seating constraints", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    constraints,
+		Message: "Retrieved seating constraints successfully",
+		Success: true,
+	})
+}
+
+// handlerCreateSeatingConstraint records that two parties must sit together or
+// be kept apart. Kind is MUST_SIT_WITH or KEEP_APART.
+func (cfg *apiConfig) handlerCreateSeatingConstraint(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Kind        string    `json:"kind"`
+		RSVPID      uuid.UUID `json:"rsvpId"`
+		OtherRSVPID uuid.UUID `json:"otherRsvpId"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	kind := strings.ToUpper(strings.TrimSpace(params.Kind))
+	if kind != database.ConstraintMustSitWith && kind != database.ConstraintKeepApart {
+		respondWithError(w, http.StatusBadRequest, "kind must be MUST_SIT_WITH or KEEP_APART", nil)
+		return
+	}
+	if params.RSVPID == params.OtherRSVPID {
+		respondWithError(w, http.StatusBadRequest, "A seating constraint needs two different RSVPs", nil)
+		return
+	}
+	for _, id := range []uuid.UUID{params.RSVPID, params.OtherRSVPID} {
+		rsvp, err := cfg.db.GetRSVP(id)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVP", err)
+			return
+		}
+		if rsvp.ID == uuid.Nil {
+			respondWithError(w, http.StatusNotFound, "RSVP not found", nil)
+			return
+		}
+	}
+
+	constraint, err := cfg.db.CreateSeatingConstraint(kind, params.RSVPID, params.OtherRSVPID)
+	if err != nil {
+		if database.IsUniqueConstraintError(err) {
+			respondWithError(w, http.StatusConflict, "These parties already have a seating constraint", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Could not create seating constraint", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, responseStructure{
+		Data:    constraint,
+		Message: "Seating constraint created successfully",
+		Success: true,
+	})
+}
+
+// handlerDeleteSeatingConstraint removes a seating constraint.
+func (cfg *apiConfig) handlerDeleteSeatingConstraint(w http.ResponseWriter, r *http.Request) {
+	constraintID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid seating constraint ID", err)
+		return
+	}
+
+	if err := cfg.db.DeleteSeatingConstraint(constraintID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not delete seating constraint", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Message: "Seating constraint deleted successfully",
+		Success: true,
+	})
+}
+
+// suggestedParty is a party in a draft seating plan.
+type suggestedParty struct {
+	RSVPID        uuid.UUID `json:"rsvpId"`
+	GuestName     string    `json:"guestName"`
+	Seats         int       `json:"seats"`
+	Category      string    `json:"category"`
+	Side          string    `json:"side"`
+	AlreadySeated bool      `json:"alreadySeated"`
+	Reason        string    `json:"reason,omitempty"`
+}
+
+// suggestedTable is a table in a draft seating plan.
+type suggestedTable struct {
+	TableID    uuid.UUID        `json:"tableId"`
+	Number     int              `json:"number"`
+	Name       string           `json:"name"`
+	Capacity   int              `json:"capacity"`
+	SeatsTaken int              `json:"seatsTaken"`
+	Parties    []suggestedParty `json:"parties"`
+}
+
+// handlerSuggestSeating drafts a table plan for the approved parties without
+// saving it. By default parties that are already seated stay where they are and
+// only the rest are placed; send keepExisting=false to plan every party from
+// scratch. The returned assignments can be adjusted and sent to
+// handlerApplySeating together with the returned replaceExisting flag.
+func (cfg *apiConfig) handlerSuggestSeating(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		KeepExisting *bool `json:"keepExisting"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+	keepExisting := params.KeepExisting == nil || *params.KeepExisting
+
+	tables, err := cfg.db.ListSeatingTables()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve tables", err)
+		return
+	}
+	if len(tables) == 0 {
+		respondWithError(w, http.StatusConflict, "Add some tables before asking for a seating plan", nil)
+		return
+	}
+
+	rsvps := map[uuid.UUID]database.ExportRow{}
+	var approved []uuid.UUID
+	err = cfg.db.StreamRSVPs(database.RSVPFilter{Status: "APPROVED"}, func(row database.ExportRow) error {
+		rsvps[row.ID] = row
+		approved = append(approved, row.ID)
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve RSVPs", err)
+		return
+	}
+
+	constraints, err := cfg.db.ListSeatingConstraints()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve seating constraints", err)
+		return
+	}
+
+	party := func(row database.ExportRow, seats int) seating.Party {
+		return seating.Party{ID: row.ID, Size: seats, Group: row.CategoryID.UUID, Side: row.CategorySide}
+	}
+
+	seated := map[uuid.UUID]bool{}
+	planTables := make([]seating.Table, len(tables))
+	for i, table := range tables {
+		planTables[i] = seating.Table{ID: table.ID, Capacity: table.Capacity}
+		if !keepExisting {
+			continue
+		}
+		seats := map[uuid.UUID]int{}
+		var order []uuid.UUID
+		for _, assignment := range table.Assignments {
+			if _, ok := seats[assignment.RSVPID]; !ok {
+				order = append(order, assignment.RSVPID)
+			}
+			seats[assignment.RSVPID] += assignment.Seats
+		}
+		for _, id := range order {
+			planTables[i].Seated = append(planTables[i].Seated, party(rsvps[id], seats[id]))
+			seated[id] = true
+		}
+	}
+
+	var parties []seating.Party
+	for _, id := range approved {
+		if !seated[id] {
+			parties = append(parties, party(rsvps[id], rsvps[id].NumberOfGuests))
+		}
+	}
+
+	planConstraints := make([]seating.Constraint, len(constraints))
+	for i, constraint := range constraints {
+		planConstraints[i] = seating.Constraint{Kind: constraint.Kind, A: constraint.RSVPID, B: constraint.OtherRSVPID}
+	}
+
+	plan := seating.Suggest(planTables, parties, planConstraints)
+
+	describe := func(id uuid.UUID, seats int) suggestedParty {
+		row := rsvps[id]
+		return suggestedParty{
+			RSVPID:    id,
+			GuestName: row.GuestName,
+			Seats:     seats,
+			Category:  row.CategoryName,
+			Side:      row.CategorySide,
+		}
+	}
+
+	draft := make([]suggestedTable, len(tables))
+	byTable := map[uuid.UUID]*suggestedTable{}
+	for i, table := range tables {
+		draft[i] = suggestedTable{
+			TableID:  table.ID,
+			Number:   table.Number,
+			Name:     table.Name,
+			Capacity: table.Capacity,
+			Parties:  []suggestedParty{},
+		}
+		for _, seatedParty := range planTables[i].Seated {
+			p := describe(seatedParty.ID, seatedParty.Size)
+			p.AlreadySeated = true
+			draft[i].Parties = append(draft[i].Parties, p)
+			draft[i].SeatsTaken += seatedParty.Size
+		}
+		byTable[table.ID] = &draft[i]
+	}
+
+	assignments := make([]database.PlanAssignment, 0, len(plan.Assignments))
+	for _, assignment := range plan.Assignments {
+		table := byTable[assignment.TableID]
+		seats := rsvps[assignment.PartyID].NumberOfGuests
+		table.Parties = append(table.Parties, describe(assignment.PartyID, seats))
+		table.SeatsTaken += seats
+		assignments = append(assignments, database.PlanAssignment{RSVPID: assignment.PartyID, TableID: assignment.TableID})
+	}
+
+	unplaced := make([]suggestedParty, 0, len(plan.Unplaced))
+	for _, u := range plan.Unplaced {
+		p := describe(u.PartyID, rsvps[u.PartyID].NumberOfGuests)
+		p.Reason = u.Reason
+		unplaced = append(unplaced, p)
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data: map[string]any{
+			"tables":          draft,
+			"unplaced":        unplaced,
+			"assignments":     assignments,
+			"replaceExisting": !keepExisting,
+		},
+		Message: "Seating plan drafted; nothing has been saved yet",
+		Success: true,
+	})
+}
+
+// handlerApplySeating saves a seating plan, usually a draft from
+// handlerSuggestSeating that the couple has accepted or tweaked. The whole plan
+// is applied or, if any party does not fit, none of it.
+func (cfg *apiConfig) handlerApplySeating(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Assignments     []database.PlanAssignment `json:"assignments"`
+		ReplaceExisting bool                      `json:"replaceExisting"`
+	}
+	params := parameters{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+	if len(params.Assignments) == 0 && !params.ReplaceExisting {
+		respondWithError(w, http.StatusBadRequest, "The seating plan has no assignments", nil)
+		return
+	}
+
+	if err := cfg.db.ApplySeatingPlan(params.Assignments, params.ReplaceExisting); err != nil {
+		respondWithSeatingError(w, err)
+		return
+	}
+
+	tables, err := cfg.db.ListSeatingTables()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve tables", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    tables,
+		Message: "Seating plan applied successfully",
+		Success: true,
+	})
+}
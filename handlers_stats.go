@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+)
+
+// handlerDashboardStats returns the aggregate RSVP, capacity and check-in numbers
+// for the guests on the signed-in couple's side.
+func (cfg *apiConfig) handlerDashboardStats(w http.ResponseWriter, r *http.Request) {
+	coupleDetails, ok := GetCoupleDetailsFromCtx(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusForbidden, http.StatusText(http.StatusForbidden), nil)
+		return
+	}
+
+	stats, err := cfg.db.GetDashboardStats(coupleDetails.Side)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not retrieve dashboard stats", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data:    stats,
+		Message: "Dashboard stats retrieved successfully",
+		Success: true,
+	})
+}
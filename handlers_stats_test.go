@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+func TestHandlerDashboardStatsIsScopedToSide(t *testing.T) {
+	cfg, _ := newTestConfig(t)
+	bride, brideCategory := newTestCouple(t, cfg, "BRIDE")
+	groom, groomCategory := newTestCouple(t, cfg, "GROOM")
+
+	newTestRSVP(t, cfg, brideCategory, "APPROVED", 2)
+	newTestRSVP(t, cfg, brideCategory, "PENDING", 1)
+	newTestRSVP(t, cfg, groomCategory, "APPROVED", 5)
+	newTestRSVP(t, cfg, groomCategory, "APPROVED", 3)
+	newTestRSVP(t, cfg, groomCategory, "WAITLISTED", 4)
+
+	tests := []struct {
+		couple       database.Couple
+		wantCategory string
+		wantSide     database.SideStats
+		wantParties  int
+	}{
+		{bride, brideCategory.Name, database.SideStats{Side: "BRIDE", Capacity: 100, ApprovedParties: 1, ApprovedGuests: 2, PendingParties: 1, PendingGuests: 1, RemainingSeats: 98}, 2},
+		{groom, groomCategory.Name, database.SideStats{Side: "GROOM", Capacity: 100, ApprovedParties: 2, ApprovedGuests: 8, WaitlistedParties: 1, WaitlistedGuests: 4, RemainingSeats: 92}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.couple.Side, func(t *testing.T) {
+			r := asCouple(httptest.NewRequest(http.MethodGet, "/api/admin/stats", nil), tt.couple)
+			w := httptest.NewRecorder()
+			cfg.handlerDashboardStats(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+
+			var resp struct {
+				Data database.DashboardStats `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			stats := resp.Data
+
+			if len(stats.Categories) != 1 || stats.Categories[0].Name != tt.wantCategory {
+				t.Errorf("categories = %+v, want only %s", stats.Categories, tt.wantCategory)
+			}
+			if len(stats.Sides) != 1 || stats.Sides[0] != tt.wantSide {
+				t.Errorf("sides = %+v, want %+v", stats.Sides, tt.wantSide)
+			}
+			submitted := 0
+			for _, day := range stats.Submissions {
+				submitted += day.Submissions
+			}
+			if submitted != tt.wantParties {
+				t.Errorf("%d submissions, want %d", submitted, tt.wantParties)
+			}
+			if stats.CheckIn.ApprovedParties != tt.wantSide.ApprovedParties || stats.CheckIn.ApprovedGuests != tt.wantSide.ApprovedGuests {
+				t.Errorf("check-in = %+v, want %d parties of %d guests", stats.CheckIn, tt.wantSide.ApprovedParties, tt.wantSide.ApprovedGuests)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/tunedev/bts2025/server/internal/database"
+	"github.com/tunedev/bts2025/server/internal/email"
+)
+
+// maxWebhookBodySize caps webhook payloads; delivery events are a few KB.
+const maxWebhookBodySize = 1 << 20
+
+// handlerEmailWebhook records delivery, bounce and complaint reports from the
+// mail provider against the emails they concern. Reports must be signed with
+// EMAIL_WEBHOOK_SECRET; cmd/webhooksign signs test payloads.
+func (cfg *apiConfig) handlerEmailWebhook(w http.ResponseWriter, r *http.Request) {
+	if cfg.webhookVerifier == nil {
+		respondWithError(w, http.StatusServiceUnavailable, "Email webhooks are not configured", nil)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, http.StatusRequestEntityTooLarge, "Webhook payload is too large", err)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Could not read webhook payload", err)
+		return
+	}
+
+	if err := cfg.webhookVerifier.Verify(r.Header, body, time.Now()); err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid webhook signature", err)
+		return
+	}
+
+	event, ok, err := email.ParseDeliveryEvent(body)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook payload", err)
+		return
+	}
+	if !ok {
+		// Acknowledge events we do not track so the provider stops retrying them.
+		respondWithJSON(w, http.StatusOK, responseStructure{Message: "Event ignored", Success: true})
+		return
+	}
+
+	found, err := cfg.db.RecordEmailDelivery(database.RecordEmailDeliveryParams{
+		ProviderMessageID: event.MessageID,
+		Status:            event.Status,
+		Detail:            event.Detail,
+		OccurredAt:        event.OccurredAt,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Could not record delivery event", err)
+		return
+	}
+	if !found {
+		respondWithJSON(w, http.StatusOK, responseStructure{Message: "Email not tracked", Success: true})
+		return
+	}
+	if event.Status == email.DeliveryBounced || event.Status == email.DeliveryComplained {
+		cfg.logger.Warn("email was not accepted by the recipient", "message_id", event.MessageID, "status", event.Status, "detail", event.Detail)
+	}
+
+	respondWithJSON(w, http.StatusOK, responseStructure{Message: "Delivery event recorded", Success: true})
+}
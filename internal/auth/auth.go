@@ -2,6 +2,7 @@ package auth
 
 import (
 	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
@@ -17,6 +18,7 @@ type TokenType string
 
 const (
 	TokenTypeAccess TokenType = "bts-access"
+	TokenTypeGuest  TokenType = "bts-guest"
 )
 
 var ErrNoAuthHeaderIncluded = errors.New("no auth header included in request")
@@ -30,27 +32,76 @@ func GenerateOTP() (string, error) {
 	return fmt.Sprintf("%d", n.Int64()+100000), nil
 }
 
+// guestCodeAlphabet leaves out characters that are easily misread, such as 0/O and 1/I.
+const guestCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateGuestCode returns a short random code that an invitee can type in to RSVP.
+func GenerateGuestCode() (string, error) {
+	codeBytes := make([]byte, 8)
+	if _, err := rand.Read(codeBytes); err != nil {
+		return "", err
+	}
+	for i, b := range codeBytes {
+		codeBytes[i] = guestCodeAlphabet[int(b)%len(guestCodeAlphabet)]
+	}
+	return string(codeBytes), nil
+}
+
+// GenerateInvitationToken returns a random, URL-safe token for a category's invitation link.
+func GenerateInvitationToken() (string, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
+
 func MakeJWT(
 	userID uuid.UUID,
 	tokenSecret string,
 	expiresIn time.Duration,
+) (string, error) {
+	return makeToken(TokenTypeAccess, userID, tokenSecret, expiresIn)
+}
+
+func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	return validateToken(TokenTypeAccess, tokenString, tokenSecret)
+}
+
+// MakeGuestJWT issues a session that lets a guest manage their own RSVP.
+// Guest sessions carry a different issuer so they are never accepted on admin routes.
+func MakeGuestJWT(rsvpID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+	return makeToken(TokenTypeGuest, rsvpID, tokenSecret, expiresIn)
+}
+
+// ValidateGuestJWT validates a guest session and returns the RSVP ID it was issued for.
+func ValidateGuestJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	return validateToken(TokenTypeGuest, tokenString, tokenSecret)
+}
+
+func makeToken(
+	tokenType TokenType,
+	subject uuid.UUID,
+	tokenSecret string,
+	expiresIn time.Duration,
 ) (string, error) {
 	signingKey := []byte(tokenSecret)
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    string(TokenTypeAccess),
+		Issuer:    string(tokenType),
 		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userID.String(),
+		Subject:   subject.String(),
 	})
 	return token.SignedString(signingKey)
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+func validateToken(tokenType TokenType, tokenString, tokenSecret string) (uuid.UUID, error) {
 	claimsStruct := jwt.RegisteredClaims{}
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&claimsStruct,
 		func(token *jwt.Token) (interface{}, error) { return []byte(tokenSecret), nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
 	)
 	if err != nil {
 		return uuid.Nil, err
@@ -65,7 +116,7 @@ func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
 	if err != nil {
 		return uuid.Nil, err
 	}
-	if issuer != string(TokenTypeAccess) {
+	if issuer != string(tokenType) {
 		return uuid.Nil, errors.New("invalid issuer")
 	}
 
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const TokenTypeTicket TokenType = "bts-ticket"
+
+// ParseTicketSigningKey decodes a base64 encoded 32-byte Ed25519 seed into a private key.
+func ParseTicketSigningKey(encoded string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ticket signing key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid ticket signing key: expected %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// MakeTicketToken signs a compact ticket for an RSVP. Tickets are signed with
+// Ed25519 so that scanners can verify them offline using only the public key.
+func MakeTicketToken(
+	rsvpID uuid.UUID,
+	signingKey ed25519.PrivateKey,
+	expiresAt time.Time,
+) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeTicket),
+		ExpiresAt: jwt.NewNumericDate(expiresAt.UTC()),
+		Subject:   rsvpID.String(),
+	})
+	return token.SignedString(signingKey)
+}
+
+// ValidateTicketToken verifies a ticket's signature and expiry and returns the RSVP ID it was issued for.
+func ValidateTicketToken(tokenString string, publicKey ed25519.PublicKey) (uuid.UUID, error) {
+	claimsStruct := jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&claimsStruct,
+		func(token *jwt.Token) (interface{}, error) { return publicKey, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	issuer, err := token.Claims.GetIssuer()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if issuer != string(TokenTypeTicket) {
+		return uuid.Nil, errors.New("invalid issuer")
+	}
+
+	rsvpIDString, err := token.Claims.GetSubject()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	id, err := uuid.Parse(rsvpIDString)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid RSVP ID: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestValidateTicketToken(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	publicKey := key.Public().(ed25519.PublicKey)
+	otherKey := ed25519.NewKeyFromSeed([]byte(strings.Repeat("x", ed25519.SeedSize)))
+	rsvpID := uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+
+	sign := func(t *testing.T, method jwt.SigningMethod, claims jwt.RegisteredClaims, key any) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("SignedString: %v", err)
+		}
+		return token
+	}
+	ticketClaims := jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeTicket),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		Subject:   rsvpID.String(),
+	}
+
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+		valid bool
+	}{
+		{
+			name: "round trip",
+			token: func(t *testing.T) string {
+				token, err := MakeTicketToken(rsvpID, key, expiresAt)
+				if err != nil {
+					t.Fatalf("MakeTicketToken: %v", err)
+				}
+				return token
+			},
+			valid: true,
+		},
+		{
+			name: "tampered payload",
+			token: func(t *testing.T) string {
+				token, err := MakeTicketToken(rsvpID, key, expiresAt)
+				if err != nil {
+					t.Fatalf("MakeTicketToken: %v", err)
+				}
+				parts := strings.Split(token, ".")
+				claims := ticketClaims
+				claims.Subject = uuid.NewString()
+				payload, err := json.Marshal(claims)
+				if err != nil {
+					t.Fatalf("Marshal: %v", err)
+				}
+				parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+				return strings.Join(parts, ".")
+			},
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				token, err := MakeTicketToken(rsvpID, key, time.Now().Add(-time.Minute))
+				if err != nil {
+					t.Fatalf("MakeTicketToken: %v", err)
+				}
+				return token
+			},
+		},
+		{
+			name: "no expiry",
+			token: func(t *testing.T) string {
+				claims := ticketClaims
+				claims.ExpiresAt = nil
+				return sign(t, jwt.SigningMethodEdDSA, claims, key)
+			},
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				claims := ticketClaims
+				claims.Issuer = "bts-access"
+				return sign(t, jwt.SigningMethodEdDSA, claims, key)
+			},
+		},
+		{
+			name: "signed with another key",
+			token: func(t *testing.T) string {
+				token, err := MakeTicketToken(rsvpID, otherKey, expiresAt)
+				if err != nil {
+					t.Fatalf("MakeTicketToken: %v", err)
+				}
+				return token
+			},
+		},
+		{
+			name: "HS256 signed with the public key",
+			token: func(t *testing.T) string {
+				return sign(t, jwt.SigningMethodHS256, ticketClaims, []byte(publicKey))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateTicketToken(tt.token(t), publicKey)
+			if tt.valid {
+				if err != nil || got != rsvpID {
+					t.Errorf("ValidateTicketToken = %s, %v, want %s", got, err, rsvpID)
+				}
+				return
+			}
+			if err == nil {
+				t.Errorf("ValidateTicketToken = %s, want an error", got)
+			}
+		})
+	}
+}
+
+func TestParseTicketSigningKey(t *testing.T) {
+	seed := []byte(strings.Repeat("s", ed25519.SeedSize))
+	key, err := ParseTicketSigningKey(base64.StdEncoding.EncodeToString(seed))
+	if err != nil {
+		t.Fatalf("ParseTicketSigningKey: %v", err)
+	}
+	if !key.Equal(ed25519.NewKeyFromSeed(seed)) {
+		t.Errorf("ParseTicketSigningKey returned a different key")
+	}
+
+	for _, encoded := range []string{"not base64!", base64.StdEncoding.EncodeToString(seed[:16])} {
+		if _, err := ParseTicketSigningKey(encoded); err == nil {
+			t.Errorf("ParseTicketSigningKey(%q) succeeded, want an error", encoded)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package calendar renders iCalendar (RFC 5545) files that guests can import
+// into any calendar app.
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of the files built by New.
+const ContentType = "text/calendar; charset=utf-8"
+
+const productID = "-//BTS Wedding//RSVP//EN"
+
+// maxLineOctets is the longest a content line may be before it must be folded.
+const maxLineOctets = 75
+
+// Event is one entry in a calendar file.
+type Event struct {
+	// UID identifies the event across re-sent files so calendar apps update it
+	// instead of adding a copy.
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	// AllDay makes the event cover whole days, from Start's date up to but not
+	// including End's date.
+	AllDay bool
+	// Reminder is how long before Start an alarm goes off; zero means no alarm.
+	Reminder time.Duration
+}
+
+// New renders events as a calendar file stamped with now.
+func New(events []Event, now time.Time) []byte {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeLine(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", productID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	for _, event := range events {
+		line("BEGIN", "VEVENT")
+		line("UID", escapeText(event.UID))
+		line("DTSTAMP", formatDateTime(now))
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE", formatDate(event.Start))
+			line("DTEND;VALUE=DATE", formatDate(event.End))
+		} else {
+			line("DTSTART", formatDateTime(event.Start))
+			line("DTEND", formatDateTime(event.End))
+		}
+		line("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escapeText(event.Location))
+		}
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+		if event.Reminder > 0 {
+			line("BEGIN", "VALARM")
+			line("ACTION", "DISPLAY")
+			line("DESCRIPTION", escapeText("Reminder: "+event.Summary))
+			line("TRIGGER", "-"+formatDuration(event.Reminder))
+			line("END", "VALARM")
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	return []byte(b.String())
+}
+
+// writeLine writes a content line terminated by CRLF, folding it onto
+// continuation lines that start with a space so that no line is longer than 75
+// octets. Lines are only folded between UTF-8 characters.
+func writeLine(b *strings.Builder, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space counts towards the continuation line's length.
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// escapeText escapes a TEXT value (RFC 5545 section 3.3.11).
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+// formatDuration renders a positive duration as an RFC 5545 DURATION, e.g.
+// P1D, PT2H or P1DT30M, to the nearest second.
+func formatDuration(d time.Duration) string {
+	seconds := int64(d.Round(time.Second) / time.Second)
+	days, seconds := seconds/86400, seconds%86400
+	hours, seconds := seconds/3600, seconds%3600
+	minutes, seconds := seconds/60, seconds%60
+
+	var b strings.Builder
+	b.WriteString("P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 {
+		b.WriteString("T")
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+	if days == 0 && hours == 0 && minutes == 0 && seconds == 0 {
+		b.WriteString("T0S")
+	}
+	return b.String()
+}
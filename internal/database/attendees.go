@@ -0,0 +1,175 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Age groups an attendee can belong to.
+const (
+	AgeGroupAdult = "ADULT"
+	AgeGroupChild = "CHILD"
+)
+
+// Attendee is one named person in an RSVP's party, with the details the caterer
+// and seating planner need.
+type Attendee struct {
+	ID                  uuid.UUID     `json:"id"`
+	Name                string        `json:"name"`
+	AgeGroup            string        `json:"age_group"`
+	DietaryRestrictions []string      `json:"dietary_restrictions"`
+	AllergyNotes        string        `json:"allergy_notes"`
+	MealOptionID        uuid.NullUUID `json:"meal_option_id"`
+	// MealOption is the name of the chosen meal option, if any.
+	MealOption string `json:"meal_option,omitempty"`
+}
+
+// AttendeeParams defines the details of an attendee added with a new RSVP.
+// DietaryRestrictions are short tags such as "vegetarian" and must not contain commas.
+type AttendeeParams struct {
+	Name                string
+	AgeGroup            string
+	DietaryRestrictions []string
+	AllergyNotes        string
+	MealOptionID        uuid.NullUUID
+}
+
+var (
+	// ErrTooManyAttendees is returned when an RSVP would name more attendees than its party size.
+	ErrTooManyAttendees = errors.New("more attendees are named than the number of guests")
+	// ErrAttendeeNotFound is returned when an attendee ID does not match any attendee.
+	ErrAttendeeNotFound = errors.New("attendee not found")
+)
+
+// insertAttendees adds the attendees of a new RSVP in the order given. A chosen
+// meal option must still be available (ErrMealOptionUnavailable otherwise).
+func (c Client) insertAttendees(q querier, rsvpID uuid.UUID, attendees []AttendeeParams) error {
+	query := `
+    INSERT INTO rsvp_attendees (
+        id,
+        rsvp_id,
+        position,
+        name,
+        age_group,
+        dietary_restrictions,
+        allergy_notes,
+        meal_option_id
+    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	for i, attendee := range attendees {
+		if attendee.MealOptionID.Valid {
+			if err := c.checkMealOptionAvailable(q, attendee.MealOptionID.UUID); err != nil {
+				return err
+			}
+		}
+		ageGroup := attendee.AgeGroup
+		if ageGroup == "" {
+			ageGroup = AgeGroupAdult
+		}
+		_, err := q.Exec(
+			c.rebind(query),
+			uuid.New(),
+			rsvpID,
+			i,
+			attendee.Name,
+			ageGroup,
+			strings.Join(attendee.DietaryRestrictions, ","),
+			attendee.AllergyNotes,
+			attendee.MealOptionID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListAttendees returns the attendees named on an RSVP.
+func (c Client) ListAttendees(rsvpID uuid.UUID) ([]Attendee, error) {
+	byRSVP, err := c.attendeesByRSVP([]uuid.UUID{rsvpID})
+	if err != nil {
+		return nil, err
+	}
+	if byRSVP[rsvpID] == nil {
+		return []Attendee{}, nil
+	}
+	return byRSVP[rsvpID], nil
+}
+
+// attendeesByRSVP loads the attendees of several RSVPs in one query, keyed by RSVP ID.
+func (c Client) attendeesByRSVP(rsvpIDs []uuid.UUID) (map[uuid.UUID][]Attendee, error) {
+	byRSVP := map[uuid.UUID][]Attendee{}
+	if len(rsvpIDs) == 0 {
+		return byRSVP, nil
+	}
+
+	placeholders := make([]string, len(rsvpIDs))
+	args := make([]any, len(rsvpIDs))
+	for i, id := range rsvpIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := `
+    SELECT
+        ra.rsvp_id,
+        ra.id,
+        ra.name,
+        ra.age_group,
+        ra.dietary_restrictions,
+        ra.allergy_notes,
+        ra.meal_option_id,
+        mo.name
+    FROM rsvp_attendees ra
+    LEFT JOIN meal_options mo ON (mo.id = ra.meal_option_id)
+    WHERE ra.rsvp_id IN (` + strings.Join(placeholders, ", ") + `)
+    ORDER BY ra.rsvp_id, ra.position`
+
+	rows, err := c.DB.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rsvpID uuid.UUID
+		var attendee Attendee
+		var dietary string
+		var mealOption sql.NullString
+		if err := rows.Scan(
+			&rsvpID,
+			&attendee.ID,
+			&attendee.Name,
+			&attendee.AgeGroup,
+			&dietary,
+			&attendee.AllergyNotes,
+			&attendee.MealOptionID,
+			&mealOption,
+		); err != nil {
+			return nil, err
+		}
+		attendee.DietaryRestrictions = splitDietaryRestrictions(dietary)
+		attendee.MealOption = mealOption.String
+		byRSVP[rsvpID] = append(byRSVP[rsvpID], attendee)
+	}
+
+	return byRSVP, rows.Err()
+}
+
+// countAttendees returns how many attendees are named on an RSVP.
+func (c Client) countAttendees(q querier, rsvpID uuid.UUID) (int, error) {
+	var count int
+	err := q.QueryRow(c.rebind(`SELECT COUNT(*) FROM rsvp_attendees WHERE rsvp_id = ?`), rsvpID).Scan(&count)
+	return count, err
+}
+
+func splitDietaryRestrictions(stored string) []string {
+	if stored == "" {
+		return []string{}
+	}
+	return strings.Split(stored, ",")
+}
@@ -18,6 +18,13 @@ type GuestCategory struct {
 	DefaultCategory bool      `json:"default_category"`
 	CoupleID        uuid.UUID `json:"couple_id"`
 	CreatedAt       time.Time `json:"created_at"`
+	// EventIDs lists the events the invitation covers; empty means every event.
+	// Only GetCategory and ListCategoriesByCouple load it.
+	EventIDs []uuid.UUID `json:"event_ids"`
+	// DeletedAt is set on a tombstone: a category deleted with its RSVPs
+	// rejected, kept so that they still belong to it. Only GetCategory loads
+	// tombstones; every other lookup leaves them out.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 // CreateCategoryParams defines the parameters for creating a new guest category.
@@ -28,12 +35,16 @@ type CreateCategoryParams struct {
 	InvitationToken *string   `json:"invitation_token"`
 	CoupleID        uuid.UUID `json:"couple_id"`
 	DefaultCategory bool      `json:"default_category"`
+	// EventIDs limits the invitation to these events; empty means every event.
+	EventIDs []uuid.UUID `json:"event_ids"`
 }
 
-// CreateCategory inserts a new guest category into the database.
+// CreateCategory inserts a new guest category into the database. Every event in
+// params.EventIDs must exist (ErrEventNotFound otherwise).
 func (c Client) CreateCategory(params CreateCategoryParams) (GuestCategory, error) {
 	id := uuid.New()
-	query := `
+	err := c.withTx(func(tx *sql.Tx) error {
+		query := `
     INSERT INTO guest_categories (
         id,
         name,
@@ -44,16 +55,22 @@ func (c Client) CreateCategory(params CreateCategoryParams) (GuestCategory, erro
 				default_category
     ) VALUES (?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := c.DB.Exec(
-		query,
-		id,
-		params.Name,
-		params.Side,
-		params.MaxGuests,
-		params.InvitationToken,
-		params.CoupleID,
-		params.DefaultCategory,
-	)
+		_, err := tx.Exec(
+			c.rebind(query),
+			id,
+			params.Name,
+			params.Side,
+			params.MaxGuests,
+			params.InvitationToken,
+			params.CoupleID,
+			params.DefaultCategory,
+		)
+		if err != nil {
+			return err
+		}
+
+		return c.setCategoryEvents(tx, id, params.EventIDs)
+	})
 	if err != nil {
 		return GuestCategory{}, err
 	}
@@ -61,8 +78,23 @@ func (c Client) CreateCategory(params CreateCategoryParams) (GuestCategory, erro
 	return c.GetCategory(id)
 }
 
-// GetCategory retrieves a single guest category by its ID.
+// GetCategory retrieves a single guest category by its ID, with its events.
 func (c Client) GetCategory(id uuid.UUID) (GuestCategory, error) {
+	category, err := c.getCategory(c.DB, id, false)
+	if err != nil || category.ID == uuid.Nil {
+		return category, err
+	}
+
+	category.EventIDs, err = c.categoryEventIDs(c.DB, id)
+	if err != nil {
+		return GuestCategory{}, err
+	}
+	return category, nil
+}
+
+// getCategory loads a category through q. When lock is set the row stays locked
+// until the surrounding transaction ends, serialising capacity checks.
+func (c Client) getCategory(q querier, id uuid.UUID, lock bool) (GuestCategory, error) {
 	query := `
     SELECT
         id,
@@ -70,20 +102,27 @@ func (c Client) GetCategory(id uuid.UUID) (GuestCategory, error) {
         side,
         max_guests,
         invitation_token,
+        default_category,
         couple_id,
-        created_at
+        created_at,
+        deleted_at
     FROM guest_categories
     WHERE id = ?`
+	if lock {
+		query = c.forUpdate(query)
+	}
 
 	var category GuestCategory
-	err := c.DB.QueryRow(query, id).Scan(
+	err := q.QueryRow(c.rebind(query), id).Scan(
 		&category.ID,
 		&category.Name,
 		&category.Side,
 		&category.MaxGuests,
 		&category.InvitationToken,
+		&category.DefaultCategory,
 		&category.CoupleID,
 		&category.CreatedAt,
+		&category.DeletedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -104,18 +143,20 @@ func (c Client) GetCategoryByName(name string) (GuestCategory, error) {
         side,
         max_guests,
         invitation_token,
+        default_category,
         couple_id,
         created_at
     FROM guest_categories
-    WHERE name = ?`
+    WHERE name = ? AND deleted_at IS NULL`
 
 	var category GuestCategory
-	err := c.DB.QueryRow(query, name).Scan(
+	err := c.DB.QueryRow(c.rebind(query), name).Scan(
 		&category.ID,
 		&category.Name,
 		&category.Side,
 		&category.MaxGuests,
 		&category.InvitationToken,
+		&category.DefaultCategory,
 		&category.CoupleID,
 		&category.CreatedAt,
 	)
@@ -138,13 +179,14 @@ func (c Client) ListCategoriesByCouple(coupleID uuid.UUID) ([]GuestCategory, err
         side,
         max_guests,
         invitation_token,
+        default_category,
         couple_id,
         created_at
     FROM guest_categories
-    WHERE couple_id = ?
+    WHERE couple_id = ? AND deleted_at IS NULL
     ORDER BY created_at ASC`
 
-	rows, err := c.DB.Query(query, coupleID)
+	rows, err := c.DB.Query(c.rebind(query), coupleID)
 	if err != nil {
 		return nil, err
 	}
@@ -159,6 +201,7 @@ func (c Client) ListCategoriesByCouple(coupleID uuid.UUID) ([]GuestCategory, err
 			&category.Side,
 			&category.MaxGuests,
 			&category.InvitationToken,
+			&category.DefaultCategory,
 			&category.CoupleID,
 			&category.CreatedAt,
 		); err != nil {
@@ -166,13 +209,48 @@ func (c Client) ListCategoriesByCouple(coupleID uuid.UUID) ([]GuestCategory, err
 		}
 		categories = append(categories, category)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range categories {
+		categories[i].EventIDs, err = c.categoryEventIDs(c.DB, categories[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return categories, nil
 }
 
-// UpdateCategory modifies an existing guest category.
-func (c Client) UpdateCategory(category GuestCategory) error {
-	query := `
+// ErrCapacityBelowApproved is returned when a category's max_guests would drop
+// below the number of guests already approved in it.
+var ErrCapacityBelowApproved = errors.New("max guests cannot be lower than the number of guests already approved")
+
+// UpdateCategory modifies an existing guest category, including the events its
+// invitation covers. Capacity cannot shrink below the approved head count; if it
+// grows, waitlisted RSVPs are promoted into the new seats and returned.
+func (c Client) UpdateCategory(category GuestCategory) ([]RSVP, error) {
+	var promoted []RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		existing, err := c.getCategory(tx, category.ID, true)
+		if err != nil {
+			return err
+		}
+		if existing.ID == uuid.Nil || existing.DeletedAt != nil || existing.CoupleID != category.CoupleID {
+			return errors.New("guest category not found")
+		}
+
+		approved, err := c.approvedGuestCount(tx, category.ID)
+		if err != nil {
+			return err
+		}
+		if !existing.DefaultCategory && category.MaxGuests < approved {
+			return ErrCapacityBelowApproved
+		}
+
+		query := `
     UPDATE guest_categories
     SET
         name = ?,
@@ -181,34 +259,279 @@ func (c Client) UpdateCategory(category GuestCategory) error {
         invitation_token = ?
     WHERE id = ? AND couple_id = ?`
 
-	_, err := c.DB.Exec(
-		query,
-		category.Name,
-		category.Side,
-		category.MaxGuests,
-		category.InvitationToken,
-		category.ID,
-		category.CoupleID, // Ensure a couple can only update their own categories
+		_, err = tx.Exec(
+			c.rebind(query),
+			category.Name,
+			category.Side,
+			category.MaxGuests,
+			category.InvitationToken,
+			category.ID,
+			category.CoupleID, // Ensure a couple can only update their own categories
+		)
+		if err != nil {
+			return err
+		}
+		if err := c.setCategoryEvents(tx, category.ID, category.EventIDs); err != nil {
+			return err
+		}
+
+		if category.MaxGuests > existing.MaxGuests {
+			category.DefaultCategory = existing.DefaultCategory
+			promoted, err = c.promoteWaitlist(tx, category)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+// DeleteCategoryParams controls what happens to the RSVPs of a deleted category.
+// When ReassignTo is set every RSVP moves to that category, keeping its status;
+// otherwise every RSVP that has not already been rejected is rejected, and the
+// category is kept as a tombstone so that those RSVPs stay in the couple's lists.
+type DeleteCategoryParams struct {
+	ID         uuid.UUID
+	CoupleID   uuid.UUID
+	ReassignTo uuid.NullUUID
+}
+
+// DeleteCategoryResult reports what happened to a deleted category's RSVPs.
+type DeleteCategoryResult struct {
+	Reassigned      int    `json:"reassigned"`
+	Rejected        []RSVP `json:"rejected"`
+	Promoted        []RSVP `json:"promoted"`
+	RemovedInvitees int    `json:"removed_invitees"`
+}
+
+// DeleteCategory removes a guest category, first reassigning or rejecting its RSVPs
+// according to params. Reassigned approved guests must fit in the target category
+// (ErrCategoryFull otherwise). Invitees move with reassigned RSVPs and are removed
+// when the RSVPs are rejected. Seats that rejected RSVPs held at events are
+// offered to those events' waitlists. Rejection and promotion emails are queued
+// with the change.
+func (c Client) DeleteCategory(params DeleteCategoryParams) (DeleteCategoryResult, error) {
+	var result DeleteCategoryResult
+	err := c.withTx(func(tx *sql.Tx) error {
+		category, err := c.getCategory(tx, params.ID, true)
+		if err != nil {
+			return err
+		}
+		if category.ID == uuid.Nil || category.DeletedAt != nil || category.CoupleID != params.CoupleID {
+			return errors.New("guest category not found")
+		}
+
+		if params.ReassignTo.Valid {
+			target, err := c.getCategory(tx, params.ReassignTo.UUID, true)
+			if err != nil {
+				return err
+			}
+			if target.ID == uuid.Nil || target.DeletedAt != nil || target.ID == category.ID {
+				return errors.New("target guest category not found")
+			}
+
+			if !target.DefaultCategory {
+				moving, err := c.approvedGuestCount(tx, category.ID)
+				if err != nil {
+					return err
+				}
+				approved, err := c.approvedGuestCount(tx, target.ID)
+				if err != nil {
+					return err
+				}
+				if approved+moving > target.MaxGuests {
+					return ErrCategoryFull
+				}
+			}
+
+			if _, err := tx.Exec(c.rebind(`UPDATE guests SET category_id = ? WHERE category_id = ?`), target.ID, category.ID); err != nil {
+				return err
+			}
+
+			res, err := tx.Exec(c.rebind(`UPDATE rsvps SET category_id = ? WHERE category_id = ?`), target.ID, category.ID)
+			if err != nil {
+				return err
+			}
+			reassigned, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			result.Reassigned = int(reassigned)
+
+			result.Promoted, err = c.promoteWaitlist(tx, target)
+			if err != nil {
+				return err
+			}
+		} else {
+			rows, err := tx.Query(c.rebind(`SELECT id FROM rsvps WHERE category_id = ? AND status <> 'REJECTED'`), category.ID)
+			if err != nil {
+				return err
+			}
+			var ids []uuid.UUID
+			for rows.Next() {
+				var id uuid.UUID
+				if err := rows.Scan(&id); err != nil {
+					rows.Close()
+					return err
+				}
+				ids = append(ids, id)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			var freedEvents []uuid.UUID
+			for _, id := range ids {
+				rsvp, err := c.getRSVP(tx, id, false)
+				if err != nil {
+					return err
+				}
+				if rsvp.Status == "APPROVED" {
+					events, err := c.rsvpEventIDs(tx, rsvp.ID)
+					if err != nil {
+						return err
+					}
+					freedEvents = append(freedEvents, events...)
+				}
+				rsvp.Status = "REJECTED"
+				if err := c.queueRSVPEmail(tx, EmailRSVPRejected, rsvp); err != nil {
+					return err
+				}
+				result.Rejected = append(result.Rejected, rsvp)
+			}
+
+			query := `DELETE FROM seat_assignments WHERE rsvp_id IN (SELECT id FROM rsvps WHERE category_id = ?)`
+			if _, err := tx.Exec(c.rebind(query), category.ID); err != nil {
+				return err
+			}
+
+			query = `UPDATE rsvps SET status = 'REJECTED' WHERE category_id = ?`
+			if _, err := tx.Exec(c.rebind(query), category.ID); err != nil {
+				return err
+			}
+
+			// The category's own waitlist was rejected too, so only other
+			// categories' guests can take the freed event seats.
+			result.Promoted, err = c.releaseSeats(tx, uuid.NullUUID{}, freedEvents)
+			if err != nil {
+				return err
+			}
+
+			res, err := tx.Exec(c.rebind(`DELETE FROM guests WHERE category_id = ?`), category.ID)
+			if err != nil {
+				return err
+			}
+			removed, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			result.RemovedInvitees = int(removed)
+		}
+
+		if _, err := tx.Exec(c.rebind(`DELETE FROM category_events WHERE category_id = ?`), category.ID); err != nil {
+			return err
+		}
+
+		var remaining int
+		if err := tx.QueryRow(c.rebind(`SELECT COUNT(*) FROM rsvps WHERE category_id = ?`), category.ID).Scan(&remaining); err != nil {
+			return err
+		}
+		if remaining == 0 {
+			_, err = tx.Exec(c.rebind(`DELETE FROM guest_categories WHERE id = ?`), category.ID)
+			return err
+		}
+
+		// The tombstone's token is replaced so that its links stop working
+		// even where tombstones are not filtered out.
+		query := `
+    UPDATE guest_categories
+    SET deleted_at = ?, default_category = false, invitation_token = ?
+    WHERE id = ?`
+		_, err = tx.Exec(c.rebind(query), time.Now().UTC(), "deleted-"+category.ID.String(), category.ID)
+		return err
+	})
+	if err != nil {
+		return DeleteCategoryResult{}, err
+	}
+
+	return result, nil
+}
+
+// GetCategoryByInvitationToken retrieves the guest category an invitation link points to.
+func (c Client) GetCategoryByInvitationToken(token string) (GuestCategory, error) {
+	query := `
+    SELECT
+        id,
+        name,
+        side,
+        max_guests,
+        invitation_token,
+        default_category,
+        couple_id,
+        created_at
+    FROM guest_categories
+    WHERE invitation_token = ? AND deleted_at IS NULL`
+
+	var category GuestCategory
+	err := c.DB.QueryRow(c.rebind(query), token).Scan(
+		&category.ID,
+		&category.Name,
+		&category.Side,
+		&category.MaxGuests,
+		&category.InvitationToken,
+		&category.DefaultCategory,
+		&category.CoupleID,
+		&category.CreatedAt,
 	)
-	return err
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return GuestCategory{}, nil
+		}
+		return GuestCategory{}, err
+	}
+
+	return category, nil
 }
 
-// DeleteCategory removes a guest category from the database.
-func (c Client) DeleteCategory(id uuid.UUID) error {
-	// Note: You may want to add logic to handle what happens to RSVPs in this category.
-	query := `DELETE FROM guest_categories WHERE id = ?`
-	_, err := c.DB.Exec(query, id)
-	return err
+// RotateInvitationToken replaces a category's invitation token, invalidating links that used the old one.
+func (c Client) RotateInvitationToken(id, coupleID uuid.UUID, token string) (GuestCategory, error) {
+	query := `
+    UPDATE guest_categories
+    SET invitation_token = ?
+    WHERE id = ? AND couple_id = ? AND deleted_at IS NULL`
+
+	result, err := c.DB.Exec(c.rebind(query), token, id, coupleID)
+	if err != nil {
+		return GuestCategory{}, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return GuestCategory{}, err
+	}
+	if rowsAffected == 0 {
+		return GuestCategory{}, errors.New("guest category not found")
+	}
+
+	return c.GetCategory(id)
 }
 
+// GetApprovedGuestCount returns the number of guests already approved in a category.
 func (c Client) GetApprovedGuestCount(categoryID uuid.UUID) (int, error) {
+	return c.approvedGuestCount(c.DB, categoryID)
+}
+
+func (c Client) approvedGuestCount(q querier, categoryID uuid.UUID) (int, error) {
 	query := `
     SELECT COALESCE(SUM(number_of_guests), 0)
     FROM rsvps
     WHERE category_id = ? AND status = 'APPROVED'`
 
 	var count int
-	err := c.DB.QueryRow(query, categoryID).Scan(&count)
+	err := q.QueryRow(c.rebind(query), categoryID).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -225,21 +548,23 @@ func (c Client) GetCategoryBySideDefault(side string) (GuestCategory, error) {
         side,
         max_guests,
         invitation_token,
+        default_category,
         couple_id,
         created_at
     FROM guest_categories
-    WHERE side = ? AND default_category = true
+    WHERE side = ? AND default_category = true AND deleted_at IS NULL
 		ORDER BY created_at ASC
 		LIMIT 1;
 		`
 
 	var category GuestCategory
-	err := c.DB.QueryRow(query, side).Scan(
+	err := c.DB.QueryRow(c.rebind(query), side).Scan(
 		&category.ID,
 		&category.Name,
 		&category.Side,
 		&category.MaxGuests,
 		&category.InvitationToken,
+		&category.DefaultCategory,
 		&category.CoupleID,
 		&category.CreatedAt,
 	)
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDeleteCategoryRejectingRSVPs(t *testing.T) {
+	c := newTestClient(t)
+
+	capacity := 4
+	event, err := c.CreateEvent(EventParams{Name: "Reception", Venue: "Hall", StartsAt: time.Now().Add(24 * time.Hour), Capacity: &capacity})
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+
+	deleted := newTestCategory(t, c, "BRIDE", 10)
+	other := newTestCategory(t, c, "BRIDE", 10)
+	create := func(category GuestCategory, name string, size int) RSVP {
+		t.Helper()
+		rsvp, err := c.CreateRSVPWithinCapacity(CreateRSVPParams{
+			GuestName:      name,
+			NumberOfGuests: size,
+			Email:          uuid.NewString() + "@example.com",
+			Phone:          uuid.NewString(),
+			CategoryID:     nullID(category.ID),
+			Events:         []uuid.UUID{event.ID},
+		})
+		if err != nil {
+			t.Fatalf("CreateRSVPWithinCapacity: %v", err)
+		}
+		return rsvp
+	}
+	seated := create(deleted, "Seated", 3)
+	waiting := create(other, "Waiting", 2)
+	if seated.Status != "APPROVED" || waiting.Status != "WAITLISTED" {
+		t.Fatalf("statuses = %s, %s, want APPROVED, WAITLISTED", seated.Status, waiting.Status)
+	}
+
+	result, err := c.DeleteCategory(DeleteCategoryParams{ID: deleted.ID, CoupleID: deleted.CoupleID})
+	if err != nil {
+		t.Fatalf("DeleteCategory: %v", err)
+	}
+	if len(result.Rejected) != 1 || result.Rejected[0].ID != seated.ID {
+		t.Errorf("rejected = %v, want only %s", result.Rejected, seated.ID)
+	}
+	if len(result.Promoted) != 1 || result.Promoted[0].ID != waiting.ID {
+		t.Errorf("promoted = %v, want only %s, whose event seats were freed", result.Promoted, waiting.ID)
+	}
+
+	// The rejected RSVP keeps its category and so stays in the side's list.
+	page, err := c.ListRSVPs(ListRSVPsParams{RSVPFilter: RSVPFilter{Side: "BRIDE", Status: "REJECTED"}, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListRSVPs: %v", err)
+	}
+	if len(page.RSVPs) != 1 || page.RSVPs[0].ID != seated.ID || page.RSVPs[0].CategoryID.UUID != deleted.ID {
+		t.Errorf("rejected RSVPs = %v, want %s in category %s", page.RSVPs, seated.ID, deleted.ID)
+	}
+
+	// The category itself survives only as a tombstone.
+	tombstone, err := c.GetCategory(deleted.ID)
+	if err != nil {
+		t.Fatalf("GetCategory: %v", err)
+	}
+	if tombstone.ID != deleted.ID || tombstone.DeletedAt == nil {
+		t.Errorf("GetCategory = %+v, want a tombstone", tombstone)
+	}
+	byToken, err := c.GetCategoryByInvitationToken(*deleted.InvitationToken)
+	if err != nil || byToken.ID != uuid.Nil {
+		t.Errorf("GetCategoryByInvitationToken = %s, %v, want no category", byToken.ID, err)
+	}
+	listed, err := c.ListCategoriesByCouple(deleted.CoupleID)
+	if err != nil || len(listed) != 0 {
+		t.Errorf("ListCategoriesByCouple = %v, %v, want no categories", listed, err)
+	}
+	if _, err := c.DeleteCategory(DeleteCategoryParams{ID: deleted.ID, CoupleID: deleted.CoupleID}); err == nil {
+		t.Errorf("deleting the tombstone succeeded, want an error")
+	}
+
+	// A category without RSVPs is removed outright.
+	empty := newTestCategory(t, c, "GROOM", 10)
+	if _, err := c.DeleteCategory(DeleteCategoryParams{ID: empty.ID, CoupleID: empty.CoupleID}); err != nil {
+		t.Fatalf("DeleteCategory: %v", err)
+	}
+	if got, err := c.GetCategory(empty.ID); err != nil || got.ID != uuid.Nil {
+		t.Errorf("GetCategory = %s, %v, want no category", got.ID, err)
+	}
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// MealCount is how many attendees chose one meal option.
+type MealCount struct {
+	MealOptionID uuid.UUID `json:"meal_option_id"`
+	Name         string    `json:"name"`
+	Count        int       `json:"count"`
+}
+
+// DietaryCount is how many attendees share one dietary restriction.
+type DietaryCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// AllergyNote is one attendee's allergy notes, with the RSVP they came on.
+type AllergyNote struct {
+	Attendee  string `json:"attendee"`
+	GuestName string `json:"guest_name"`
+	Notes     string `json:"notes"`
+}
+
+// CateringSummary totals the approved guests of a category, a side or the whole
+// wedding for the caterer. Guests counts everyone approved, named or not, so
+// NoMealChoice covers both unnamed guests and attendees who skipped the menu.
+type CateringSummary struct {
+	CategoryID   uuid.UUID      `json:"category_id,omitzero"`
+	Name         string         `json:"name,omitempty"`
+	Side         string         `json:"side,omitempty"`
+	Guests       int            `json:"guests"`
+	Children     int            `json:"children"`
+	Meals        []MealCount    `json:"meals"`
+	NoMealChoice int            `json:"no_meal_choice"`
+	Dietary      []DietaryCount `json:"dietary"`
+	Allergies    []AllergyNote  `json:"allergies"`
+}
+
+// CateringReport breaks approved meal choices and dietary needs down by category
+// and side, with a grand total.
+type CateringReport struct {
+	Categories []CateringSummary `json:"categories"`
+	Sides      []CateringSummary `json:"sides"`
+	Total      CateringSummary   `json:"total"`
+}
+
+// GetCateringReport aggregates the meal choices, age groups, dietary restrictions
+// and allergies of attendees on approved RSVPs.
+func (c Client) GetCateringReport() (CateringReport, error) {
+	query := `
+    SELECT
+        gc.id,
+        gc.name,
+        gc.side,
+        COALESCE(SUM(r.number_of_guests), 0)
+    FROM guest_categories gc
+    LEFT JOIN rsvps r ON (r.category_id = gc.id AND r.status = 'APPROVED')
+    WHERE gc.deleted_at IS NULL
+    GROUP BY gc.id, gc.name, gc.side
+    ORDER BY gc.side ASC, gc.name ASC`
+
+	rows, err := c.DB.Query(query)
+	if err != nil {
+		return CateringReport{}, err
+	}
+	defer rows.Close()
+
+	var categories []*CateringSummary
+	byCategory := map[uuid.UUID]*CateringSummary{}
+	for rows.Next() {
+		summary := &CateringSummary{}
+		if err := rows.Scan(&summary.CategoryID, &summary.Name, &summary.Side, &summary.Guests); err != nil {
+			return CateringReport{}, err
+		}
+		categories = append(categories, summary)
+		byCategory[summary.CategoryID] = summary
+	}
+	if err := rows.Err(); err != nil {
+		return CateringReport{}, err
+	}
+
+	if err := c.addMealCounts(byCategory); err != nil {
+		return CateringReport{}, err
+	}
+	if err := c.addAttendeeNeeds(byCategory); err != nil {
+		return CateringReport{}, err
+	}
+
+	report := CateringReport{
+		Categories: make([]CateringSummary, 0, len(categories)),
+		Sides:      []CateringSummary{},
+	}
+	sides := map[string]*CateringSummary{}
+	var sideOrder []string
+	for _, category := range categories {
+		finishCateringSummary(category)
+		report.Categories = append(report.Categories, *category)
+
+		side, ok := sides[category.Side]
+		if !ok {
+			side = &CateringSummary{Side: category.Side}
+			sides[category.Side] = side
+			sideOrder = append(sideOrder, category.Side)
+		}
+		mergeCateringSummary(side, *category)
+		mergeCateringSummary(&report.Total, *category)
+	}
+	for _, name := range sideOrder {
+		finishCateringSummary(sides[name])
+		report.Sides = append(report.Sides, *sides[name])
+	}
+	finishCateringSummary(&report.Total)
+
+	return report, nil
+}
+
+// addMealCounts fills in each category's meal counts.
+func (c Client) addMealCounts(byCategory map[uuid.UUID]*CateringSummary) error {
+	query := `
+    SELECT
+        r.category_id,
+        mo.id,
+        mo.name,
+        COUNT(*)
+    FROM rsvp_attendees ra
+    JOIN rsvps r ON (r.id = ra.rsvp_id)
+    JOIN meal_options mo ON (mo.id = ra.meal_option_id)
+    WHERE r.status = 'APPROVED'
+    GROUP BY r.category_id, mo.id, mo.name`
+
+	rows, err := c.DB.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var categoryID uuid.UUID
+		var meal MealCount
+		if err := rows.Scan(&categoryID, &meal.MealOptionID, &meal.Name, &meal.Count); err != nil {
+			return err
+		}
+		if summary, ok := byCategory[categoryID]; ok {
+			summary.Meals = append(summary.Meals, meal)
+		}
+	}
+
+	return rows.Err()
+}
+
+// addAttendeeNeeds fills in each category's children, dietary restrictions and
+// allergy notes. Dietary restrictions are stored as a tag list, so they are
+// counted here rather than in SQL.
+func (c Client) addAttendeeNeeds(byCategory map[uuid.UUID]*CateringSummary) error {
+	query := `
+    SELECT
+        r.category_id,
+        r.guest_name,
+        ra.name,
+        ra.age_group,
+        ra.dietary_restrictions,
+        ra.allergy_notes
+    FROM rsvp_attendees ra
+    JOIN rsvps r ON (r.id = ra.rsvp_id)
+    WHERE r.status = 'APPROVED'
+    ORDER BY r.guest_name ASC, ra.position ASC`
+
+	rows, err := c.DB.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var categoryID uuid.UUID
+		var guestName, name, ageGroup, dietary, allergyNotes string
+		if err := rows.Scan(&categoryID, &guestName, &name, &ageGroup, &dietary, &allergyNotes); err != nil {
+			return err
+		}
+		summary, ok := byCategory[categoryID]
+		if !ok {
+			continue
+		}
+
+		if ageGroup == AgeGroupChild {
+			summary.Children++
+		}
+		for _, tag := range splitDietaryRestrictions(dietary) {
+			summary.Dietary = addDietaryCount(summary.Dietary, DietaryCount{Tag: tag, Count: 1})
+		}
+		if allergyNotes != "" {
+			summary.Allergies = append(summary.Allergies, AllergyNote{Attendee: name, GuestName: guestName, Notes: allergyNotes})
+		}
+	}
+
+	return rows.Err()
+}
+
+// mergeCateringSummary adds the totals of from into into.
+func mergeCateringSummary(into *CateringSummary, from CateringSummary) {
+	into.Guests += from.Guests
+	into.Children += from.Children
+	for _, meal := range from.Meals {
+		found := false
+		for i := range into.Meals {
+			if into.Meals[i].MealOptionID == meal.MealOptionID {
+				into.Meals[i].Count += meal.Count
+				found = true
+				break
+			}
+		}
+		if !found {
+			into.Meals = append(into.Meals, meal)
+		}
+	}
+	for _, dietary := range from.Dietary {
+		into.Dietary = addDietaryCount(into.Dietary, dietary)
+	}
+	into.Allergies = append(into.Allergies, from.Allergies...)
+}
+
+func addDietaryCount(counts []DietaryCount, add DietaryCount) []DietaryCount {
+	for i := range counts {
+		if counts[i].Tag == add.Tag {
+			counts[i].Count += add.Count
+			return counts
+		}
+	}
+	return append(counts, add)
+}
+
+// finishCateringSummary works out NoMealChoice and puts the lists in a stable
+// order, most common first.
+func finishCateringSummary(summary *CateringSummary) {
+	chosen := 0
+	for _, meal := range summary.Meals {
+		chosen += meal.Count
+	}
+	summary.NoMealChoice = max(summary.Guests-chosen, 0)
+
+	if summary.Meals == nil {
+		summary.Meals = []MealCount{}
+	}
+	if summary.Dietary == nil {
+		summary.Dietary = []DietaryCount{}
+	}
+	if summary.Allergies == nil {
+		summary.Allergies = []AllergyNote{}
+	}
+	sort.SliceStable(summary.Meals, func(i, j int) bool {
+		if summary.Meals[i].Count != summary.Meals[j].Count {
+			return summary.Meals[i].Count > summary.Meals[j].Count
+		}
+		return summary.Meals[i].Name < summary.Meals[j].Name
+	})
+	sort.SliceStable(summary.Dietary, func(i, j int) bool {
+		if summary.Dietary[i].Count != summary.Dietary[j].Count {
+			return summary.Dietary[i].Count > summary.Dietary[j].Count
+		}
+		return summary.Dietary[i].Tag < summary.Dietary[j].Tag
+	})
+}
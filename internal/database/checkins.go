@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CheckIn records a guest party's arrival at the venue.
+type CheckIn struct {
+	ID            uuid.UUID `json:"id"`
+	RSVPID        uuid.UUID `json:"rsvp_id"`
+	GuestsArrived int       `json:"guests_arrived"`
+	CheckedInBy   uuid.UUID `json:"checked_in_by"`
+	CheckedInAt   time.Time `json:"checked_in_at"`
+}
+
+// CreateCheckInParams defines the parameters for recording a check-in.
+type CreateCheckInParams struct {
+	RSVPID        uuid.UUID `json:"rsvp_id"`
+	GuestsArrived int       `json:"guests_arrived"`
+	CheckedInBy   uuid.UUID `json:"checked_in_by"`
+}
+
+// CheckInStats summarises arrivals against the approved guest list.
+type CheckInStats struct {
+	ApprovedParties  int `json:"approved_parties"`
+	ApprovedGuests   int `json:"approved_guests"`
+	CheckedInParties int `json:"checked_in_parties"`
+	GuestsArrived    int `json:"guests_arrived"`
+}
+
+// CreateCheckIn inserts a check-in for an RSVP. An RSVP can only be checked in once.
+func (c Client) CreateCheckIn(params CreateCheckInParams) (CheckIn, error) {
+	id := uuid.New()
+	query := `
+    INSERT INTO checkins (
+        id,
+        rsvp_id,
+        guests_arrived,
+        checked_in_by
+    ) VALUES (?, ?, ?, ?)`
+
+	_, err := c.DB.Exec(
+		c.rebind(query),
+		id,
+		params.RSVPID,
+		params.GuestsArrived,
+		params.CheckedInBy,
+	)
+	if err != nil {
+		return CheckIn{}, err
+	}
+
+	return c.GetCheckInByRSVP(params.RSVPID)
+}
+
+// GetCheckInByRSVP retrieves the check-in recorded for an RSVP, if any.
+func (c Client) GetCheckInByRSVP(rsvpID uuid.UUID) (CheckIn, error) {
+	query := `
+    SELECT
+        id,
+        rsvp_id,
+        guests_arrived,
+        checked_in_by,
+        checked_in_at
+    FROM checkins
+    WHERE rsvp_id = ?`
+
+	var checkIn CheckIn
+	err := c.DB.QueryRow(c.rebind(query), rsvpID).Scan(
+		&checkIn.ID,
+		&checkIn.RSVPID,
+		&checkIn.GuestsArrived,
+		&checkIn.CheckedInBy,
+		&checkIn.CheckedInAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return CheckIn{}, nil
+		}
+		return CheckIn{}, err
+	}
+
+	return checkIn, nil
+}
+
+// GetCheckInStats returns how many approved parties and guests have arrived so
+// far across both sides, as the door team sees them.
+func (c Client) GetCheckInStats() (CheckInStats, error) {
+	return c.getCheckInStats("")
+}
+
+// getCheckInStats is GetCheckInStats limited to the guests of one side when side is set.
+func (c Client) getCheckInStats(side string) (CheckInStats, error) {
+	query := `
+    SELECT
+        COUNT(*),
+        COALESCE(SUM(rsvps.number_of_guests), 0),
+        COUNT(checkins.id),
+        COALESCE(SUM(checkins.guests_arrived), 0)
+    FROM rsvps
+    LEFT JOIN checkins ON checkins.rsvp_id = rsvps.id
+    WHERE rsvps.status = 'APPROVED'`
+	var args []any
+	if side != "" {
+		query += ` AND rsvps.category_id IN (SELECT id FROM guest_categories WHERE side = ?)`
+		args = append(args, side)
+	}
+
+	var stats CheckInStats
+	err := c.DB.QueryRow(c.rebind(query), args...).Scan(
+		&stats.ApprovedParties,
+		&stats.ApprovedGuests,
+		&stats.CheckedInParties,
+		&stats.GuestsArrived,
+	)
+	if err != nil {
+		return CheckInStats{}, err
+	}
+
+	return stats, nil
+}
@@ -33,7 +33,7 @@ func (c Client) CreateCouple(params CreateCoupleParams) (Couple, error) {
     INSERT INTO couples (id, name, email, side)
     VALUES (?, ?, ?, ?)`
 
-	_, err := c.DB.Exec(query, id, params.Name, params.Email, params.Side)
+	_, err := c.DB.Exec(c.rebind(query), id, params.Name, params.Email, params.Side)
 	if err != nil {
 		return Couple{}, err
 	}
@@ -46,7 +46,7 @@ func (c Client) GetCouple(id uuid.UUID) (Couple, error) {
 	query := `SELECT id, name, email, side, created_at FROM couples WHERE id = ?`
 
 	var couple Couple
-	err := c.DB.QueryRow(query, id).Scan(
+	err := c.DB.QueryRow(c.rebind(query), id).Scan(
 		&couple.ID,
 		&couple.Name,
 		&couple.Email,
@@ -63,12 +63,40 @@ func (c Client) GetCouple(id uuid.UUID) (Couple, error) {
 	return couple, nil
 }
 
+// ListCouples retrieves every couple account.
+func (c Client) ListCouples() ([]Couple, error) {
+	query := `SELECT id, name, email, side, created_at FROM couples ORDER BY created_at ASC`
+
+	rows, err := c.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var couples []Couple
+	for rows.Next() {
+		var couple Couple
+		if err := rows.Scan(
+			&couple.ID,
+			&couple.Name,
+			&couple.Email,
+			&couple.Side,
+			&couple.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		couples = append(couples, couple)
+	}
+
+	return couples, rows.Err()
+}
+
 // GetCoupleByEmail retrieves a single couple by their email address.
 func (c Client) GetCoupleByEmail(email string) (Couple, error) {
 	query := `SELECT id, name, email, side, created_at FROM couples WHERE email = ?`
 
 	var couple Couple
-	err := c.DB.QueryRow(query, email).Scan(
+	err := c.DB.QueryRow(c.rebind(query), email).Scan(
 		&couple.ID,
 		&couple.Name,
 		&couple.Email,
@@ -85,10 +113,9 @@ func (c Client) GetCoupleByEmail(email string) (Couple, error) {
 }
 
 // StoreOTPForCouple saves a generated OTP and its expiry time for a user.
-// NOTE: You need to add `otp` and `otp_expiry` columns to your `couples` table for this.
 func (c Client) StoreOTPForCouple(email string, otp string, expiry time.Time) error {
 	query := `UPDATE couples SET otp = ?, otp_expiry = ? WHERE email = ?`
-	_, err := c.DB.Exec(query, otp, expiry, email)
+	_, err := c.DB.Exec(c.rebind(query), otp, expiry.UTC(), email)
 	return err
 }
 
@@ -97,10 +124,10 @@ func (c Client) VerifyOTPForCouple(email string, otp string) (Couple, error) {
 	query := `
     SELECT id, name, email, side, created_at
     FROM couples
-    WHERE email = ? AND otp = ? AND otp_expiry > CURRENT_TIMESTAMP`
+    WHERE email = ? AND otp = ? AND otp_expiry > ?`
 
 	var couple Couple
-	err := c.DB.QueryRow(query, email, otp).Scan(
+	err := c.DB.QueryRow(c.rebind(query), email, otp, time.Now().UTC()).Scan(
 		&couple.ID,
 		&couple.Name,
 		&couple.Email,
@@ -117,7 +144,7 @@ func (c Client) VerifyOTPForCouple(email string, otp string) (Couple, error) {
 
 	// Optional: Clear the OTP after successful verification
 	// query = `UPDATE couples SET otp = NULL, otp_expiry = NULL WHERE email = ?`
-	// c.DB.Exec(query, email)
+	// c.DB.Exec(c.rebind(query), email)
 
 	return couple, nil
 }
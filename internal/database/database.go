@@ -2,18 +2,55 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// dialect identifies the SQL flavour spoken by the underlying driver.
+type dialect string
+
+const (
+	dialectSQLite   dialect = "sqlite3"
+	dialectPostgres dialect = "postgres"
 )
 
 type Client struct {
-	DB *sql.DB
+	DB      *sql.DB
+	dialect dialect
 }
 
+// NewClient opens a database connection and applies any pending migrations.
+// It refuses to start if the database has been migrated past what this binary knows.
 func NewClient(dataSourceName string) (Client, error) {
-	db, err := sql.Open("sqlite3", dataSourceName)
+	c, err := Open(dataSourceName)
+	if err != nil {
+		return Client{}, err
+	}
+
+	if err := c.checkSchemaNotAhead(); err != nil {
+		return Client{}, err
+	}
+	if err := c.MigrateUp(); err != nil {
+		return Client{}, fmt.Errorf("migration failed: %w", err)
+	}
+
+	log.Printf("Database client (%s) initialized and migrated successfully.", c.dialect)
+	return c, nil
+}
+
+// Open connects to the database without touching its schema, choosing the driver
+// from the DSN scheme. "postgres://" and "postgresql://" DSNs use PostgreSQL;
+// anything else, including an optional "sqlite://" prefix, is a SQLite database path.
+func Open(dataSourceName string) (Client, error) {
+	d, dsn := parseDSN(dataSourceName)
+
+	db, err := sql.Open(string(d), dsn)
 	if err != nil {
 		return Client{}, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -22,68 +59,106 @@ func NewClient(dataSourceName string) (Client, error) {
 		return Client{}, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	c := Client{DB: db}
-	if err := c.autoMigrate(); err != nil {
-		return Client{}, fmt.Errorf("migration failed: %w", err)
+	return Client{DB: db, dialect: d}, nil
+}
+
+// parseDSN maps a data source name onto the dialect that should serve it.
+func parseDSN(dataSourceName string) (dialect, string) {
+	switch {
+	case strings.HasPrefix(dataSourceName, "postgres://"), strings.HasPrefix(dataSourceName, "postgresql://"):
+		return dialectPostgres, dataSourceName
+	case strings.HasPrefix(dataSourceName, "sqlite://"):
+		return dialectSQLite, sqliteDSN(strings.TrimPrefix(dataSourceName, "sqlite://"))
+	case strings.HasPrefix(dataSourceName, "sqlite3://"):
+		return dialectSQLite, sqliteDSN(strings.TrimPrefix(dataSourceName, "sqlite3://"))
+	default:
+		return dialectSQLite, sqliteDSN(dataSourceName)
+	}
+}
+
+// sqliteDSN makes every SQLite transaction take the write lock up front and wait
+// for a busy database instead of failing, so that concurrent capacity checks
+// serialise rather than racing or erroring with SQLITE_BUSY.
+func sqliteDSN(dsn string) string {
+	for _, param := range []string{"_txlock=immediate", "_busy_timeout=10000"} {
+		key := param[:strings.Index(param, "=")+1]
+		if strings.Contains(dsn, key) {
+			continue
+		}
+		if strings.Contains(dsn, "?") {
+			dsn += "&" + param
+		} else {
+			dsn += "?" + param
+		}
 	}
+	return dsn
+}
 
-	log.Println("Database client initialized and migrated successfully.")
-	return c, nil
+// querier is implemented by both *sql.DB and *sql.Tx so lookups can be shared
+// between standalone calls and transactions.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// withTx runs fn inside a transaction, committing only if fn returns nil.
+func (c Client) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// autoMigrate creates all necessary tables if they don't already exist.
-func (c *Client) autoMigrate() error {
-	couplesTable := `
-    CREATE TABLE IF NOT EXISTS couples (
-        id TEXT PRIMARY KEY,
-        name TEXT NOT NULL,
-        email TEXT NOT NULL UNIQUE,
-        side TEXT NOT NULL CHECK(side IN ('BRIDE', 'GROOM')),
-				otp TEXT,
-				otp_expiry TIMESTAMP,
-        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-    );`
-
-	guestCategoriesTable := `
-    CREATE TABLE IF NOT EXISTS guest_categories (
-        id TEXT PRIMARY KEY,
-        name TEXT NOT NULL,
-        side TEXT NOT NULL CHECK(side IN ('BRIDE', 'GROOM')),
-        max_guests INTEGER NOT NULL,
-        invitation_token TEXT NOT NULL UNIQUE,
-        couple_id TEXT NOT NULL,
-				default_category BOOLEAN NOT NULL DEFAULT false,
-        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-        FOREIGN KEY (couple_id) REFERENCES couples(id)
-    );`
-
-	rsvpsTable := `
-    CREATE TABLE IF NOT EXISTS rsvps (
-        id TEXT PRIMARY KEY,
-        guest_name TEXT NOT NULL,
-        email TEXT NOT NULL UNIQUE,
-        phone TEXT NOT NULL UNIQUE,
-        number_of_guests INTEGER NOT NULL,
-        status TEXT NOT NULL DEFAULT 'PENDING' CHECK(status IN ('PENDING', 'APPROVED', 'REJECTED')),
-        category_id TEXT,
-        submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-        FOREIGN KEY (category_id) REFERENCES guest_categories(id)
-    );
-		
-		CREATE INDEX IF NOT EXISTS idx_rsvps_category_id ON rsvps(category_id);
-
-		`
-
-	// Execute tables in order of dependency
-	if _, err := c.DB.Exec(couplesTable); err != nil {
-		return fmt.Errorf("failed to create couples table: %w", err)
+// forUpdate appends a row-locking clause on dialects that support it. SQLite
+// transactions already hold the database write lock (see sqliteDSN).
+func (c Client) forUpdate(query string) string {
+	if c.dialect == dialectPostgres {
+		return query + " FOR UPDATE"
 	}
-	if _, err := c.DB.Exec(guestCategoriesTable); err != nil {
-		return fmt.Errorf("failed to create guest_categories table: %w", err)
+	return query
+}
+
+// rebind rewrites the "?" placeholders used throughout this package into the
+// positional "$n" form PostgreSQL expects. SQLite queries are returned untouched.
+func (c Client) rebind(query string) string {
+	if c.dialect != dialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
 	}
-	if _, err := c.DB.Exec(rsvpsTable); err != nil {
-		return fmt.Errorf("failed to create rsvps table: %w", err)
+	return b.String()
+}
+
+// IsUniqueConstraintError reports whether err was caused by a UNIQUE constraint violation.
+func IsUniqueConstraintError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
 	}
 
-	return nil
+	return false
 }
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// newTestClient returns a migrated SQLite database that is removed when the test ends.
+func newTestClient(t *testing.T) Client {
+	t.Helper()
+
+	c, err := NewClient(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.DB.Close() })
+	return c
+}
+
+// newTestCategory creates a couple and a capacity-limited category for them.
+func newTestCategory(t *testing.T, c Client, side string, maxGuests int) GuestCategory {
+	t.Helper()
+
+	couple, err := c.CreateCouple(CreateCoupleParams{Name: side + " couple", Email: uuid.NewString() + "@example.com", Side: side})
+	if err != nil {
+		t.Fatalf("CreateCouple: %v", err)
+	}
+	token := uuid.NewString()
+	category, err := c.CreateCategory(CreateCategoryParams{
+		Name:            "Friends " + token[:8],
+		Side:            side,
+		MaxGuests:       maxGuests,
+		InvitationToken: &token,
+		CoupleID:        couple.ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	return category
+}
+
+func nullID(id uuid.UUID) uuid.NullUUID {
+	return uuid.NullUUID{UUID: id, Valid: true}
+}
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect
+		query   string
+		want    string
+	}{
+		{"sqlite untouched", dialectSQLite, "SELECT * FROM rsvps WHERE id = ? AND status = ?", "SELECT * FROM rsvps WHERE id = ? AND status = ?"},
+		{"postgres numbered", dialectPostgres, "SELECT * FROM rsvps WHERE id = ? AND status = ?", "SELECT * FROM rsvps WHERE id = $1 AND status = $2"},
+		{"postgres no placeholders", dialectPostgres, "SELECT 1", "SELECT 1"},
+		{"postgres many", dialectPostgres, "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", "VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)"},
+		{"postgres multibyte", dialectPostgres, "SELECT 'é' WHERE a = ?", "SELECT 'é' WHERE a = $1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Client{dialect: tt.dialect}
+			if got := c.rebind(tt.query); got != tt.want {
+				t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	tests := []struct {
+		dsn         string
+		wantDialect dialect
+		wantDSN     string
+	}{
+		{"postgres://u:p@localhost/db", dialectPostgres, "postgres://u:p@localhost/db"},
+		{"postgresql://localhost/db?sslmode=disable", dialectPostgres, "postgresql://localhost/db?sslmode=disable"},
+		{"wedding.db", dialectSQLite, "wedding.db?_txlock=immediate&_busy_timeout=10000"},
+		{"sqlite://wedding.db", dialectSQLite, "wedding.db?_txlock=immediate&_busy_timeout=10000"},
+		{"sqlite3://wedding.db?cache=shared", dialectSQLite, "wedding.db?cache=shared&_txlock=immediate&_busy_timeout=10000"},
+		{"wedding.db?_busy_timeout=500", dialectSQLite, "wedding.db?_busy_timeout=500&_txlock=immediate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dsn, func(t *testing.T) {
+			gotDialect, gotDSN := parseDSN(tt.dsn)
+			if gotDialect != tt.wantDialect || gotDSN != tt.wantDSN {
+				t.Errorf("parseDSN(%q) = %q, %q, want %q, %q", tt.dsn, gotDialect, gotDSN, tt.wantDialect, tt.wantDSN)
+			}
+		})
+	}
+}
+
+func TestIsUniqueConstraintErrorSQLite(t *testing.T) {
+	c := newTestClient(t)
+	testUniqueConstraintError(t, c)
+}
+
+// TestPostgresStore runs a store round trip against the PostgreSQL database in
+// DATABASE_URL. It is skipped when that is not set.
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	if d, _ := parseDSN(dsn); d != dialectPostgres {
+		t.Skip("DATABASE_URL is not a PostgreSQL database")
+	}
+
+	c, err := NewClient(dsn)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.DB.Close() })
+
+	category := newTestCategory(t, c, "BRIDE", 10)
+	got, err := c.GetCategory(category.ID)
+	if err != nil {
+		t.Fatalf("GetCategory: %v", err)
+	}
+	if got.ID != category.ID || got.MaxGuests != 10 {
+		t.Errorf("GetCategory = %+v, want %+v", got, category)
+	}
+
+	rsvp, err := c.CreateRSVPWithinCapacity(CreateRSVPParams{
+		GuestName:      "Ada Lovelace",
+		NumberOfGuests: 2,
+		Email:          uuid.NewString() + "@example.com",
+		Phone:          uuid.NewString(),
+		CategoryID:     nullID(category.ID),
+	})
+	if err != nil {
+		t.Fatalf("CreateRSVPWithinCapacity: %v", err)
+	}
+	if rsvp.Status != "APPROVED" {
+		t.Errorf("status = %s, want APPROVED", rsvp.Status)
+	}
+	t.Cleanup(func() { c.DeleteRSVP(rsvp.ID) })
+
+	testUniqueConstraintError(t, c)
+}
+
+// testUniqueConstraintError checks that a duplicate couple email is reported
+// as a unique constraint violation and other errors are not.
+func testUniqueConstraintError(t *testing.T, c Client) {
+	t.Helper()
+
+	params := CreateCoupleParams{Name: "Diamond", Email: uuid.NewString() + "@example.com", Side: "BRIDE"}
+	if _, err := c.CreateCouple(params); err != nil {
+		t.Fatalf("CreateCouple: %v", err)
+	}
+	_, err := c.CreateCouple(params)
+	if !IsUniqueConstraintError(err) {
+		t.Errorf("duplicate email: IsUniqueConstraintError(%v) = false, want true", err)
+	}
+
+	_, err = c.DB.Exec("SELECT * FROM no_such_table")
+	if err == nil || IsUniqueConstraintError(err) {
+		t.Errorf("missing table: IsUniqueConstraintError(%v) = true, want false", err)
+	}
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailTemplate is a couple's own wording for one of the built-in emails.
+type EmailTemplate struct {
+	ID        uuid.UUID `json:"id"`
+	CoupleID  uuid.UUID `json:"couple_id"`
+	Name      string    `json:"name"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SaveEmailTemplateParams defines a couple's wording for a built-in email.
+type SaveEmailTemplateParams struct {
+	CoupleID uuid.UUID
+	Name     string
+	Subject  string
+	Body     string
+}
+
+const emailTemplateColumns = `
+        id,
+        couple_id,
+        name,
+        subject,
+        body,
+        updated_at`
+
+func scanEmailTemplate(row interface{ Scan(...any) error }) (EmailTemplate, error) {
+	var template EmailTemplate
+	err := row.Scan(
+		&template.ID,
+		&template.CoupleID,
+		&template.Name,
+		&template.Subject,
+		&template.Body,
+		&template.UpdatedAt,
+	)
+	return template, err
+}
+
+// ListEmailTemplates returns the templates a couple has overridden, by name.
+func (c Client) ListEmailTemplates(coupleID uuid.UUID) ([]EmailTemplate, error) {
+	query := `SELECT ` + emailTemplateColumns + ` FROM email_templates WHERE couple_id = ? ORDER BY name ASC`
+
+	rows, err := c.DB.Query(c.rebind(query), coupleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []EmailTemplate{}
+	for rows.Next() {
+		template, err := scanEmailTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	return templates, rows.Err()
+}
+
+// GetEmailTemplate retrieves a couple's override of the named template, or an
+// empty EmailTemplate if they have not changed it.
+func (c Client) GetEmailTemplate(coupleID uuid.UUID, name string) (EmailTemplate, error) {
+	query := `SELECT ` + emailTemplateColumns + ` FROM email_templates WHERE couple_id = ? AND name = ?`
+
+	template, err := scanEmailTemplate(c.DB.QueryRow(c.rebind(query), coupleID, name))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return EmailTemplate{}, nil
+		}
+		return EmailTemplate{}, err
+	}
+	return template, nil
+}
+
+// SaveEmailTemplate creates or replaces a couple's override of a template.
+// Callers are expected to have validated the subject and body.
+func (c Client) SaveEmailTemplate(params SaveEmailTemplateParams) (EmailTemplate, error) {
+	err := c.withTx(func(tx *sql.Tx) error {
+		query := `
+    UPDATE email_templates
+    SET subject = ?, body = ?, updated_at = ?
+    WHERE couple_id = ? AND name = ?`
+
+		result, err := tx.Exec(c.rebind(query), params.Subject, params.Body, c.timeArg(time.Now()), params.CoupleID, params.Name)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+
+		query = `
+    INSERT INTO email_templates (id, couple_id, name, subject, body)
+    VALUES (?, ?, ?, ?, ?)`
+
+		_, err = tx.Exec(c.rebind(query), uuid.New(), params.CoupleID, params.Name, params.Subject, params.Body)
+		return err
+	})
+	if err != nil {
+		return EmailTemplate{}, err
+	}
+
+	return c.GetEmailTemplate(params.CoupleID, params.Name)
+}
+
+// DeleteEmailTemplate removes a couple's override so the built-in template is
+// used again. It reports whether there was an override to remove.
+func (c Client) DeleteEmailTemplate(coupleID uuid.UUID, name string) (bool, error) {
+	query := `DELETE FROM email_templates WHERE couple_id = ? AND name = ?`
+
+	result, err := c.DB.Exec(c.rebind(query), coupleID, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
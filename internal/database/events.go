@@ -0,0 +1,511 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is one part of the celebration, such as the traditional ceremony, the
+// church service or the reception. Each category's invitation covers some or
+// all of the events, and each RSVP records which of them the party attends.
+type Event struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Venue    string    `json:"venue"`
+	StartsAt time.Time `json:"starts_at"`
+	Address  string    `json:"address"`
+	MapURL   string    `json:"map_url"`
+	// Capacity is nil when the event has no head count of its own.
+	Capacity *int `json:"capacity"`
+	// ApprovedGuests is the number of approved guests attending the event.
+	ApprovedGuests int       `json:"approved_guests"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// EventParams defines the details of a new event.
+type EventParams struct {
+	Name     string
+	Venue    string
+	StartsAt time.Time
+	Address  string
+	MapURL   string
+	Capacity *int
+}
+
+var (
+	// ErrEventNotFound is returned when an event ID does not match any event.
+	ErrEventNotFound = errors.New("event not found")
+	// ErrEventNotInvited is returned when an RSVP asks to attend an event its
+	// category's invitation does not cover.
+	ErrEventNotInvited = errors.New("the invitation does not include this event")
+	// ErrNoEventsChosen is returned when an RSVP explicitly attends no events.
+	ErrNoEventsChosen = errors.New("at least one event must be chosen")
+	// ErrEventFull is returned when approving guests would exceed an event's capacity.
+	ErrEventFull = errors.New("event does not have enough remaining capacity")
+	// ErrEventCapacityBelowApproved is returned when an event's capacity would
+	// drop below the number of guests already approved for it.
+	ErrEventCapacityBelowApproved = errors.New("capacity cannot be lower than the number of guests already approved for the event")
+	// ErrEventInUse is returned when deleting an event that RSVPs or categories refer to.
+	ErrEventInUse = errors.New("event is referenced by RSVPs or categories")
+)
+
+const eventColumns = `
+        events.id,
+        events.name,
+        events.venue,
+        events.starts_at,
+        events.address,
+        events.map_url,
+        events.capacity,
+        (SELECT COALESCE(SUM(r.number_of_guests), 0)
+         FROM rsvp_events re
+         JOIN rsvps r ON (r.id = re.rsvp_id)
+         WHERE re.event_id = events.id AND r.status = 'APPROVED'),
+        events.created_at`
+
+func scanEvent(row interface{ Scan(...any) error }) (Event, error) {
+	var event Event
+	var capacity sql.NullInt64
+	err := row.Scan(
+		&event.ID,
+		&event.Name,
+		&event.Venue,
+		&event.StartsAt,
+		&event.Address,
+		&event.MapURL,
+		&capacity,
+		&event.ApprovedGuests,
+		&event.CreatedAt,
+	)
+	if capacity.Valid {
+		n := int(capacity.Int64)
+		event.Capacity = &n
+	}
+	return event, err
+}
+
+// CreateEvent inserts a new event.
+func (c Client) CreateEvent(params EventParams) (Event, error) {
+	id := uuid.New()
+	query := `
+    INSERT INTO events (
+        id,
+        name,
+        venue,
+        starts_at,
+        address,
+        map_url,
+        capacity
+    ) VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := c.DB.Exec(
+		c.rebind(query),
+		id,
+		params.Name,
+		params.Venue,
+		params.StartsAt.UTC(),
+		params.Address,
+		params.MapURL,
+		params.Capacity,
+	)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return c.GetEvent(id)
+}
+
+// GetEvent retrieves an event by ID.
+func (c Client) GetEvent(id uuid.UUID) (Event, error) {
+	return c.getEvent(c.DB, id, false)
+}
+
+// getEvent loads an event through q. When lock is set the row stays locked
+// until the surrounding transaction ends, serialising capacity checks.
+func (c Client) getEvent(q querier, id uuid.UUID, lock bool) (Event, error) {
+	query := `SELECT` + eventColumns + `
+    FROM events
+    WHERE events.id = ?`
+	if lock {
+		query = c.forUpdate(query)
+	}
+
+	event, err := scanEvent(q.QueryRow(c.rebind(query), id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Event{}, nil
+		}
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+// ListEvents returns every event in the order they take place.
+func (c Client) ListEvents() ([]Event, error) {
+	return c.listEvents(c.DB, "", nil)
+}
+
+func (c Client) listEvents(q querier, where string, args []any) ([]Event, error) {
+	query := `SELECT` + eventColumns + `
+    FROM events`
+	if where != "" {
+		query += "\n    WHERE " + where
+	}
+	query += "\n    ORDER BY events.starts_at ASC, events.name ASC"
+
+	rows, err := q.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// UpdateEvent saves an event's details. Capacity cannot drop below the guests
+// already approved for the event; if it grows, waitlisted RSVPs attending the
+// event are offered the new seats and the promoted RSVPs are returned.
+func (c Client) UpdateEvent(event Event) ([]RSVP, error) {
+	var promoted []RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		existing, err := c.getEvent(tx, event.ID, true)
+		if err != nil {
+			return err
+		}
+		if existing.ID == uuid.Nil {
+			return ErrEventNotFound
+		}
+		if event.Capacity != nil && *event.Capacity < existing.ApprovedGuests {
+			return ErrEventCapacityBelowApproved
+		}
+
+		query := `
+    UPDATE events
+    SET name = ?, venue = ?, starts_at = ?, address = ?, map_url = ?, capacity = ?
+    WHERE id = ?`
+		_, err = tx.Exec(
+			c.rebind(query),
+			event.Name,
+			event.Venue,
+			event.StartsAt.UTC(),
+			event.Address,
+			event.MapURL,
+			event.Capacity,
+			event.ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		grew := existing.Capacity != nil && (event.Capacity == nil || *event.Capacity > *existing.Capacity)
+		if grew {
+			promoted, err = c.releaseSeats(tx, uuid.NullUUID{}, []uuid.UUID{event.ID})
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+// DeleteEvent removes an event that no RSVP attends and no category lists
+// (ErrEventInUse otherwise).
+func (c Client) DeleteEvent(id uuid.UUID) error {
+	return c.withTx(func(tx *sql.Tx) error {
+		query := `
+    SELECT
+        (SELECT COUNT(*) FROM rsvp_events WHERE event_id = ?) +
+        (SELECT COUNT(*) FROM category_events WHERE event_id = ?)`
+
+		var references int
+		if err := tx.QueryRow(c.rebind(query), id, id).Scan(&references); err != nil {
+			return err
+		}
+		if references > 0 {
+			return ErrEventInUse
+		}
+
+		_, err := tx.Exec(c.rebind(`DELETE FROM events WHERE id = ?`), id)
+		return err
+	})
+}
+
+// ListCategoryEvents returns the events a category's invitation covers: the
+// events chosen for it, or every event if none were.
+func (c Client) ListCategoryEvents(categoryID uuid.UUID) ([]Event, error) {
+	return c.listCategoryEvents(c.DB, categoryID)
+}
+
+func (c Client) listCategoryEvents(q querier, categoryID uuid.UUID) ([]Event, error) {
+	events, err := c.listEvents(q, "events.id IN (SELECT event_id FROM category_events WHERE category_id = ?)", []any{categoryID})
+	if err != nil || len(events) > 0 {
+		return events, err
+	}
+	return c.listEvents(q, "", nil)
+}
+
+// categoryEventIDs returns the events explicitly chosen for a category.
+func (c Client) categoryEventIDs(q querier, categoryID uuid.UUID) ([]uuid.UUID, error) {
+	return c.queryIDs(q, `SELECT event_id FROM category_events WHERE category_id = ? ORDER BY event_id`, categoryID)
+}
+
+// setCategoryEvents replaces the events a category's invitation covers. An
+// empty list invites the category to every event. RSVPs already attending an
+// event that is no longer covered keep their place.
+func (c Client) setCategoryEvents(q querier, categoryID uuid.UUID, eventIDs []uuid.UUID) error {
+	if _, err := q.Exec(c.rebind(`DELETE FROM category_events WHERE category_id = ?`), categoryID); err != nil {
+		return err
+	}
+
+	for _, eventID := range uniqueIDs(eventIDs) {
+		event, err := c.getEvent(q, eventID, false)
+		if err != nil {
+			return err
+		}
+		if event.ID == uuid.Nil {
+			return ErrEventNotFound
+		}
+
+		query := `INSERT INTO category_events (category_id, event_id) VALUES (?, ?)`
+		if _, err := q.Exec(c.rebind(query), categoryID, eventID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRSVPEvents returns the events an RSVP's party attends, in the order they take place.
+func (c Client) ListRSVPEvents(rsvpID uuid.UUID) ([]Event, error) {
+	return c.listEvents(c.DB, "events.id IN (SELECT event_id FROM rsvp_events WHERE rsvp_id = ?)", []any{rsvpID})
+}
+
+// rsvpEventIDs returns the events an RSVP's party attends.
+func (c Client) rsvpEventIDs(q querier, rsvpID uuid.UUID) ([]uuid.UUID, error) {
+	return c.queryIDs(q, `SELECT event_id FROM rsvp_events WHERE rsvp_id = ? ORDER BY event_id`, rsvpID)
+}
+
+// resolveRSVPEvents checks the events requested for an RSVP in a category
+// against the category's invitation. A nil request means every event the
+// category is invited to. When no events exist at all, none are returned.
+func (c Client) resolveRSVPEvents(q querier, categoryID uuid.NullUUID, requested []uuid.UUID) ([]uuid.UUID, error) {
+	var invited []Event
+	var err error
+	if categoryID.Valid {
+		invited, err = c.listCategoryEvents(q, categoryID.UUID)
+	} else {
+		invited, err = c.listEvents(q, "", nil)
+	}
+	if err != nil || len(invited) == 0 {
+		return nil, err
+	}
+
+	if requested == nil {
+		ids := make([]uuid.UUID, len(invited))
+		for i, event := range invited {
+			ids[i] = event.ID
+		}
+		return ids, nil
+	}
+
+	requested = uniqueIDs(requested)
+	if len(requested) == 0 {
+		return nil, ErrNoEventsChosen
+	}
+	for _, id := range requested {
+		if !slices.ContainsFunc(invited, func(event Event) bool { return event.ID == id }) {
+			return nil, ErrEventNotInvited
+		}
+	}
+	return requested, nil
+}
+
+// insertRSVPEvents records the events a new RSVP's party attends.
+func (c Client) insertRSVPEvents(q querier, rsvpID uuid.UUID, eventIDs []uuid.UUID) error {
+	for _, eventID := range eventIDs {
+		query := `INSERT INTO rsvp_events (rsvp_id, event_id) VALUES (?, ?)`
+		if _, err := q.Exec(c.rebind(query), rsvpID, eventID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetRSVPEvents changes which events an RSVP's party attends, within what its
+// category is invited to. An approved party must fit in every event it joins
+// (ErrEventFull otherwise); seats it gives up are offered to waitlisted RSVPs
+// attending those events, and the promoted RSVPs are returned.
+func (c Client) SetRSVPEvents(rsvpID uuid.UUID, eventIDs []uuid.UUID) ([]RSVP, error) {
+	var promoted []RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		rsvp, err := c.getRSVP(tx, rsvpID, true)
+		if err != nil {
+			return err
+		}
+		if rsvp.ID == uuid.Nil {
+			return errors.New("no RSVP found with the given ID to update")
+		}
+
+		promoted, err = c.setRSVPEvents(tx, rsvp, eventIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return promoted, nil
+}
+
+// setRSVPEvents is SetRSVPEvents for an RSVP already locked in tx.
+func (c Client) setRSVPEvents(tx *sql.Tx, rsvp RSVP, eventIDs []uuid.UUID) ([]RSVP, error) {
+	wanted, err := c.resolveRSVPEvents(tx, rsvp.CategoryID, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	current, err := c.rsvpEventIDs(tx, rsvp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var joined, left []uuid.UUID
+	for _, id := range wanted {
+		if !slices.Contains(current, id) {
+			joined = append(joined, id)
+		}
+	}
+	for _, id := range current {
+		if !slices.Contains(wanted, id) {
+			left = append(left, id)
+		}
+	}
+
+	if rsvp.Status == "APPROVED" && len(joined) > 0 {
+		fits, err := c.eventsFit(tx, joined, rsvp.NumberOfGuests)
+		if err != nil {
+			return nil, err
+		}
+		if !fits {
+			return nil, ErrEventFull
+		}
+	}
+
+	for _, id := range left {
+		if _, err := tx.Exec(c.rebind(`DELETE FROM rsvp_events WHERE rsvp_id = ? AND event_id = ?`), rsvp.ID, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.insertRSVPEvents(tx, rsvp.ID, joined); err != nil {
+		return nil, err
+	}
+
+	if rsvp.Status == "APPROVED" && len(left) > 0 {
+		return c.releaseSeats(tx, uuid.NullUUID{}, left)
+	}
+	return nil, nil
+}
+
+// eventsFit reports whether numberOfGuests more approved guests fit in every
+// event listed. The events stay locked until the transaction ends; they are
+// locked in a fixed order so concurrent checks cannot deadlock.
+func (c Client) eventsFit(tx *sql.Tx, eventIDs []uuid.UUID, numberOfGuests int) (bool, error) {
+	for _, id := range uniqueIDs(eventIDs) {
+		event, err := c.getEvent(tx, id, true)
+		if err != nil {
+			return false, err
+		}
+		if event.Capacity != nil && event.ApprovedGuests+numberOfGuests > *event.Capacity {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// eventNamesByRSVP returns the names of the events each RSVP attends, in the
+// order they take place.
+func (c Client) eventNamesByRSVP(rsvpIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	byRSVP := map[uuid.UUID][]string{}
+	if len(rsvpIDs) == 0 {
+		return byRSVP, nil
+	}
+
+	placeholders, args := inList(rsvpIDs)
+	query := `
+    SELECT re.rsvp_id, events.name
+    FROM rsvp_events re
+    JOIN events ON (events.id = re.event_id)
+    WHERE re.rsvp_id IN (` + placeholders + `)
+    ORDER BY events.starts_at ASC, events.name ASC`
+
+	rows, err := c.DB.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rsvpID uuid.UUID
+		var name string
+		if err := rows.Scan(&rsvpID, &name); err != nil {
+			return nil, err
+		}
+		byRSVP[rsvpID] = append(byRSVP[rsvpID], name)
+	}
+
+	return byRSVP, rows.Err()
+}
+
+// queryIDs runs a query that selects a single UUID column.
+func (c Client) queryIDs(q querier, query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := q.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// inList builds the placeholders and arguments for an IN clause over ids.
+func inList(ids []uuid.UUID) (string, []any) {
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return strings.Join(placeholders, ", "), args
+}
+
+// uniqueIDs returns the distinct IDs in a fixed order, which is also the order
+// rows are locked in.
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	sorted := slices.Clone(ids)
+	slices.SortFunc(sorted, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	return slices.Compact(sorted)
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Guest is an invitee the couple added ahead of time. Each invitee has a
+// personal code that lets them RSVP for up to AllottedSeats people, once.
+type Guest struct {
+	ID            uuid.UUID     `json:"id"`
+	Name          string        `json:"name"`
+	Email         *string       `json:"email"`
+	Phone         *string       `json:"phone"`
+	Code          string        `json:"code"`
+	AllottedSeats int           `json:"allotted_seats"`
+	CategoryID    uuid.UUID     `json:"category_id"`
+	RSVPID        uuid.NullUUID `json:"rsvp_id"`
+	RespondedAt   *time.Time    `json:"responded_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// CreateGuestParams defines the parameters for adding an invitee.
+type CreateGuestParams struct {
+	Name          string
+	Email         *string
+	Phone         *string
+	Code          string
+	AllottedSeats int
+	CategoryID    uuid.UUID
+}
+
+var (
+	// ErrGuestAlreadyResponded is returned when an invitee's code is used a second time.
+	ErrGuestAlreadyResponded = errors.New("this invitation has already been used to RSVP")
+	// ErrExceedsAllotment is returned when an invitee RSVPs for more seats than they were given.
+	ErrExceedsAllotment = errors.New("number of guests exceeds the invitation's allotted seats")
+)
+
+// NormalizeGuestCode upper-cases and trims a personal code so lookups are
+// forgiving of how guests type it.
+func NormalizeGuestCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CreateGuest inserts a new invitee.
+func (c Client) CreateGuest(params CreateGuestParams) (Guest, error) {
+	id := uuid.New()
+	query := `
+    INSERT INTO guests (
+        id,
+        name,
+        email,
+        phone,
+        code,
+        allotted_seats,
+        category_id
+    ) VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := c.DB.Exec(
+		c.rebind(query),
+		id,
+		params.Name,
+		params.Email,
+		params.Phone,
+		NormalizeGuestCode(params.Code),
+		params.AllottedSeats,
+		params.CategoryID,
+	)
+	if err != nil {
+		return Guest{}, err
+	}
+
+	return c.GetGuest(id)
+}
+
+const guestColumns = `
+        guests.id,
+        guests.name,
+        guests.email,
+        guests.phone,
+        guests.code,
+        guests.allotted_seats,
+        guests.category_id,
+        guests.rsvp_id,
+        guests.responded_at,
+        guests.created_at`
+
+func scanGuest(row interface{ Scan(...any) error }) (Guest, error) {
+	var guest Guest
+	err := row.Scan(
+		&guest.ID,
+		&guest.Name,
+		&guest.Email,
+		&guest.Phone,
+		&guest.Code,
+		&guest.AllottedSeats,
+		&guest.CategoryID,
+		&guest.RSVPID,
+		&guest.RespondedAt,
+		&guest.CreatedAt,
+	)
+	return guest, err
+}
+
+// GetGuest retrieves an invitee by ID.
+func (c Client) GetGuest(id uuid.UUID) (Guest, error) {
+	return c.getGuest(c.DB, "guests.id = ?", id, false)
+}
+
+// GetGuestByCode retrieves an invitee by their personal code.
+func (c Client) GetGuestByCode(code string) (Guest, error) {
+	return c.getGuest(c.DB, "guests.code = ?", NormalizeGuestCode(code), false)
+}
+
+func (c Client) getGuest(q querier, where string, arg any, lock bool) (Guest, error) {
+	query := `SELECT` + guestColumns + `
+    FROM guests
+    WHERE ` + where
+	if lock {
+		query = c.forUpdate(query)
+	}
+
+	guest, err := scanGuest(q.QueryRow(c.rebind(query), arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Guest{}, nil
+		}
+		return Guest{}, err
+	}
+
+	return guest, nil
+}
+
+// ListGuests returns every invitee in the categories of a side, ordered by name.
+func (c Client) ListGuests(side string) ([]Guest, error) {
+	query := `SELECT` + guestColumns + `
+    FROM guests
+    JOIN guest_categories gc ON (gc.id = guests.category_id)
+    WHERE gc.side = ?
+    ORDER BY guests.name ASC, guests.id ASC`
+
+	rows, err := c.DB.Query(c.rebind(query), side)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guests := []Guest{}
+	for rows.Next() {
+		guest, err := scanGuest(rows)
+		if err != nil {
+			return nil, err
+		}
+		guests = append(guests, guest)
+	}
+
+	return guests, rows.Err()
+}
+
+// CountGuestsByCategory returns how many invitees belong to a category.
+func (c Client) CountGuestsByCategory(categoryID uuid.UUID) (int, error) {
+	var count int
+	err := c.DB.QueryRow(c.rebind(`SELECT COUNT(*) FROM guests WHERE category_id = ?`), categoryID).Scan(&count)
+	return count, err
+}
+
+// UpdateGuest saves an invitee's name, contact details, allotment and category.
+func (c Client) UpdateGuest(guest Guest) (Guest, error) {
+	query := `
+    UPDATE guests
+    SET name = ?, email = ?, phone = ?, allotted_seats = ?, category_id = ?
+    WHERE id = ?`
+
+	_, err := c.DB.Exec(
+		c.rebind(query),
+		guest.Name,
+		guest.Email,
+		guest.Phone,
+		guest.AllottedSeats,
+		guest.CategoryID,
+		guest.ID,
+	)
+	if err != nil {
+		return Guest{}, err
+	}
+
+	return c.GetGuest(guest.ID)
+}
+
+// DeleteGuest removes an invitee. An RSVP they already submitted is kept.
+func (c Client) DeleteGuest(id uuid.UUID) error {
+	_, err := c.DB.Exec(c.rebind(`DELETE FROM guests WHERE id = ?`), id)
+	return err
+}
+
+// CreateRSVPForGuest submits an RSVP on behalf of an invitee. The invitee and
+// their category are locked while the allotment and capacity are checked, and
+// the invitee is marked as responded in the same transaction, so a code can
+// only ever be used once. Status follows the invitee's category: default
+// categories leave the RSVP PENDING, others approve it while seats remain and
+// waitlist it otherwise. The email announcing the status is queued with the RSVP.
+func (c Client) CreateRSVPForGuest(guestID uuid.UUID, params CreateRSVPParams) (RSVP, error) {
+	var rsvp RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		guest, err := c.getGuest(tx, "guests.id = ?", guestID, true)
+		if err != nil {
+			return err
+		}
+		if guest.ID == uuid.Nil {
+			return errors.New("invitee not found")
+		}
+		if guest.RSVPID.Valid {
+			return ErrGuestAlreadyResponded
+		}
+		if params.NumberOfGuests > guest.AllottedSeats {
+			return ErrExceedsAllotment
+		}
+
+		category, err := c.getCategory(tx, guest.CategoryID, true)
+		if err != nil {
+			return err
+		}
+		if category.ID == uuid.Nil || category.DeletedAt != nil {
+			return errors.New("guest category not found")
+		}
+
+		params.CategoryID = uuid.NullUUID{UUID: category.ID, Valid: true}
+		params.Events, err = c.resolveRSVPEvents(tx, params.CategoryID, params.Events)
+		if err != nil {
+			return err
+		}
+
+		status := "PENDING"
+		if !category.DefaultCategory {
+			status, err = c.statusWithinCapacity(tx, category, params.NumberOfGuests, params.Events)
+			if err != nil {
+				return err
+			}
+		}
+
+		id, err := c.insertRSVP(tx, params, status)
+		if err != nil {
+			return err
+		}
+
+		query := `UPDATE guests SET rsvp_id = ?, responded_at = CURRENT_TIMESTAMP WHERE id = ?`
+		if _, err := tx.Exec(c.rebind(query), id, guest.ID); err != nil {
+			return err
+		}
+
+		rsvp, err = c.getRSVP(tx, id, false)
+		if err != nil {
+			return err
+		}
+		return c.queueSubmittedEmail(tx, rsvp)
+	})
+	if err != nil {
+		return RSVP{}, err
+	}
+
+	return rsvp, nil
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxJobAttempts is how many times a failed scheduled job is retried before it
+// is given up on.
+const maxJobAttempts = 3
+
+// ClaimJob marks the job identified by key as running and reports whether the
+// caller should perform it. A job is performed at most once: it is refused if
+// another caller already claimed it, if it completed, or if it has failed
+// maxJobAttempts times. A job left running by a crash is never retried, so a
+// restart cannot send the same message twice.
+func (c Client) ClaimJob(key, kind string) (bool, error) {
+	query := `
+    INSERT INTO scheduled_jobs (id, job_key, kind, status)
+    VALUES (?, ?, ?, 'RUNNING')`
+
+	_, err := c.DB.Exec(c.rebind(query), uuid.New(), key, kind)
+	if err == nil {
+		return true, nil
+	}
+	if !IsUniqueConstraintError(err) {
+		return false, err
+	}
+
+	query = `
+    UPDATE scheduled_jobs
+    SET status = 'RUNNING', attempts = attempts + 1, updated_at = ?
+    WHERE job_key = ? AND status = 'FAILED' AND attempts < ?`
+
+	result, err := c.DB.Exec(c.rebind(query), time.Now().UTC(), key, maxJobAttempts)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// CompleteJob records that a claimed job was performed.
+func (c Client) CompleteJob(key string) error {
+	query := `
+    UPDATE scheduled_jobs
+    SET status = 'DONE', last_error = '', updated_at = ?
+    WHERE job_key = ?`
+
+	_, err := c.DB.Exec(c.rebind(query), time.Now().UTC(), key)
+	return err
+}
+
+// FailJob records that a claimed job failed so that it can be claimed again.
+func (c Client) FailJob(key string, cause error) error {
+	query := `
+    UPDATE scheduled_jobs
+    SET status = 'FAILED', last_error = ?, updated_at = ?
+    WHERE job_key = ?`
+
+	_, err := c.DB.Exec(c.rebind(query), cause.Error(), time.Now().UTC(), key)
+	return err
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MealOption is a menu choice the couple offers guests. Unavailable options are
+// kept for attendees who already chose them but can no longer be picked.
+type MealOption struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Available   bool      `json:"available"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateMealOptionParams defines the parameters for adding a meal option.
+type CreateMealOptionParams struct {
+	Name        string
+	Description string
+}
+
+var (
+	// ErrMealOptionUnavailable is returned when an attendee picks a meal option
+	// that does not exist or has been withdrawn.
+	ErrMealOptionUnavailable = errors.New("meal option is not available")
+	// ErrMealOptionInUse is returned when deleting a meal option attendees have chosen.
+	ErrMealOptionInUse = errors.New("meal option has been chosen by attendees")
+)
+
+// CreateMealOption inserts a new, available meal option.
+func (c Client) CreateMealOption(params CreateMealOptionParams) (MealOption, error) {
+	id := uuid.New()
+	query := `INSERT INTO meal_options (id, name, description) VALUES (?, ?, ?)`
+
+	if _, err := c.DB.Exec(c.rebind(query), id, params.Name, params.Description); err != nil {
+		return MealOption{}, err
+	}
+
+	return c.GetMealOption(id)
+}
+
+// GetMealOption retrieves a meal option by ID.
+func (c Client) GetMealOption(id uuid.UUID) (MealOption, error) {
+	query := `
+    SELECT id, name, description, available, created_at
+    FROM meal_options
+    WHERE id = ?`
+
+	var option MealOption
+	err := c.DB.QueryRow(c.rebind(query), id).Scan(
+		&option.ID,
+		&option.Name,
+		&option.Description,
+		&option.Available,
+		&option.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return MealOption{}, nil
+		}
+		return MealOption{}, err
+	}
+
+	return option, nil
+}
+
+// ListMealOptions returns the meal options in the order they were added,
+// optionally only those guests can still choose.
+func (c Client) ListMealOptions(availableOnly bool) ([]MealOption, error) {
+	query := `
+    SELECT id, name, description, available, created_at
+    FROM meal_options`
+	if availableOnly {
+		query += "\n    WHERE available = true"
+	}
+	query += "\n    ORDER BY created_at ASC, name ASC"
+
+	rows, err := c.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	options := []MealOption{}
+	for rows.Next() {
+		var option MealOption
+		if err := rows.Scan(
+			&option.ID,
+			&option.Name,
+			&option.Description,
+			&option.Available,
+			&option.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	}
+
+	return options, rows.Err()
+}
+
+// UpdateMealOption saves a meal option's name, description and availability.
+func (c Client) UpdateMealOption(option MealOption) (MealOption, error) {
+	query := `
+    UPDATE meal_options
+    SET name = ?, description = ?, available = ?
+    WHERE id = ?`
+
+	if _, err := c.DB.Exec(c.rebind(query), option.Name, option.Description, option.Available, option.ID); err != nil {
+		return MealOption{}, err
+	}
+
+	return c.GetMealOption(option.ID)
+}
+
+// DeleteMealOption removes a meal option nobody has chosen yet. Options already
+// chosen return ErrMealOptionInUse and should be made unavailable instead.
+func (c Client) DeleteMealOption(id uuid.UUID) error {
+	return c.withTx(func(tx *sql.Tx) error {
+		var chosen int
+		query := `SELECT COUNT(*) FROM rsvp_attendees WHERE meal_option_id = ?`
+		if err := tx.QueryRow(c.rebind(query), id).Scan(&chosen); err != nil {
+			return err
+		}
+		if chosen > 0 {
+			return ErrMealOptionInUse
+		}
+
+		_, err := tx.Exec(c.rebind(`DELETE FROM meal_options WHERE id = ?`), id)
+		return err
+	})
+}
+
+// checkMealOptionAvailable returns ErrMealOptionUnavailable unless the meal
+// option exists and guests may still choose it.
+func (c Client) checkMealOptionAvailable(q querier, id uuid.UUID) error {
+	var available bool
+	err := q.QueryRow(c.rebind(`SELECT available FROM meal_options WHERE id = ?`), id).Scan(&available)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !available) {
+		return ErrMealOptionUnavailable
+	}
+	return err
+}
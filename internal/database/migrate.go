@@ -0,0 +1,309 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationFilePattern matches files such as "0002_add_waitlist.up.sql" or a
+// dialect-specific variant like "0002_add_waitlist.postgres.up.sql", which takes
+// precedence over the generic file when running against that dialect.
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+?)(?:\.(sqlite3|postgres))?\.(up|down)\.sql$`)
+
+// Migration is a numbered schema change with its up and down scripts.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState describes whether a migration has been applied to the database.
+type MigrationState struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+	// Unknown is set for versions recorded in the database that this binary does not ship.
+	Unknown bool `json:"unknown"`
+}
+
+// loadMigrations reads the embedded migrations for a dialect, ordered by version.
+func loadMigrations(d dialect) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	type script struct {
+		sql      string
+		specific bool
+	}
+	byVersion := map[int]*Migration{}
+	scripts := map[string]script{}
+
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unrecognised migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		name, fileDialect, direction := match[2], match[3], match[4]
+
+		if fileDialect != "" && fileDialect != string(d) {
+			continue
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, name)
+		}
+
+		key := strconv.Itoa(version) + direction
+		if existing, ok := scripts[key]; ok && existing.specific {
+			continue
+		}
+
+		body, err := migrationsFS.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		scripts[key] = script{sql: string(body), specific: fileDialect != ""}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, m := range byVersion {
+		up, okUp := scripts[strconv.Itoa(version)+"up"]
+		down, okDown := scripts[strconv.Itoa(version)+"down"]
+		if !okUp || !okDown {
+			return nil, fmt.Errorf("migration %d (%s) must have both an up and a down script", version, m.Name)
+		}
+		m.Up, m.Down = up.sql, down.sql
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// ensureMigrationsTable creates the bookkeeping table used to track applied migrations.
+func (c Client) ensureMigrationsTable() error {
+	_, err := c.DB.Exec(`
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        version INTEGER PRIMARY KEY,
+        name TEXT NOT NULL,
+        applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );`)
+	return err
+}
+
+// SchemaVersion returns the highest migration version applied to the database, or 0.
+func (c Client) SchemaVersion() (int, error) {
+	if err := c.ensureMigrationsTable(); err != nil {
+		return 0, err
+	}
+
+	var version int
+	err := c.DB.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// LatestSchemaVersion returns the newest migration version shipped with this binary.
+func (c Client) LatestSchemaVersion() (int, error) {
+	migrations, err := loadMigrations(c.dialect)
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// MigrationStatus lists every known migration alongside whether it has been applied.
+func (c Client) MigrationStatus() ([]MigrationState, error) {
+	migrations, err := loadMigrations(c.dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := c.DB.Query(`SELECT version, name, applied_at FROM schema_migrations ORDER BY version ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]MigrationState{}
+	for rows.Next() {
+		var state MigrationState
+		var appliedAt time.Time
+		if err := rows.Scan(&state.Version, &state.Name, &appliedAt); err != nil {
+			return nil, err
+		}
+		state.Applied = true
+		state.AppliedAt = &appliedAt
+		applied[state.Version] = state
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	for _, m := range migrations {
+		state, ok := applied[m.Version]
+		if !ok {
+			state = MigrationState{Version: m.Version, Name: m.Name}
+		}
+		delete(applied, m.Version)
+		states = append(states, state)
+	}
+	for _, state := range applied {
+		state.Unknown = true
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+
+	return states, nil
+}
+
+// MigrateUp applies every pending migration in order.
+func (c Client) MigrateUp() error {
+	latest, err := c.LatestSchemaVersion()
+	if err != nil {
+		return err
+	}
+	return c.MigrateTo(latest)
+}
+
+// MigrateDown reverts the most recently applied migration.
+func (c Client) MigrateDown() error {
+	current, err := c.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return nil
+	}
+
+	migrations, err := loadMigrations(c.dialect)
+	if err != nil {
+		return err
+	}
+	target := 0
+	for _, m := range migrations {
+		if m.Version < current {
+			target = m.Version
+		}
+	}
+	return c.MigrateTo(target)
+}
+
+// MigrateTo applies or reverts migrations until the database is at the given version.
+func (c Client) MigrateTo(target int) error {
+	migrations, err := loadMigrations(c.dialect)
+	if err != nil {
+		return err
+	}
+
+	current, err := c.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	known := map[int]bool{0: true}
+	for _, m := range migrations {
+		known[m.Version] = true
+	}
+	if !known[target] {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+	if !known[current] {
+		return fmt.Errorf("database is at version %d which this binary does not know how to migrate", current)
+	}
+
+	if target >= current {
+		for _, m := range migrations {
+			if m.Version <= current || m.Version > target {
+				continue
+			}
+			if err := c.applyMigration(m, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version > current || m.Version <= target {
+			continue
+		}
+		if err := c.applyMigration(m, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMigration runs one migration script and records the result in a single transaction.
+func (c Client) applyMigration(m Migration, up bool) error {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	script, direction := m.Up, "up"
+	if !up {
+		script, direction = m.Down, "down"
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		return fmt.Errorf("migration %04d_%s (%s) failed: %w", m.Version, m.Name, direction, err)
+	}
+
+	if up {
+		_, err = tx.Exec(c.rebind(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`), m.Version, m.Name)
+	} else {
+		_, err = tx.Exec(c.rebind(`DELETE FROM schema_migrations WHERE version = ?`), m.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkSchemaNotAhead refuses to run against a database migrated by a newer binary.
+func (c Client) checkSchemaNotAhead() error {
+	current, err := c.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	latest, err := c.LatestSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current > latest {
+		return fmt.Errorf("database schema is at version %d but this binary only knows up to version %d", current, latest)
+	}
+	return nil
+}
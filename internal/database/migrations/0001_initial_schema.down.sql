@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS checkins;
+DROP INDEX IF EXISTS idx_rsvps_category_id;
+DROP TABLE IF EXISTS rsvps;
+DROP TABLE IF EXISTS guest_categories;
+DROP TABLE IF EXISTS couples;
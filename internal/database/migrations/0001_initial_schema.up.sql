@@ -0,0 +1,47 @@
+-- Baseline schema. Every statement is guarded with IF NOT EXISTS so databases
+-- created by the old autoMigrate are adopted without changes.
+CREATE TABLE IF NOT EXISTS couples (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    side TEXT NOT NULL CHECK(side IN ('BRIDE', 'GROOM')),
+    otp TEXT,
+    otp_expiry TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS guest_categories (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    side TEXT NOT NULL CHECK(side IN ('BRIDE', 'GROOM')),
+    max_guests INTEGER NOT NULL,
+    invitation_token TEXT NOT NULL UNIQUE,
+    couple_id TEXT NOT NULL,
+    default_category BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (couple_id) REFERENCES couples(id)
+);
+
+CREATE TABLE IF NOT EXISTS rsvps (
+    id TEXT PRIMARY KEY,
+    guest_name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    phone TEXT NOT NULL UNIQUE,
+    number_of_guests INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK(status IN ('PENDING', 'APPROVED', 'REJECTED')),
+    category_id TEXT,
+    submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES guest_categories(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rsvps_category_id ON rsvps(category_id);
+
+CREATE TABLE IF NOT EXISTS checkins (
+    id TEXT PRIMARY KEY,
+    rsvp_id TEXT NOT NULL UNIQUE,
+    guests_arrived INTEGER NOT NULL,
+    checked_in_by TEXT NOT NULL,
+    checked_in_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rsvp_id) REFERENCES rsvps(id),
+    FOREIGN KEY (checked_in_by) REFERENCES couples(id)
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_rsvps_category_status_submitted;
+
+UPDATE rsvps SET status = 'PENDING' WHERE status = 'WAITLISTED';
+
+ALTER TABLE rsvps DROP CONSTRAINT IF EXISTS rsvps_status_check;
+ALTER TABLE rsvps ADD CONSTRAINT rsvps_status_check
+    CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED'));
@@ -0,0 +1,5 @@
+ALTER TABLE rsvps DROP CONSTRAINT IF EXISTS rsvps_status_check;
+ALTER TABLE rsvps ADD CONSTRAINT rsvps_status_check
+    CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'WAITLISTED'));
+
+CREATE INDEX IF NOT EXISTS idx_rsvps_category_status_submitted ON rsvps(category_id, status, submitted_at);
@@ -0,0 +1,21 @@
+UPDATE rsvps SET status = 'PENDING' WHERE status = 'WAITLISTED';
+
+CREATE TABLE rsvps_old (
+    id TEXT PRIMARY KEY,
+    guest_name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    phone TEXT NOT NULL UNIQUE,
+    number_of_guests INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK(status IN ('PENDING', 'APPROVED', 'REJECTED')),
+    category_id TEXT,
+    submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES guest_categories(id)
+);
+
+INSERT INTO rsvps_old (id, guest_name, email, phone, number_of_guests, status, category_id, submitted_at)
+SELECT id, guest_name, email, phone, number_of_guests, status, category_id, submitted_at FROM rsvps;
+
+DROP TABLE rsvps;
+ALTER TABLE rsvps_old RENAME TO rsvps;
+
+CREATE INDEX IF NOT EXISTS idx_rsvps_category_id ON rsvps(category_id);
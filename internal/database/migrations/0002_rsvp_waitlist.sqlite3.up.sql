@@ -0,0 +1,22 @@
+-- SQLite cannot alter a CHECK constraint in place, so rebuild rsvps with
+-- WAITLISTED added to the allowed statuses.
+CREATE TABLE rsvps_new (
+    id TEXT PRIMARY KEY,
+    guest_name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    phone TEXT NOT NULL UNIQUE,
+    number_of_guests INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK(status IN ('PENDING', 'APPROVED', 'REJECTED', 'WAITLISTED')),
+    category_id TEXT,
+    submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES guest_categories(id)
+);
+
+INSERT INTO rsvps_new (id, guest_name, email, phone, number_of_guests, status, category_id, submitted_at)
+SELECT id, guest_name, email, phone, number_of_guests, status, category_id, submitted_at FROM rsvps;
+
+DROP TABLE rsvps;
+ALTER TABLE rsvps_new RENAME TO rsvps;
+
+CREATE INDEX IF NOT EXISTS idx_rsvps_category_id ON rsvps(category_id);
+CREATE INDEX IF NOT EXISTS idx_rsvps_category_status_submitted ON rsvps(category_id, status, submitted_at);
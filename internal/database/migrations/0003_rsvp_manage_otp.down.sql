@@ -0,0 +1,2 @@
+ALTER TABLE rsvps DROP COLUMN manage_otp_expiry;
+ALTER TABLE rsvps DROP COLUMN manage_otp;
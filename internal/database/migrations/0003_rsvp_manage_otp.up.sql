@@ -0,0 +1,2 @@
+ALTER TABLE rsvps ADD COLUMN manage_otp TEXT;
+ALTER TABLE rsvps ADD COLUMN manage_otp_expiry TIMESTAMP;
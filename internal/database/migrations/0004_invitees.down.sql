@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_guests_category_id;
+DROP TABLE IF EXISTS guests;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS guests (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    email TEXT,
+    phone TEXT,
+    code TEXT NOT NULL UNIQUE,
+    allotted_seats INTEGER NOT NULL DEFAULT 1 CHECK(allotted_seats >= 1),
+    category_id TEXT NOT NULL,
+    rsvp_id TEXT UNIQUE,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES guest_categories(id),
+    FOREIGN KEY (rsvp_id) REFERENCES rsvps(id)
+);
+CREATE INDEX IF NOT EXISTS idx_guests_category_id ON guests(category_id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS rsvp_attendees (
+    id TEXT PRIMARY KEY,
+    rsvp_id TEXT NOT NULL,
+    position INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    age_group TEXT NOT NULL DEFAULT 'ADULT' CHECK(age_group IN ('ADULT', 'CHILD')),
+    dietary_restrictions TEXT NOT NULL DEFAULT '',
+    allergy_notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rsvp_id) REFERENCES rsvps(id),
+    UNIQUE (rsvp_id, position)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE rsvp_attendees DROP COLUMN meal_option_id;
+DROP TABLE IF EXISTS meal_options;
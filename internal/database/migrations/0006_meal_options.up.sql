@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS meal_options (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    available BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE rsvp_attendees ADD COLUMN meal_option_id TEXT REFERENCES meal_options(id);
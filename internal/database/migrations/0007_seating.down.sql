@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_seat_assignments_rsvp_id;
+DROP INDEX IF EXISTS idx_seat_assignments_table_id;
+DROP TABLE IF EXISTS seat_assignments;
+DROP TABLE IF EXISTS seating_tables;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS seating_tables (
+    id TEXT PRIMARY KEY,
+    number INTEGER NOT NULL UNIQUE,
+    name TEXT NOT NULL DEFAULT '',
+    capacity INTEGER NOT NULL CHECK(capacity >= 1),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A row seats either a whole party (attendee_id NULL, taking number_of_guests
+-- seats) or one named attendee (taking a single seat).
+CREATE TABLE IF NOT EXISTS seat_assignments (
+    id TEXT PRIMARY KEY,
+    table_id TEXT NOT NULL,
+    rsvp_id TEXT NOT NULL,
+    attendee_id TEXT UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (table_id) REFERENCES seating_tables(id),
+    FOREIGN KEY (rsvp_id) REFERENCES rsvps(id),
+    FOREIGN KEY (attendee_id) REFERENCES rsvp_attendees(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_seat_assignments_table_id ON seat_assignments(table_id);
+CREATE INDEX IF NOT EXISTS idx_seat_assignments_rsvp_id ON seat_assignments(rsvp_id);
@@ -0,0 +1,11 @@
+-- The application stores each pair with the lower RSVP ID first so a pair has one row.
+CREATE TABLE IF NOT EXISTS seating_constraints (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL CHECK(kind IN ('MUST_SIT_WITH', 'KEEP_APART')),
+    rsvp_id TEXT NOT NULL,
+    other_rsvp_id TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rsvp_id) REFERENCES rsvps(id),
+    FOREIGN KEY (other_rsvp_id) REFERENCES rsvps(id),
+    UNIQUE (rsvp_id, other_rsvp_id)
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_rsvp_events_event_id;
+DROP TABLE IF EXISTS rsvp_events;
+DROP TABLE IF EXISTS category_events;
+DROP TABLE IF EXISTS events;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS events (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    venue TEXT NOT NULL DEFAULT '',
+    starts_at TIMESTAMP NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    map_url TEXT NOT NULL DEFAULT '',
+    -- NULL means the event is only limited by its categories' capacity.
+    capacity INTEGER CHECK(capacity >= 0),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The events a category's invitation covers. A category without rows here is
+-- invited to every event.
+CREATE TABLE IF NOT EXISTS category_events (
+    category_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    PRIMARY KEY (category_id, event_id),
+    FOREIGN KEY (category_id) REFERENCES guest_categories(id),
+    FOREIGN KEY (event_id) REFERENCES events(id)
+);
+
+-- The events each RSVP's party will attend.
+CREATE TABLE IF NOT EXISTS rsvp_events (
+    rsvp_id TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    PRIMARY KEY (rsvp_id, event_id),
+    FOREIGN KEY (rsvp_id) REFERENCES rsvps(id),
+    FOREIGN KEY (event_id) REFERENCES events(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rsvp_events_event_id ON rsvp_events(event_id);
@@ -0,0 +1,13 @@
+-- One row per message the background scheduler has sent or is sending. job_key
+-- names a single send (e.g. the 7-day reminder for one RSVP) so that it happens
+-- at most once, even across restarts or several server instances.
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+    id TEXT PRIMARY KEY,
+    job_key TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL CHECK(status IN ('RUNNING', 'DONE', 'FAILED')),
+    attempts INTEGER NOT NULL DEFAULT 1,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
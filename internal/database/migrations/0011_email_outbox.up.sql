@@ -0,0 +1,20 @@
+-- Emails waiting to be delivered. Rows are written in the same transaction as
+-- the RSVP change that causes them, and a background worker renders and sends
+-- them, retrying with backoff until the message is SENT or, after too many
+-- attempts, FAILED. SKIPPED rows were no longer relevant when their turn came,
+-- e.g. a confirmation for an RSVP that has since been cancelled.
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id TEXT PRIMARY KEY,
+    kind TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    rsvp_id TEXT,
+    payload TEXT NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK(status IN ('PENDING', 'SENT', 'SKIPPED', 'FAILED')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_due ON email_outbox(status, next_attempt_at);
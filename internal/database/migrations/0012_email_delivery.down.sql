@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_email_outbox_rsvp_id;
+DROP INDEX IF EXISTS idx_email_outbox_provider_message_id;
+ALTER TABLE email_outbox DROP COLUMN delivery_updated_at;
+ALTER TABLE email_outbox DROP COLUMN delivery_detail;
+ALTER TABLE email_outbox DROP COLUMN delivery_status;
+ALTER TABLE email_outbox DROP COLUMN provider_message_id;
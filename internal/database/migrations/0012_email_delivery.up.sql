@@ -0,0 +1,11 @@
+-- Delivery tracking for sent emails. provider_message_id is the ID the mail
+-- transport returned, which the provider's webhooks refer to; delivery_status
+-- is the latest state they reported (DELIVERED, DELAYED, BOUNCED or
+-- COMPLAINED), or NULL until the first report arrives.
+ALTER TABLE email_outbox ADD COLUMN provider_message_id TEXT;
+ALTER TABLE email_outbox ADD COLUMN delivery_status TEXT;
+ALTER TABLE email_outbox ADD COLUMN delivery_detail TEXT NOT NULL DEFAULT '';
+ALTER TABLE email_outbox ADD COLUMN delivery_updated_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_provider_message_id ON email_outbox(provider_message_id);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_rsvp_id ON email_outbox(rsvp_id);
@@ -0,0 +1,15 @@
+-- A couple's own wording for one of the built-in emails. name is the built-in
+-- template it replaces (e.g. rsvp_pending); subject and body are Go templates
+-- rendered with the same data as the built-in one. Deleting the row restores
+-- the built-in wording.
+CREATE TABLE IF NOT EXISTS email_templates (
+    id TEXT PRIMARY KEY,
+    couple_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (couple_id, name),
+    FOREIGN KEY (couple_id) REFERENCES couples(id)
+);
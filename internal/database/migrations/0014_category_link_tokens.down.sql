@@ -0,0 +1,3 @@
+-- A backfilled token cannot be told apart from one the couple chose, so the
+-- backfill is kept.
+SELECT 1;
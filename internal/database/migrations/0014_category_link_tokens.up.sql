@@ -0,0 +1,6 @@
+-- Invitation links used to carry the category's ID. Categories that never had
+-- a token of their own get their ID as one, which keeps those links working.
+-- Categories that already have a token keep it: it may have been rotated to
+-- revoke old links, and overwriting it would bring them back.
+UPDATE guest_categories SET invitation_token = id
+WHERE invitation_token IS NULL OR invitation_token = '';
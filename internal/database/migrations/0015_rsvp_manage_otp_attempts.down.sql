@@ -0,0 +1,2 @@
+-- The emails lower-cased on the way up are left as they are.
+ALTER TABLE rsvps DROP COLUMN manage_otp_attempts;
@@ -0,0 +1,14 @@
+-- Wrong guesses at a guest's sign-in code. The code is cleared once too many
+-- have been made, so it cannot be found by trying every six-digit number.
+ALTER TABLE rsvps ADD COLUMN manage_otp_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- Guests sign in by email, which is now stored lower-cased and matched exactly.
+-- An address that differs from another RSVP's only in case is left as it is,
+-- since lower-casing it would break the unique constraint; the couple has to
+-- sort those out.
+UPDATE rsvps SET email = LOWER(TRIM(email))
+WHERE email <> LOWER(TRIM(email))
+  AND NOT EXISTS (
+    SELECT 1 FROM rsvps other
+    WHERE other.id <> rsvps.id AND LOWER(TRIM(other.email)) = LOWER(TRIM(rsvps.email))
+  );
@@ -0,0 +1,2 @@
+-- Tombstoned categories become ordinary categories again; their RSVPs stay rejected.
+ALTER TABLE guest_categories DROP COLUMN deleted_at;
@@ -0,0 +1,5 @@
+-- A category deleted with its RSVPs rejected is kept as a tombstone, marked by
+-- deleted_at, so that the rejected RSVPs still belong to a side and show up in
+-- the couple's lists and exports. Tombstones are left out wherever a category
+-- can be picked, linked to or listed.
+ALTER TABLE guest_categories ADD COLUMN deleted_at TIMESTAMP;
@@ -0,0 +1,462 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of email queued in the outbox.
+const (
+	EmailRSVPConfirmed  = "rsvp_confirmed"
+	EmailRSVPReceived   = "rsvp_received"
+	EmailRSVPWaitlisted = "rsvp_waitlisted"
+	EmailRSVPRejected   = "rsvp_rejected"
+	EmailRSVPChanged    = "rsvp_changed"
+)
+
+const (
+	// maxEmailAttempts is how many times delivery is tried before an email is
+	// marked FAILED and left for an admin to re-send.
+	maxEmailAttempts = 8
+	// emailRetryBase is the wait after the first failed attempt; it doubles with
+	// every further failure, up to emailRetryMax.
+	emailRetryBase = 30 * time.Second
+	emailRetryMax  = time.Hour
+	// emailClaimLease is how long a claimed email is reserved for the worker that
+	// claimed it. If that worker dies mid-send, the email is retried afterwards.
+	emailClaimLease = 5 * time.Minute
+)
+
+// ErrEmailNotFailed is returned when re-sending an email that has not failed.
+var ErrEmailNotFailed = errors.New("only failed emails can be re-sent")
+
+// OutboxEmail is an email waiting to be, or already, delivered.
+type OutboxEmail struct {
+	ID        uuid.UUID     `json:"id"`
+	Kind      string        `json:"kind"`
+	Recipient string        `json:"recipient"`
+	RSVPID    uuid.NullUUID `json:"rsvp_id"`
+	// Payload holds what the email needs beyond the RSVP itself, as JSON.
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     string          `json:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	SentAt        *time.Time      `json:"sent_at"`
+	// ProviderMessageID is the ID the mail transport sent the email under.
+	ProviderMessageID *string `json:"provider_message_id"`
+	// DeliveryStatus is the latest state reported by the provider's webhooks.
+	DeliveryStatus    *string    `json:"delivery_status"`
+	DeliveryDetail    string     `json:"delivery_detail"`
+	DeliveryUpdatedAt *time.Time `json:"delivery_updated_at"`
+}
+
+// QueueEmailParams describes an email to add to the outbox.
+type QueueEmailParams struct {
+	Kind      string
+	Recipient string
+	RSVPID    uuid.NullUUID
+	// Payload is stored as JSON.
+	Payload any
+}
+
+// RSVPEmailPayload is the payload of emails sent to a guest about their RSVP.
+// The name is kept so the email can still be written if the RSVP is deleted.
+type RSVPEmailPayload struct {
+	GuestName string `json:"guest_name"`
+}
+
+// QueueEmail adds an email to the outbox for the worker to deliver.
+func (c Client) QueueEmail(params QueueEmailParams) error {
+	return c.queueEmail(c.DB, params)
+}
+
+// queueEmail adds an email to the outbox through q, so that callers can queue it
+// in the same transaction as the change it announces.
+func (c Client) queueEmail(q querier, params QueueEmailParams) error {
+	payload, err := json.Marshal(params.Payload)
+	if err != nil {
+		return err
+	}
+
+	query := `
+    INSERT INTO email_outbox (id, kind, recipient, rsvp_id, payload, next_attempt_at, created_at)
+    VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	// created_at keeps sub-second precision so that the latest email to a guest
+	// can be told apart from one queued in the same second.
+	now := time.Now()
+	_, err = q.Exec(c.rebind(query), uuid.New(), params.Kind, params.Recipient, params.RSVPID, string(payload), c.timeArg(now), c.preciseTimeArg(now))
+	return err
+}
+
+// queueRSVPEmail queues an email of the given kind to an RSVP's guest.
+func (c Client) queueRSVPEmail(q querier, kind string, rsvp RSVP) error {
+	return c.queueEmail(q, QueueEmailParams{
+		Kind:      kind,
+		Recipient: rsvp.Email,
+		RSVPID:    uuid.NullUUID{UUID: rsvp.ID, Valid: true},
+		Payload:   RSVPEmailPayload{GuestName: rsvp.GuestName},
+	})
+}
+
+// queueSubmittedEmail queues the email that tells a guest the outcome of the
+// RSVP they just submitted.
+func (c Client) queueSubmittedEmail(q querier, rsvp RSVP) error {
+	switch rsvp.Status {
+	case "APPROVED":
+		return c.queueRSVPEmail(q, EmailRSVPConfirmed, rsvp)
+	case "WAITLISTED":
+		return c.queueRSVPEmail(q, EmailRSVPWaitlisted, rsvp)
+	default:
+		return c.queueRSVPEmail(q, EmailRSVPReceived, rsvp)
+	}
+}
+
+const outboxColumns = `
+        id,
+        kind,
+        recipient,
+        rsvp_id,
+        payload,
+        status,
+        attempts,
+        last_error,
+        next_attempt_at,
+        created_at,
+        sent_at,
+        provider_message_id,
+        delivery_status,
+        delivery_detail,
+        delivery_updated_at`
+
+func scanOutboxEmail(row interface{ Scan(...any) error }) (OutboxEmail, error) {
+	var email OutboxEmail
+	var payload string
+	err := row.Scan(
+		&email.ID,
+		&email.Kind,
+		&email.Recipient,
+		&email.RSVPID,
+		&payload,
+		&email.Status,
+		&email.Attempts,
+		&email.LastError,
+		&email.NextAttemptAt,
+		&email.CreatedAt,
+		&email.SentAt,
+		&email.ProviderMessageID,
+		&email.DeliveryStatus,
+		&email.DeliveryDetail,
+		&email.DeliveryUpdatedAt,
+	)
+	email.Payload = json.RawMessage(payload)
+	return email, err
+}
+
+// coupleOutboxFilter limits outbox emails to those sent to guests in one of a
+// couple's categories.
+const coupleOutboxFilter = `rsvp_id IN (
+        SELECT rsvps.id
+        FROM rsvps
+        JOIN guest_categories gc ON gc.id = rsvps.category_id
+        WHERE gc.couple_id = ?
+    )`
+
+// GetOutboxEmail retrieves a single outbox email by its ID, provided it was
+// sent to a guest of the given couple.
+func (c Client) GetOutboxEmail(id, coupleID uuid.UUID) (OutboxEmail, error) {
+	query := `SELECT ` + outboxColumns + ` FROM email_outbox WHERE id = ? AND ` + coupleOutboxFilter
+
+	email, err := scanOutboxEmail(c.DB.QueryRow(c.rebind(query), id, coupleID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OutboxEmail{}, nil
+		}
+		return OutboxEmail{}, err
+	}
+	return email, nil
+}
+
+// ListOutboxEmails returns up to limit of the emails sent to a couple's guests,
+// newest first, optionally only those with the given status.
+func (c Client) ListOutboxEmails(coupleID uuid.UUID, status string, limit int) ([]OutboxEmail, error) {
+	query := `SELECT ` + outboxColumns + ` FROM email_outbox WHERE ` + coupleOutboxFilter
+	args := []any{coupleID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC, id ASC LIMIT ?`
+	args = append(args, limit)
+
+	return c.queryOutboxEmails(c.DB, query, args...)
+}
+
+func (c Client) queryOutboxEmails(q querier, query string, args ...any) ([]OutboxEmail, error) {
+	rows, err := q.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := []OutboxEmail{}
+	for rows.Next() {
+		email, err := scanOutboxEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	return emails, rows.Err()
+}
+
+// ClaimDueEmails reserves up to limit pending emails whose next attempt is due,
+// oldest first, and counts the attempt. A claimed email is not handed out again
+// until emailClaimLease has passed, so several workers never send it at once.
+func (c Client) ClaimDueEmails(limit int) ([]OutboxEmail, error) {
+	now := time.Now()
+	due, err := c.queryOutboxEmails(c.DB, `
+    SELECT `+outboxColumns+`
+    FROM email_outbox
+    WHERE status = 'PENDING' AND next_attempt_at <= ?
+    ORDER BY next_attempt_at ASC, id ASC
+    LIMIT ?`, c.timeArg(now), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+    UPDATE email_outbox
+    SET attempts = attempts + 1, next_attempt_at = ?
+    WHERE id = ? AND status = 'PENDING' AND next_attempt_at <= ?`
+
+	claimed := make([]OutboxEmail, 0, len(due))
+	for _, email := range due {
+		result, err := c.DB.Exec(c.rebind(query), c.timeArg(now.Add(emailClaimLease)), email.ID, c.timeArg(now))
+		if err != nil {
+			return nil, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if n == 1 {
+			email.Attempts++
+			claimed = append(claimed, email)
+		}
+	}
+
+	return claimed, nil
+}
+
+// MarkEmailSent records that a claimed email was handed to the mail transport,
+// which sent it under messageID.
+func (c Client) MarkEmailSent(id uuid.UUID, messageID string) error {
+	query := `
+    UPDATE email_outbox
+    SET status = 'SENT', last_error = '', sent_at = ?, provider_message_id = ?
+    WHERE id = ?`
+
+	_, err := c.DB.Exec(c.rebind(query), c.timeArg(time.Now()), sql.NullString{String: messageID, Valid: messageID != ""}, id)
+	return err
+}
+
+// MarkEmailSkipped records that a claimed email was no longer worth sending.
+func (c Client) MarkEmailSkipped(id uuid.UUID, reason string) error {
+	query := `
+    UPDATE email_outbox
+    SET status = 'SKIPPED', last_error = ?
+    WHERE id = ?`
+
+	_, err := c.DB.Exec(c.rebind(query), reason, id)
+	return err
+}
+
+// MarkEmailFailed records a failed delivery attempt. The email is retried with
+// exponential backoff until it has been tried maxEmailAttempts times, after
+// which it is marked FAILED.
+func (c Client) MarkEmailFailed(email OutboxEmail, cause error) error {
+	if email.Attempts >= maxEmailAttempts {
+		query := `
+    UPDATE email_outbox
+    SET status = 'FAILED', last_error = ?
+    WHERE id = ?`
+
+		_, err := c.DB.Exec(c.rebind(query), cause.Error(), email.ID)
+		return err
+	}
+
+	query := `
+    UPDATE email_outbox
+    SET last_error = ?, next_attempt_at = ?
+    WHERE id = ?`
+
+	next := time.Now().Add(emailRetryDelay(email.Attempts))
+	_, err := c.DB.Exec(c.rebind(query), cause.Error(), c.timeArg(next), email.ID)
+	return err
+}
+
+// emailRetryDelay is how long to wait after the given number of failed attempts.
+func emailRetryDelay(attempts int) time.Duration {
+	delay := emailRetryBase
+	for i := 1; i < attempts && delay < emailRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, emailRetryMax)
+}
+
+// RetryOutboxEmail puts a FAILED email back in the queue for immediate delivery
+// with a fresh set of attempts. Only emails to the given couple's guests are
+// touched.
+func (c Client) RetryOutboxEmail(id, coupleID uuid.UUID) (OutboxEmail, error) {
+	query := `
+    UPDATE email_outbox
+    SET status = 'PENDING', attempts = 0, next_attempt_at = ?
+    WHERE id = ? AND status = 'FAILED' AND ` + coupleOutboxFilter
+
+	result, err := c.DB.Exec(c.rebind(query), c.timeArg(time.Now()), id, coupleID)
+	if err != nil {
+		return OutboxEmail{}, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return OutboxEmail{}, err
+	}
+	if n == 0 {
+		return OutboxEmail{}, ErrEmailNotFailed
+	}
+
+	return c.GetOutboxEmail(id, coupleID)
+}
+
+// RecordEmailDeliveryParams is a delivery report from the mail provider.
+type RecordEmailDeliveryParams struct {
+	ProviderMessageID string
+	// Status is DELIVERED, DELAYED, BOUNCED or COMPLAINED.
+	Status     string
+	Detail     string
+	OccurredAt time.Time
+}
+
+// RecordEmailDelivery stores the delivery state of the email sent under a
+// provider message ID. Reports older than the one already stored are ignored,
+// since webhooks can arrive out of order. It returns false if no sent email has
+// that ID, for instance a sign-in code, which is not sent through the outbox.
+func (c Client) RecordEmailDelivery(params RecordEmailDeliveryParams) (bool, error) {
+	query := `
+    UPDATE email_outbox
+    SET delivery_status = ?, delivery_detail = ?, delivery_updated_at = ?
+    WHERE provider_message_id = ?
+      AND (delivery_updated_at IS NULL OR delivery_updated_at <= ?)`
+
+	occurredAt := c.timeArg(params.OccurredAt)
+	result, err := c.DB.Exec(c.rebind(query), params.Status, params.Detail, occurredAt, params.ProviderMessageID, occurredAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		return true, nil
+	}
+
+	var exists bool
+	err = c.DB.QueryRow(c.rebind(`SELECT EXISTS (SELECT 1 FROM email_outbox WHERE provider_message_id = ?)`), params.ProviderMessageID).Scan(&exists)
+	return exists, err
+}
+
+// EmailDelivery is where the latest email sent to an RSVP's guest has got to.
+type EmailDelivery struct {
+	EmailID uuid.UUID `json:"email_id"`
+	Kind    string    `json:"kind"`
+	// Status is the provider's latest report (DELIVERED, DELAYED, BOUNCED or
+	// COMPLAINED) or, until one arrives, the outbox status: PENDING, SENT or
+	// FAILED.
+	Status    string    `json:"status"`
+	Detail    string    `json:"detail,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// deliveriesByRSVP finds the delivery state of the latest email sent to each
+// RSVP's guest. Emails to the couple about an RSVP, and emails that were
+// skipped, are left out.
+func (c Client) deliveriesByRSVP(rsvpIDs []uuid.UUID) (map[uuid.UUID]EmailDelivery, error) {
+	byRSVP := map[uuid.UUID]EmailDelivery{}
+	if len(rsvpIDs) == 0 {
+		return byRSVP, nil
+	}
+
+	placeholders, args := inList(rsvpIDs)
+	query := `
+    SELECT
+        o.id,
+        o.kind,
+        o.rsvp_id,
+        o.status,
+        o.last_error,
+        o.created_at,
+        o.sent_at,
+        o.delivery_status,
+        o.delivery_detail,
+        o.delivery_updated_at
+    FROM email_outbox o
+    JOIN rsvps ON (rsvps.id = o.rsvp_id AND rsvps.email = o.recipient)
+    WHERE o.rsvp_id IN (` + placeholders + `) AND o.status <> 'SKIPPED'
+    ORDER BY o.created_at ASC, o.id ASC`
+
+	rows, err := c.DB.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var delivery EmailDelivery
+		var rsvpID uuid.UUID
+		var lastError, deliveryDetail string
+		var createdAt time.Time
+		var sentAt, deliveryUpdatedAt *time.Time
+		var deliveryStatus *string
+		if err := rows.Scan(
+			&delivery.EmailID,
+			&delivery.Kind,
+			&rsvpID,
+			&delivery.Status,
+			&lastError,
+			&createdAt,
+			&sentAt,
+			&deliveryStatus,
+			&deliveryDetail,
+			&deliveryUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		delivery.UpdatedAt = createdAt
+		if sentAt != nil {
+			delivery.UpdatedAt = *sentAt
+		}
+		if delivery.Status != "SENT" {
+			delivery.Detail = lastError
+		}
+		if deliveryStatus != nil {
+			delivery.Status = *deliveryStatus
+			delivery.Detail = deliveryDetail
+			if deliveryUpdatedAt != nil {
+				delivery.UpdatedAt = *deliveryUpdatedAt
+			}
+		}
+		// Rows come oldest first, so the latest email wins.
+		byRSVP[rsvpID] = delivery
+	}
+
+	return byRSVP, rows.Err()
+}
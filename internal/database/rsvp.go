@@ -18,6 +18,11 @@ type RSVP struct {
 	Status         string        `json:"status"`
 	CategoryID     uuid.NullUUID `json:"category_id"`
 	SubmittedAt    time.Time     `json:"submitted_at"`
+	// Attendees, Events and Delivery are only loaded by listings that ask for them.
+	Attendees []Attendee `json:"attendees,omitempty"`
+	Events    []string   `json:"events,omitempty"`
+	// Delivery is the state of the latest email sent to the guest, if any.
+	Delivery *EmailDelivery `json:"delivery,omitempty"`
 }
 
 // CreateRSVPParams defines the parameters for creating a new RSVP.
@@ -27,9 +32,50 @@ type CreateRSVPParams struct {
 	Email          string        `json:"email"`
 	Phone          string        `json:"phone"`
 	CategoryID     uuid.NullUUID `json:"category_id"`
+	// Attendees optionally names the people in the party, up to NumberOfGuests.
+	Attendees []AttendeeParams `json:"attendees"`
+	// Events lists the events the party attends; nil means every event the
+	// category is invited to.
+	Events []uuid.UUID `json:"events"`
 }
 
+// CreateRSVP inserts an RSVP with the given status without any capacity checks,
+// queueing the email that tells the guest its status.
 func (c Client) CreateRSVP(params CreateRSVPParams, status string) (RSVP, error) {
+	var rsvp RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		var err error
+		params.Events, err = c.resolveRSVPEvents(tx, params.CategoryID, params.Events)
+		if err != nil {
+			return err
+		}
+
+		id, err := c.insertRSVP(tx, params, status)
+		if err != nil {
+			return err
+		}
+
+		rsvp, err = c.getRSVP(tx, id, false)
+		if err != nil {
+			return err
+		}
+		return c.queueSubmittedEmail(tx, rsvp)
+	})
+	if err != nil {
+		return RSVP{}, err
+	}
+
+	return rsvp, nil
+}
+
+// insertRSVP adds an RSVP with its attendees and events. params.Events must
+// already be resolved against the category with resolveRSVPEvents. Callers pass
+// a transaction so a failed attendee insert leaves nothing behind.
+func (c Client) insertRSVP(q querier, params CreateRSVPParams, status string) (uuid.UUID, error) {
+	if len(params.Attendees) > params.NumberOfGuests {
+		return uuid.Nil, ErrTooManyAttendees
+	}
+
 	id := uuid.New()
 	query := `
     INSERT INTO rsvps (
@@ -42,8 +88,8 @@ func (c Client) CreateRSVP(params CreateRSVPParams, status string) (RSVP, error)
 				status
     ) VALUES (?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := c.DB.Exec(
-		query,
+	_, err := q.Exec(
+		c.rebind(query),
 		id,
 		params.GuestName,
 		params.NumberOfGuests,
@@ -52,15 +98,179 @@ func (c Client) CreateRSVP(params CreateRSVPParams, status string) (RSVP, error)
 		params.CategoryID,
 		status,
 	)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := c.insertAttendees(q, id, params.Attendees); err != nil {
+		return uuid.Nil, err
+	}
+	if err := c.insertRSVPEvents(q, id, params.Events); err != nil {
+		return uuid.Nil, err
+	}
+
+	return id, nil
+}
+
+// CreateRSVPWithinCapacity inserts an RSVP for a capacity-limited category. The
+// approved head count is read and the row inserted in one transaction with the
+// category and its events locked, so concurrent submissions cannot overbook
+// them. The RSVP is APPROVED if the party fits and WAITLISTED otherwise, and the
+// matching email is queued in the same transaction.
+func (c Client) CreateRSVPWithinCapacity(params CreateRSVPParams) (RSVP, error) {
+	var rsvp RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		category, err := c.getCategory(tx, params.CategoryID.UUID, true)
+		if err != nil {
+			return err
+		}
+		if category.ID == uuid.Nil || category.DeletedAt != nil {
+			return errors.New("guest category not found")
+		}
+
+		params.Events, err = c.resolveRSVPEvents(tx, params.CategoryID, params.Events)
+		if err != nil {
+			return err
+		}
+
+		status, err := c.statusWithinCapacity(tx, category, params.NumberOfGuests, params.Events)
+		if err != nil {
+			return err
+		}
+
+		id, err := c.insertRSVP(tx, params, status)
+		if err != nil {
+			return err
+		}
+
+		rsvp, err = c.getRSVP(tx, id, false)
+		if err != nil {
+			return err
+		}
+		return c.queueSubmittedEmail(tx, rsvp)
+	})
+	if err != nil {
+		return RSVP{}, err
+	}
+
+	return rsvp, nil
+}
+
+// statusWithinCapacity returns APPROVED if a party of numberOfGuests still fits in
+// the category and in each of the events it attends, and WAITLISTED otherwise.
+// The category must be locked by the caller.
+func (c Client) statusWithinCapacity(tx *sql.Tx, category GuestCategory, numberOfGuests int, eventIDs []uuid.UUID) (string, error) {
+	approved, err := c.approvedGuestCount(tx, category.ID)
+	if err != nil {
+		return "", err
+	}
+	if approved+numberOfGuests > category.MaxGuests {
+		return "WAITLISTED", nil
+	}
+
+	fits, err := c.eventsFit(tx, eventIDs, numberOfGuests)
+	if err != nil {
+		return "", err
+	}
+	if !fits {
+		return "WAITLISTED", nil
+	}
+	return "APPROVED", nil
+}
+
+// ErrCategoryFull is returned when approving an RSVP would exceed its category's capacity.
+var ErrCategoryFull = errors.New("guest category does not have enough remaining capacity")
+
+// ApproveRSVP approves an RSVP, first assigning categoryID if the RSVP has no
+// category yet. The capacity check and status change happen atomically with the
+// category locked; side-default categories are not capacity-limited, but every
+// RSVP must fit in the events it attends (ErrEventFull otherwise). The guest's
+// confirmation email is queued with the change.
+func (c Client) ApproveRSVP(rsvpID uuid.UUID, categoryID uuid.NullUUID) (RSVP, error) {
+	var rsvp RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		var err error
+		rsvp, err = c.getRSVP(tx, rsvpID, true)
+		if err != nil {
+			return err
+		}
+		if rsvp.ID == uuid.Nil {
+			return errors.New("no RSVP found with the given ID to approve")
+		}
+
+		// An RSVP rejected along with its category has to be given a new one.
+		if rsvp.CategoryID.Valid {
+			current, err := c.getCategory(tx, rsvp.CategoryID.UUID, false)
+			if err != nil {
+				return err
+			}
+			if current.DeletedAt != nil {
+				rsvp.CategoryID = uuid.NullUUID{}
+			}
+		}
+		if !rsvp.CategoryID.Valid {
+			if !categoryID.Valid {
+				return errors.New("a category must be assigned to approve this RSVP")
+			}
+			rsvp.CategoryID = categoryID
+		}
+
+		category, err := c.getCategory(tx, rsvp.CategoryID.UUID, true)
+		if err != nil {
+			return err
+		}
+		if category.ID == uuid.Nil || category.DeletedAt != nil {
+			return errors.New("guest category not found")
+		}
+
+		if !category.DefaultCategory && rsvp.Status != "APPROVED" {
+			approved, err := c.approvedGuestCount(tx, category.ID)
+			if err != nil {
+				return err
+			}
+			if approved+rsvp.NumberOfGuests > category.MaxGuests {
+				return ErrCategoryFull
+			}
+		}
+
+		if rsvp.Status != "APPROVED" {
+			events, err := c.rsvpEventIDs(tx, rsvp.ID)
+			if err != nil {
+				return err
+			}
+			fits, err := c.eventsFit(tx, events, rsvp.NumberOfGuests)
+			if err != nil {
+				return err
+			}
+			if !fits {
+				return ErrEventFull
+			}
+		}
+
+		query := `
+    UPDATE rsvps
+    SET status = 'APPROVED', category_id = ?
+    WHERE id = ?`
+		if _, err := tx.Exec(c.rebind(query), rsvp.CategoryID, rsvp.ID); err != nil {
+			return err
+		}
+		rsvp.Status = "APPROVED"
+		return c.queueRSVPEmail(tx, EmailRSVPConfirmed, rsvp)
+	})
 	if err != nil {
 		return RSVP{}, err
 	}
 
-	return c.GetRSVP(id)
+	return rsvp, nil
 }
 
 // GetRSVP retrieves a single RSVP by its ID.
 func (c Client) GetRSVP(id uuid.UUID) (RSVP, error) {
+	return c.getRSVP(c.DB, id, false)
+}
+
+// getRSVP loads an RSVP through q, optionally locking its row for the surrounding transaction.
+func (c Client) getRSVP(q querier, id uuid.UUID, lock bool) (RSVP, error) {
 	query := `
     SELECT
         id,
@@ -73,9 +283,12 @@ func (c Client) GetRSVP(id uuid.UUID) (RSVP, error) {
         submitted_at
     FROM rsvps
     WHERE id = ?`
+	if lock {
+		query = c.forUpdate(query)
+	}
 
 	var rsvp RSVP
-	err := c.DB.QueryRow(query, id).Scan(
+	err := q.QueryRow(c.rebind(query), id).Scan(
 		&rsvp.ID,
 		&rsvp.GuestName,
 		&rsvp.NumberOfGuests,
@@ -112,7 +325,7 @@ func (c Client) ListRSVPsByCategory(categoryID uuid.UUID) ([]RSVP, error) {
     WHERE category_id = ?
     ORDER BY submitted_at DESC`
 
-	rows, err := c.DB.Query(query, categoryID)
+	rows, err := c.DB.Query(c.rebind(query), categoryID)
 	if err != nil {
 		return nil, err
 	}
@@ -139,22 +352,128 @@ func (c Client) ListRSVPsByCategory(categoryID uuid.UUID) ([]RSVP, error) {
 	return rsvps, nil
 }
 
+// ListRSVPsByStatus retrieves every RSVP with the given status, oldest first.
+func (c Client) ListRSVPsByStatus(status string) ([]RSVP, error) {
+	return c.listRSVPs("status = ?", status)
+}
+
+// ListRSVPsSubmittedBetween retrieves the RSVPs submitted in [from, to), oldest first.
+func (c Client) ListRSVPsSubmittedBetween(from, to time.Time) ([]RSVP, error) {
+	return c.listRSVPs("submitted_at >= ? AND submitted_at < ?", c.timeArg(from), c.timeArg(to))
+}
+
+// listRSVPs retrieves the RSVPs matching a WHERE clause in submission order.
+func (c Client) listRSVPs(where string, args ...any) ([]RSVP, error) {
+	query := `
+    SELECT
+        id,
+        guest_name,
+        number_of_guests,
+        email,
+        phone,
+        status,
+        category_id,
+        submitted_at
+    FROM rsvps
+    WHERE ` + where + `
+    ORDER BY submitted_at ASC, id ASC`
+
+	rows, err := c.DB.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rsvps []RSVP
+	for rows.Next() {
+		var rsvp RSVP
+		if err := rows.Scan(
+			&rsvp.ID,
+			&rsvp.GuestName,
+			&rsvp.NumberOfGuests,
+			&rsvp.Email,
+			&rsvp.Phone,
+			&rsvp.Status,
+			&rsvp.CategoryID,
+			&rsvp.SubmittedAt,
+		); err != nil {
+			return nil, err
+		}
+		rsvps = append(rsvps, rsvp)
+	}
+
+	return rsvps, rows.Err()
+}
+
 // UpdateRSVPStatus updates the status of an RSVP (e.g., from PENDING to APPROVED).
+// An RSVP that is no longer approved loses its seats.
 func (c Client) UpdateRSVPStatus(id uuid.UUID, status string) error {
-	query := `
+	return c.withTx(func(tx *sql.Tx) error {
+		query := `
     UPDATE rsvps
     SET status = ?
     WHERE id = ?`
 
-	_, err := c.DB.Exec(query, status, id)
-	return err
+		if _, err := tx.Exec(c.rebind(query), status, id); err != nil {
+			return err
+		}
+		if status != "APPROVED" {
+			return c.unseatRSVP(tx, id)
+		}
+		return nil
+	})
 }
 
-// DeleteRSVP removes an RSVP record from the database.
-func (c Client) DeleteRSVP(id uuid.UUID) error {
-	query := `DELETE FROM rsvps WHERE id = ?`
-	_, err := c.DB.Exec(query, id)
-	return err
+// DeleteRSVP removes an RSVP record from the database. Seats it held are offered
+// to the waitlists of its category and events, and the promoted RSVPs are returned.
+func (c Client) DeleteRSVP(id uuid.UUID) ([]RSVP, error) {
+	var promoted []RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		rsvp, err := c.getRSVP(tx, id, true)
+		if err != nil {
+			return err
+		}
+		if rsvp.ID == uuid.Nil {
+			return errors.New("no RSVP found with the given ID to delete")
+		}
+		events, err := c.rsvpEventIDs(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(c.rebind(`DELETE FROM checkins WHERE rsvp_id = ?`), id); err != nil {
+			return err
+		}
+		if err := c.unseatRSVP(tx, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(c.rebind(`DELETE FROM seating_constraints WHERE rsvp_id = ? OR other_rsvp_id = ?`), id, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(c.rebind(`DELETE FROM rsvp_attendees WHERE rsvp_id = ?`), id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(c.rebind(`DELETE FROM rsvp_events WHERE rsvp_id = ?`), id); err != nil {
+			return err
+		}
+		// Free the invitee's code so they can respond again.
+		if _, err := tx.Exec(c.rebind(`UPDATE guests SET rsvp_id = NULL, responded_at = NULL WHERE rsvp_id = ?`), id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(c.rebind(`DELETE FROM rsvps WHERE id = ?`), id); err != nil {
+			return err
+		}
+
+		if rsvp.Status == "APPROVED" {
+			promoted, err = c.releaseSeats(tx, rsvp.CategoryID, events)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return promoted, nil
 }
 
 // ListAllRSVPs retrieves all RSVPs from the database.
@@ -171,7 +490,7 @@ func (c Client) ListAllRSVPs(status, side string) ([]RSVP, error) {
         category_id,
         submitted_at
     FROM rsvps
-		JOIN guest_categories gc ON (gc.id == rsvps.category_id)`
+		JOIN guest_categories gc ON (gc.id = rsvps.category_id)`
 
 	args := []interface{}{}
 
@@ -189,7 +508,7 @@ func (c Client) ListAllRSVPs(status, side string) ([]RSVP, error) {
 
 	query += " ORDER BY submitted_at ASC"
 
-	rows, err := c.DB.Query(query, args...)
+	rows, err := c.DB.Query(c.rebind(query), args...)
 	if err != nil {
 
 		return nil, err
@@ -229,7 +548,7 @@ func (c Client) AssignCategoryToRSVP(rsvpID uuid.UUID, categoryID uuid.UUID) err
     SET category_id = ?
     WHERE id = ?`
 
-	result, err := c.DB.Exec(query, categoryID, rsvpID)
+	result, err := c.DB.Exec(c.rebind(query), categoryID, rsvpID)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Outcomes reported for each row of a bulk import.
+const (
+	ImportCreated = "created"
+	ImportSkipped = "skipped"
+	ImportInvalid = "invalid"
+)
+
+// errDryRun rolls back an import transaction once the report has been built.
+var errDryRun = errors.New("dry run")
+
+// ImportRow is one already normalized guest from an import file. Rows that
+// failed validation carry their reason in Error and are reported as invalid.
+type ImportRow struct {
+	Line           int
+	GuestName      string
+	Email          string
+	Phone          string
+	NumberOfGuests int
+	Error          string
+}
+
+// ImportResult reports what happened to one ImportRow.
+type ImportResult struct {
+	Line      int       `json:"line"`
+	GuestName string    `json:"guest_name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	Outcome   string    `json:"outcome"`
+	Status    string    `json:"status,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	RSVPID    uuid.UUID `json:"rsvp_id,omitzero"`
+}
+
+// ImportRSVPs creates RSVPs for rows under a category in a single transaction
+// with the category locked. Rows whose email or phone is already taken, either
+// in the database or earlier in the file, are skipped. Capacity-limited
+// categories approve rows while seats remain in the category and its events and
+// waitlist the rest; default categories leave them PENDING. With dryRun the
+// transaction is rolled back, so the report shows exactly what a real import
+// would do without saving anything. With notify, each created guest's status
+// email is queued in the same transaction.
+func (c Client) ImportRSVPs(categoryID uuid.UUID, rows []ImportRow, dryRun, notify bool) ([]ImportResult, error) {
+	var results []ImportResult
+	err := c.withTx(func(tx *sql.Tx) error {
+		category, err := c.getCategory(tx, categoryID, true)
+		if err != nil {
+			return err
+		}
+		if category.ID == uuid.Nil || category.DeletedAt != nil {
+			return errors.New("guest category not found")
+		}
+
+		// Imported guests attend every event the category is invited to.
+		events, err := c.resolveRSVPEvents(tx, uuid.NullUUID{UUID: category.ID, Valid: true}, nil)
+		if err != nil {
+			return err
+		}
+
+		seenEmails := map[string]int{}
+		seenPhones := map[string]int{}
+		results = make([]ImportResult, 0, len(rows))
+
+		for _, row := range rows {
+			result := ImportResult{
+				Line:      row.Line,
+				GuestName: row.GuestName,
+				Email:     row.Email,
+				Phone:     row.Phone,
+			}
+
+			if row.Error != "" {
+				result.Outcome, result.Reason = ImportInvalid, row.Error
+				results = append(results, result)
+				continue
+			}
+
+			if line, ok := seenEmails[row.Email]; ok {
+				result.Outcome, result.Reason = ImportSkipped, duplicateInFile("email", line)
+				results = append(results, result)
+				continue
+			}
+			if line, ok := seenPhones[row.Phone]; ok {
+				result.Outcome, result.Reason = ImportSkipped, duplicateInFile("phone", line)
+				results = append(results, result)
+				continue
+			}
+
+			reason, err := c.existingRSVPConflict(tx, row.Email, row.Phone)
+			if err != nil {
+				return err
+			}
+			if reason != "" {
+				result.Outcome, result.Reason = ImportSkipped, reason
+				results = append(results, result)
+				continue
+			}
+
+			status := "PENDING"
+			if !category.DefaultCategory {
+				status, err = c.statusWithinCapacity(tx, category, row.NumberOfGuests, events)
+				if err != nil {
+					return err
+				}
+			}
+
+			id, err := c.insertRSVP(tx, CreateRSVPParams{
+				GuestName:      row.GuestName,
+				NumberOfGuests: row.NumberOfGuests,
+				Email:          row.Email,
+				Phone:          row.Phone,
+				CategoryID:     uuid.NullUUID{UUID: category.ID, Valid: true},
+				Events:         events,
+			}, status)
+			if err != nil {
+				return err
+			}
+			if notify {
+				rsvp := RSVP{ID: id, GuestName: row.GuestName, Email: row.Email, Status: status}
+				if err := c.queueSubmittedEmail(tx, rsvp); err != nil {
+					return err
+				}
+			}
+
+			seenEmails[row.Email] = row.Line
+			seenPhones[row.Phone] = row.Line
+			result.Outcome, result.Status = ImportCreated, status
+			if !dryRun {
+				result.RSVPID = id
+			}
+			results = append(results, result)
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// existingRSVPConflict explains why an email or phone cannot be imported, or
+// returns "" if neither is used by an existing RSVP. phone must be normalized;
+// stored phones are compared with their formatting stripped the same way, since
+// RSVPs submitted through the site keep what the guest typed.
+func (c Client) existingRSVPConflict(q querier, email, phone string) (string, error) {
+	var emailTaken, phoneTaken int
+	storedPhone := normalizedPhoneSQL("phone")
+	query := `
+    SELECT
+        COUNT(CASE WHEN LOWER(email) = ? THEN 1 END),
+        COUNT(CASE WHEN ` + storedPhone + ` = ? THEN 1 END)
+    FROM rsvps
+    WHERE LOWER(email) = ? OR ` + storedPhone + ` = ?`
+
+	err := q.QueryRow(c.rebind(query), email, phone, email, phone).Scan(&emailTaken, &phoneTaken)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case emailTaken > 0:
+		return "an RSVP with this email already exists", nil
+	case phoneTaken > 0:
+		return "an RSVP with this phone number already exists", nil
+	}
+	return "", nil
+}
+
+// normalizedPhoneSQL is an SQL expression for column with the formatting the
+// import's phone normalization removes stripped, and a leading 00 turned into +.
+func normalizedPhoneSQL(column string) string {
+	stripped := column
+	for _, char := range []string{" ", "-", ".", "(", ")", "\t"} {
+		stripped = "REPLACE(" + stripped + ", '" + char + "', '')"
+	}
+	return "(CASE WHEN " + stripped + " LIKE '00%' THEN '+' || SUBSTR(" + stripped + ", 3) ELSE " + stripped + " END)"
+}
+
+func duplicateInFile(field string, line int) string {
+	return fmt.Sprintf("duplicate %s of line %d in this file", field, line)
+}
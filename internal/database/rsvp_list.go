@@ -0,0 +1,480 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRSVPPageSize = 50
+	maxRSVPPageSize     = 200
+)
+
+// rsvpSortColumns maps the sort keys accepted by ListRSVPs onto columns.
+var rsvpSortColumns = map[string]string{
+	"submitted_at":     "rsvps.submitted_at",
+	"guest_name":       "rsvps.guest_name",
+	"number_of_guests": "rsvps.number_of_guests",
+}
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or
+// does not match the requested sort order.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// RSVPFilter narrows down the RSVPs returned by ListRSVPs.
+type RSVPFilter struct {
+	Side       string
+	Status     string
+	CategoryID uuid.NullUUID
+	// Search matches guest name, email or phone, case-insensitively.
+	Search string
+	// SubmittedFrom is inclusive and SubmittedTo exclusive.
+	SubmittedFrom *time.Time
+	SubmittedTo   *time.Time
+}
+
+// ListRSVPsParams describes a page of RSVPs to fetch.
+type ListRSVPsParams struct {
+	RSVPFilter
+	// SortBy is one of submitted_at (default), guest_name or number_of_guests.
+	SortBy     string
+	Descending bool
+	Limit      int
+	Cursor     string
+}
+
+// RSVPPage is one page of RSVPs plus totals for the whole filtered result.
+type RSVPPage struct {
+	RSVPs      []RSVP `json:"rsvps"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+	// StatusTotals counts matching RSVPs per status, ignoring the status filter.
+	StatusTotals map[string]int `json:"status_totals"`
+}
+
+// rsvpCursor marks the last row of a page so the next page can resume after it.
+type rsvpCursor struct {
+	SortBy string `json:"s"`
+	Value  any    `json:"v"`
+	ID     string `json:"id"`
+}
+
+// ListRSVPs returns a filtered, sorted page of RSVPs using keyset pagination.
+func (c Client) ListRSVPs(params ListRSVPsParams) (RSVPPage, error) {
+	if params.SortBy == "" {
+		params.SortBy = "submitted_at"
+	}
+	sortColumn, ok := rsvpSortColumns[params.SortBy]
+	if !ok {
+		return RSVPPage{}, fmt.Errorf("unsupported sort field %q", params.SortBy)
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultRSVPPageSize
+	}
+	if params.Limit > maxRSVPPageSize {
+		params.Limit = maxRSVPPageSize
+	}
+
+	page := RSVPPage{RSVPs: []RSVP{}}
+
+	statusTotals, err := c.countRSVPsByStatus(params.RSVPFilter)
+	if err != nil {
+		return RSVPPage{}, err
+	}
+	page.StatusTotals = statusTotals
+	for status, count := range statusTotals {
+		if params.Status == "" || params.Status == status {
+			page.Total += count
+		}
+	}
+
+	where, args := c.rsvpFilterClause(params.RSVPFilter, true)
+
+	direction, comparison := "ASC", ">"
+	if params.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if params.Cursor != "" {
+		cursor, err := c.decodeRSVPCursor(params.Cursor, params.SortBy)
+		if err != nil {
+			return RSVPPage{}, err
+		}
+		where = append(where, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND rsvps.id %[2]s ?))", sortColumn, comparison))
+		args = append(args, cursor.Value, cursor.Value, cursor.ID)
+	}
+
+	query := `
+    SELECT
+        rsvps.id,
+        guest_name,
+        number_of_guests,
+        email,
+        phone,
+        status,
+        category_id,
+        submitted_at
+    FROM rsvps
+    JOIN guest_categories gc ON (gc.id = rsvps.category_id)`
+	if len(where) > 0 {
+		query += "\n    WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf("\n    ORDER BY %s %s, rsvps.id %s\n    LIMIT ?", sortColumn, direction, direction)
+	args = append(args, params.Limit+1)
+
+	rows, err := c.DB.Query(c.rebind(query), args...)
+	if err != nil {
+		return RSVPPage{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rsvp RSVP
+		if err := rows.Scan(
+			&rsvp.ID,
+			&rsvp.GuestName,
+			&rsvp.NumberOfGuests,
+			&rsvp.Email,
+			&rsvp.Phone,
+			&rsvp.Status,
+			&rsvp.CategoryID,
+			&rsvp.SubmittedAt,
+		); err != nil {
+			return RSVPPage{}, err
+		}
+		page.RSVPs = append(page.RSVPs, rsvp)
+	}
+	if err := rows.Err(); err != nil {
+		return RSVPPage{}, err
+	}
+
+	if len(page.RSVPs) > params.Limit {
+		page.RSVPs = page.RSVPs[:params.Limit]
+		last := page.RSVPs[len(page.RSVPs)-1]
+		page.NextCursor, err = encodeRSVPCursor(params.SortBy, last)
+		if err != nil {
+			return RSVPPage{}, err
+		}
+	}
+
+	ids := make([]uuid.UUID, len(page.RSVPs))
+	for i, rsvp := range page.RSVPs {
+		ids[i] = rsvp.ID
+	}
+	attendees, err := c.attendeesByRSVP(ids)
+	if err != nil {
+		return RSVPPage{}, err
+	}
+	events, err := c.eventNamesByRSVP(ids)
+	if err != nil {
+		return RSVPPage{}, err
+	}
+	deliveries, err := c.deliveriesByRSVP(ids)
+	if err != nil {
+		return RSVPPage{}, err
+	}
+	for i := range page.RSVPs {
+		page.RSVPs[i].Attendees = attendees[page.RSVPs[i].ID]
+		page.RSVPs[i].Events = events[page.RSVPs[i].ID]
+		if delivery, ok := deliveries[page.RSVPs[i].ID]; ok {
+			page.RSVPs[i].Delivery = &delivery
+		}
+	}
+
+	return page, nil
+}
+
+// countRSVPsByStatus counts the RSVPs matching filter per status, ignoring filter.Status.
+func (c Client) countRSVPsByStatus(filter RSVPFilter) (map[string]int, error) {
+	where, args := c.rsvpFilterClause(filter, false)
+
+	query := `
+    SELECT rsvps.status, COUNT(*)
+    FROM rsvps
+    JOIN guest_categories gc ON (gc.id = rsvps.category_id)`
+	if len(where) > 0 {
+		query += "\n    WHERE " + strings.Join(where, " AND ")
+	}
+	query += "\n    GROUP BY rsvps.status"
+
+	rows, err := c.DB.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := map[string]int{"PENDING": 0, "APPROVED": 0, "REJECTED": 0, "WAITLISTED": 0}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		totals[status] = count
+	}
+
+	return totals, rows.Err()
+}
+
+// rsvpFilterClause builds the WHERE conditions for filter over rsvps joined with
+// guest_categories aliased as gc.
+func (c Client) rsvpFilterClause(filter RSVPFilter, includeStatus bool) ([]string, []any) {
+	var where []string
+	var args []any
+
+	if filter.Side != "" {
+		where = append(where, "gc.side = ?")
+		args = append(args, filter.Side)
+	}
+	if includeStatus && filter.Status != "" {
+		where = append(where, "rsvps.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.CategoryID.Valid {
+		where = append(where, "rsvps.category_id = ?")
+		args = append(args, filter.CategoryID.UUID)
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(search)) + "%"
+		where = append(where, `(LOWER(rsvps.guest_name) LIKE ? ESCAPE '\' OR LOWER(rsvps.email) LIKE ? ESCAPE '\' OR rsvps.phone LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern)
+	}
+	if filter.SubmittedFrom != nil {
+		where = append(where, "rsvps.submitted_at >= ?")
+		args = append(args, c.timeArg(*filter.SubmittedFrom))
+	}
+	if filter.SubmittedTo != nil {
+		where = append(where, "rsvps.submitted_at < ?")
+		args = append(args, c.timeArg(*filter.SubmittedTo))
+	}
+
+	return where, args
+}
+
+// timeArg prepares a time for comparison against a TIMESTAMP column. SQLite keeps
+// CURRENT_TIMESTAMP defaults as "YYYY-MM-DD HH:MM:SS" text in UTC, so comparisons
+// must use the same textual form; PostgreSQL compares real timestamps.
+func (c Client) timeArg(t time.Time) any {
+	if c.dialect == dialectSQLite {
+		return t.UTC().Format(time.DateTime)
+	}
+	return t
+}
+
+// preciseTimeArg is timeArg with sub-second precision, for timestamps that
+// order rows written within the same second. The SQLite form still sorts
+// correctly against whole-second values.
+func (c Client) preciseTimeArg(t time.Time) any {
+	if c.dialect == dialectSQLite {
+		return t.UTC().Format("2006-01-02 15:04:05.000000")
+	}
+	return t
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func encodeRSVPCursor(sortBy string, last RSVP) (string, error) {
+	cursor := rsvpCursor{SortBy: sortBy, ID: last.ID.String()}
+	switch sortBy {
+	case "guest_name":
+		cursor.Value = last.GuestName
+	case "number_of_guests":
+		cursor.Value = last.NumberOfGuests
+	default:
+		cursor.Value = last.SubmittedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeRSVPCursor restores a cursor, converting its value back to the type of
+// the sort column so it can be bound as a query argument.
+func (c Client) decodeRSVPCursor(encoded, sortBy string) (rsvpCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return rsvpCursor{}, ErrInvalidCursor
+	}
+	var cursor rsvpCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return rsvpCursor{}, ErrInvalidCursor
+	}
+	if cursor.SortBy != sortBy {
+		return rsvpCursor{}, ErrInvalidCursor
+	}
+	if _, err := uuid.Parse(cursor.ID); err != nil {
+		return rsvpCursor{}, ErrInvalidCursor
+	}
+
+	switch sortBy {
+	case "guest_name":
+		if _, ok := cursor.Value.(string); !ok {
+			return rsvpCursor{}, ErrInvalidCursor
+		}
+	case "number_of_guests":
+		n, ok := cursor.Value.(float64)
+		if !ok {
+			return rsvpCursor{}, ErrInvalidCursor
+		}
+		cursor.Value = int(n)
+	default:
+		raw, ok := cursor.Value.(string)
+		if !ok {
+			return rsvpCursor{}, ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return rsvpCursor{}, ErrInvalidCursor
+		}
+		cursor.Value = c.timeArg(t)
+	}
+
+	return cursor, nil
+}
+
+// ExportRow is an RSVP joined with the category details needed for exports.
+type ExportRow struct {
+	RSVP
+	CategoryName string
+	CategorySide string
+}
+
+// exportBatchSize is how many RSVPs StreamRSVPs holds while it looks up their
+// events.
+const exportBatchSize = 200
+
+// StreamRSVPs calls fn for every RSVP matching filter, with its attendees and
+// events, ordered by submission time, without holding the whole result in
+// memory. Iteration stops at the first error returned by fn.
+func (c Client) StreamRSVPs(filter RSVPFilter, fn func(ExportRow) error) error {
+	// Event names would multiply the attendee rows below, so they are looked
+	// up for a batch of RSVPs at a time instead.
+	var batch []ExportRow
+	flush := func() error {
+		ids := make([]uuid.UUID, len(batch))
+		for i, row := range batch {
+			ids[i] = row.ID
+		}
+		events, err := c.eventNamesByRSVP(ids)
+		if err != nil {
+			return err
+		}
+		for _, row := range batch {
+			row.Events = events[row.ID]
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	where, args := c.rsvpFilterClause(filter, true)
+
+	query := `
+    SELECT
+        rsvps.id,
+        rsvps.guest_name,
+        rsvps.number_of_guests,
+        rsvps.email,
+        rsvps.phone,
+        rsvps.status,
+        rsvps.category_id,
+        rsvps.submitted_at,
+        gc.name,
+        gc.side,
+        ra.id,
+        ra.name,
+        ra.age_group,
+        ra.dietary_restrictions,
+        ra.allergy_notes,
+        ra.meal_option_id,
+        mo.name
+    FROM rsvps
+    JOIN guest_categories gc ON (gc.id = rsvps.category_id)
+    LEFT JOIN rsvp_attendees ra ON (ra.rsvp_id = rsvps.id)
+    LEFT JOIN meal_options mo ON (mo.id = ra.meal_option_id)`
+	if len(where) > 0 {
+		query += "\n    WHERE " + strings.Join(where, " AND ")
+	}
+	query += "\n    ORDER BY rsvps.submitted_at ASC, rsvps.id ASC, ra.position ASC"
+
+	rows, err := c.DB.Query(c.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Each RSVP spans one row per attendee; rows are gathered until the RSVP changes.
+	var current ExportRow
+	for rows.Next() {
+		var row ExportRow
+		var attendeeID uuid.NullUUID
+		var attendeeName, ageGroup, dietary, allergyNotes, mealOption sql.NullString
+		var mealOptionID uuid.NullUUID
+		if err := rows.Scan(
+			&row.ID,
+			&row.GuestName,
+			&row.NumberOfGuests,
+			&row.Email,
+			&row.Phone,
+			&row.Status,
+			&row.CategoryID,
+			&row.SubmittedAt,
+			&row.CategoryName,
+			&row.CategorySide,
+			&attendeeID,
+			&attendeeName,
+			&ageGroup,
+			&dietary,
+			&allergyNotes,
+			&mealOptionID,
+			&mealOption,
+		); err != nil {
+			return err
+		}
+
+		if row.ID != current.ID {
+			if current.ID != uuid.Nil {
+				batch = append(batch, current)
+			}
+			if len(batch) == exportBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			current = row
+		}
+		if attendeeID.Valid {
+			current.Attendees = append(current.Attendees, Attendee{
+				ID:                  attendeeID.UUID,
+				Name:                attendeeName.String,
+				AgeGroup:            ageGroup.String,
+				DietaryRestrictions: splitDietaryRestrictions(dietary.String),
+				AllergyNotes:        allergyNotes.String,
+				MealOptionID:        mealOptionID,
+				MealOption:          mealOption.String,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if current.ID != uuid.Nil {
+		batch = append(batch, current)
+	}
+	return flush()
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxManageOTPAttempts is how many wrong guesses a guest's sign-in code allows
+// before it is cleared and a new one has to be requested.
+const maxManageOTPAttempts = 5
+
+// ErrInvalidOTP is returned when a guest's sign-in code is wrong, expired or
+// has been cleared after too many wrong guesses.
+var ErrInvalidOTP = errors.New("invalid or expired OTP")
+
+// GetRSVPByEmail retrieves an RSVP by the guest's email address, which is
+// stored lower-cased.
+func (c Client) GetRSVPByEmail(email string) (RSVP, error) {
+	query := `SELECT id FROM rsvps WHERE email = ?`
+
+	var id uuid.UUID
+	err := c.DB.QueryRow(c.rebind(query), email).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return RSVP{}, nil
+		}
+		return RSVP{}, err
+	}
+
+	return c.GetRSVP(id)
+}
+
+// StoreOTPForRSVP saves a one-time code a guest can exchange for a session to
+// manage their RSVP, replacing any earlier code and its wrong guesses.
+func (c Client) StoreOTPForRSVP(id uuid.UUID, otp string, expiry time.Time) error {
+	query := `UPDATE rsvps SET manage_otp = ?, manage_otp_expiry = ?, manage_otp_attempts = 0 WHERE id = ?`
+	_, err := c.DB.Exec(c.rebind(query), otp, expiry.UTC(), id)
+	return err
+}
+
+// VerifyOTPForRSVP checks a guest's one-time code and, if valid, consumes it
+// and returns their RSVP. A wrong code counts against the guest's code, which
+// is cleared after maxManageOTPAttempts wrong guesses.
+func (c Client) VerifyOTPForRSVP(email string, otp string) (RSVP, error) {
+	var id uuid.UUID
+	valid := false
+	err := c.withTx(func(tx *sql.Tx) error {
+		query := c.forUpdate(`
+    SELECT id, manage_otp, manage_otp_expiry, manage_otp_attempts
+    FROM rsvps
+    WHERE email = ?`)
+
+		var storedOTP sql.NullString
+		var expiry sql.NullTime
+		var attempts int
+		err := tx.QueryRow(c.rebind(query), email).Scan(&id, &storedOTP, &expiry, &attempts)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+		if !storedOTP.Valid || !expiry.Valid || !expiry.Time.After(time.Now()) {
+			return nil
+		}
+
+		valid = subtle.ConstantTimeCompare([]byte(storedOTP.String), []byte(otp)) == 1
+		if !valid && attempts+1 < maxManageOTPAttempts {
+			query = `UPDATE rsvps SET manage_otp_attempts = ? WHERE id = ?`
+			_, err = tx.Exec(c.rebind(query), attempts+1, id)
+			return err
+		}
+		// The code is used up, either by signing in or by the last wrong guess.
+		query = `UPDATE rsvps SET manage_otp = NULL, manage_otp_expiry = NULL, manage_otp_attempts = 0 WHERE id = ?`
+		_, err = tx.Exec(c.rebind(query), id)
+		return err
+	})
+	if err != nil {
+		return RSVP{}, err
+	}
+	if !valid {
+		return RSVP{}, ErrInvalidOTP
+	}
+
+	return c.GetRSVP(id)
+}
+
+// UpdateGuestRSVPParams are the changes a guest makes to their own RSVP. Nil
+// fields are left as they are.
+type UpdateGuestRSVPParams struct {
+	Phone          *string
+	NumberOfGuests *int
+	// Events replaces the events the party attends when not nil.
+	Events []uuid.UUID
+}
+
+// UpdateGuestRSVP applies a guest's changes to their RSVP in one transaction,
+// so that either all of them are made or, if any fails, none is. The party size
+// and events are checked as by UpdateRSVPPartySize and SetRSVPEvents, and the
+// RSVPs promoted into seats the changes freed are returned.
+func (c Client) UpdateGuestRSVP(id uuid.UUID, params UpdateGuestRSVPParams) (RSVP, []RSVP, error) {
+	var rsvp RSVP
+	var promoted []RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		var err error
+		rsvp, err = c.getRSVP(tx, id, true)
+		if err != nil {
+			return err
+		}
+		if rsvp.ID == uuid.Nil {
+			return errors.New("no RSVP found with the given ID to update")
+		}
+
+		if params.Phone != nil {
+			if _, err := tx.Exec(c.rebind(`UPDATE rsvps SET phone = ? WHERE id = ?`), *params.Phone, rsvp.ID); err != nil {
+				return err
+			}
+			rsvp.Phone = *params.Phone
+		}
+
+		// The size changes first so that events the party joins are checked
+		// against its new size.
+		if params.NumberOfGuests != nil {
+			released, err := c.updateRSVPPartySize(tx, &rsvp, *params.NumberOfGuests)
+			if err != nil {
+				return err
+			}
+			promoted = append(promoted, released...)
+		}
+
+		if params.Events != nil {
+			released, err := c.setRSVPEvents(tx, rsvp, params.Events)
+			if err != nil {
+				return err
+			}
+			promoted = append(promoted, released...)
+		}
+		return nil
+	})
+	if err != nil {
+		return RSVP{}, nil, err
+	}
+
+	return rsvp, promoted, nil
+}
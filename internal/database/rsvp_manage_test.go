@@ -0,0 +1,90 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifyOTPForRSVP(t *testing.T) {
+	c := newTestClient(t)
+	category := newTestCategory(t, c, "BRIDE", 10)
+	rsvp, err := c.CreateRSVP(CreateRSVPParams{
+		GuestName:      "Ada Lovelace",
+		NumberOfGuests: 1,
+		Email:          "ada@example.com",
+		Phone:          "08000000001",
+		CategoryID:     nullID(category.ID),
+	}, "APPROVED")
+	if err != nil {
+		t.Fatalf("CreateRSVP: %v", err)
+	}
+
+	store := func(t *testing.T, otp string, expiry time.Time) {
+		t.Helper()
+		if err := c.StoreOTPForRSVP(rsvp.ID, otp, expiry); err != nil {
+			t.Fatalf("StoreOTPForRSVP: %v", err)
+		}
+	}
+	verify := func(email, otp string) error {
+		got, err := c.VerifyOTPForRSVP(email, otp)
+		if err == nil && got.ID != rsvp.ID {
+			t.Errorf("VerifyOTPForRSVP returned RSVP %s, want %s", got.ID, rsvp.ID)
+		}
+		return err
+	}
+
+	t.Run("valid code is used up", func(t *testing.T) {
+		store(t, "123456", time.Now().Add(time.Hour))
+		if err := verify("ada@example.com", "123456"); err != nil {
+			t.Fatalf("VerifyOTPForRSVP: %v", err)
+		}
+		if err := verify("ada@example.com", "123456"); !errors.Is(err, ErrInvalidOTP) {
+			t.Errorf("second use: err = %v, want %v", err, ErrInvalidOTP)
+		}
+	})
+
+	t.Run("expired code", func(t *testing.T) {
+		store(t, "123456", time.Now().Add(-time.Minute))
+		if err := verify("ada@example.com", "123456"); !errors.Is(err, ErrInvalidOTP) {
+			t.Errorf("err = %v, want %v", err, ErrInvalidOTP)
+		}
+	})
+
+	t.Run("email is matched exactly", func(t *testing.T) {
+		store(t, "123456", time.Now().Add(time.Hour))
+		if err := verify("Ada@Example.com", "123456"); !errors.Is(err, ErrInvalidOTP) {
+			t.Errorf("err = %v, want %v", err, ErrInvalidOTP)
+		}
+	})
+
+	t.Run("wrong guesses below the limit", func(t *testing.T) {
+		store(t, "123456", time.Now().Add(time.Hour))
+		for range maxManageOTPAttempts - 1 {
+			if err := verify("ada@example.com", "000000"); !errors.Is(err, ErrInvalidOTP) {
+				t.Fatalf("wrong guess: err = %v, want %v", err, ErrInvalidOTP)
+			}
+		}
+		if err := verify("ada@example.com", "123456"); err != nil {
+			t.Errorf("right code after %d wrong guesses: %v", maxManageOTPAttempts-1, err)
+		}
+	})
+
+	t.Run("too many wrong guesses clear the code", func(t *testing.T) {
+		store(t, "123456", time.Now().Add(time.Hour))
+		for range maxManageOTPAttempts {
+			if err := verify("ada@example.com", "000000"); !errors.Is(err, ErrInvalidOTP) {
+				t.Fatalf("wrong guess: err = %v, want %v", err, ErrInvalidOTP)
+			}
+		}
+		if err := verify("ada@example.com", "123456"); !errors.Is(err, ErrInvalidOTP) {
+			t.Errorf("right code after %d wrong guesses: err = %v, want %v", maxManageOTPAttempts, err, ErrInvalidOTP)
+		}
+
+		// A new code starts with a clean count.
+		store(t, "654321", time.Now().Add(time.Hour))
+		if err := verify("ada@example.com", "654321"); err != nil {
+			t.Errorf("new code: %v", err)
+		}
+	})
+}
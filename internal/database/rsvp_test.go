@@ -0,0 +1,137 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// approvedGuests sums the party sizes of the category's APPROVED RSVPs and
+// checks that every other RSVP is WAITLISTED.
+func approvedGuests(t *testing.T, c Client, category GuestCategory) int {
+	t.Helper()
+
+	rsvps, err := c.ListRSVPsByCategory(category.ID)
+	if err != nil {
+		t.Fatalf("ListRSVPsByCategory: %v", err)
+	}
+	total := 0
+	for _, rsvp := range rsvps {
+		switch rsvp.Status {
+		case "APPROVED":
+			total += rsvp.NumberOfGuests
+		case "WAITLISTED", "PENDING":
+		default:
+			t.Errorf("RSVP %s has status %s", rsvp.ID, rsvp.Status)
+		}
+	}
+	return total
+}
+
+func TestCreateRSVPWithinCapacityConcurrent(t *testing.T) {
+	c := newTestClient(t)
+	const maxGuests = 50
+	category := newTestCategory(t, c, "BRIDE", maxGuests)
+
+	const submissions = 300
+	statuses := make(chan string, submissions)
+	errs := make(chan error, submissions)
+	var wg sync.WaitGroup
+	for i := range submissions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rsvp, err := c.CreateRSVPWithinCapacity(CreateRSVPParams{
+				GuestName:      fmt.Sprintf("Guest %d", i),
+				NumberOfGuests: 1 + i%3,
+				Email:          fmt.Sprintf("guest%d@example.com", i),
+				Phone:          fmt.Sprintf("0800000%04d", i),
+				CategoryID:     nullID(category.ID),
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			statuses <- rsvp.Status
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	close(statuses)
+
+	for err := range errs {
+		t.Errorf("CreateRSVPWithinCapacity: %v", err)
+	}
+	counts := map[string]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	if counts["APPROVED"]+counts["WAITLISTED"] != submissions {
+		t.Errorf("statuses = %v, want only APPROVED and WAITLISTED for %d submissions", counts, submissions)
+	}
+	if counts["WAITLISTED"] == 0 {
+		t.Errorf("no submission was waitlisted; the test did not fill the category")
+	}
+
+	if total := approvedGuests(t, c, category); total > maxGuests {
+		t.Errorf("approved %d guests, more than the category's %d", total, maxGuests)
+	}
+}
+
+func TestApproveRSVPConcurrent(t *testing.T) {
+	c := newTestClient(t)
+	const maxGuests = 40
+	category := newTestCategory(t, c, "GROOM", maxGuests)
+
+	const submissions = 200
+	var pending []RSVP
+	for i := range submissions {
+		rsvp, err := c.CreateRSVP(CreateRSVPParams{
+			GuestName:      fmt.Sprintf("Guest %d", i),
+			NumberOfGuests: 1 + i%3,
+			Email:          fmt.Sprintf("guest%d@example.com", i),
+			Phone:          fmt.Sprintf("0800000%04d", i),
+			CategoryID:     nullID(category.ID),
+		}, "PENDING")
+		if err != nil {
+			t.Fatalf("CreateRSVP: %v", err)
+		}
+		pending = append(pending, rsvp)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		approved int
+		full     int
+	)
+	for _, rsvp := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.ApproveRSVP(rsvp.ID, nullID(category.ID))
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				approved++
+			case errors.Is(err, ErrCategoryFull):
+				full++
+			default:
+				t.Errorf("ApproveRSVP: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if approved+full != submissions {
+		t.Errorf("%d approved and %d full, want %d in total", approved, full, submissions)
+	}
+	if full == 0 {
+		t.Errorf("no approval was refused; the test did not fill the category")
+	}
+	if total := approvedGuests(t, c, category); total > maxGuests {
+		t.Errorf("approved %d guests, more than the category's %d", total, maxGuests)
+	}
+}
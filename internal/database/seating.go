@@ -0,0 +1,435 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SeatingTable is a reception table. SeatsTaken counts whole parties by their
+// party size and individually seated attendees as one seat each.
+type SeatingTable struct {
+	ID          uuid.UUID        `json:"id"`
+	Number      int              `json:"number"`
+	Name        string           `json:"name"`
+	Capacity    int              `json:"capacity"`
+	SeatsTaken  int              `json:"seats_taken"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Assignments []SeatAssignment `json:"assignments,omitempty"`
+}
+
+// CreateSeatingTableParams defines the parameters for adding a table.
+type CreateSeatingTableParams struct {
+	Number   int
+	Name     string
+	Capacity int
+}
+
+// SeatAssignment seats either a whole approved party or, when AttendeeID is set,
+// one named attendee at a table.
+type SeatAssignment struct {
+	ID           uuid.UUID     `json:"id"`
+	TableID      uuid.UUID     `json:"table_id"`
+	RSVPID       uuid.UUID     `json:"rsvp_id"`
+	AttendeeID   uuid.NullUUID `json:"attendee_id"`
+	GuestName    string        `json:"guest_name"`
+	AttendeeName string        `json:"attendee_name,omitempty"`
+	CategoryName string        `json:"category_name"`
+	Seats        int           `json:"seats"`
+}
+
+var (
+	// ErrTableNotFound is returned when seating guests at a table that does not exist.
+	ErrTableNotFound = errors.New("table not found")
+	// ErrTableFull is returned when seating a party would exceed a table's capacity.
+	ErrTableFull = errors.New("table does not have enough free seats")
+	// ErrCapacityBelowSeated is returned when shrinking a table below the guests already seated at it.
+	ErrCapacityBelowSeated = errors.New("table capacity cannot be lower than the seats already taken")
+	// ErrRSVPNotApproved is returned when seating an RSVP that is not APPROVED.
+	ErrRSVPNotApproved = errors.New("only approved RSVPs can be seated")
+	// ErrPartySeatedTogether is returned when seating one attendee of a party that
+	// is already seated as a whole.
+	ErrPartySeatedTogether = errors.New("this party is seated together; unseat it before seating attendees individually")
+)
+
+// seatsTakenExpr sums the seats of assignments joined with their RSVPs as r.
+const seatsTakenExpr = `COALESCE(SUM(CASE WHEN sa.attendee_id IS NULL THEN r.number_of_guests ELSE 1 END), 0)`
+
+// CreateSeatingTable inserts a new, empty table.
+func (c Client) CreateSeatingTable(params CreateSeatingTableParams) (SeatingTable, error) {
+	id := uuid.New()
+	query := `INSERT INTO seating_tables (id, number, name, capacity) VALUES (?, ?, ?, ?)`
+
+	if _, err := c.DB.Exec(c.rebind(query), id, params.Number, params.Name, params.Capacity); err != nil {
+		return SeatingTable{}, err
+	}
+
+	return c.GetSeatingTable(id)
+}
+
+// GetSeatingTable retrieves a table and how many of its seats are taken.
+func (c Client) GetSeatingTable(id uuid.UUID) (SeatingTable, error) {
+	return c.getSeatingTable(c.DB, id, false)
+}
+
+func (c Client) getSeatingTable(q querier, id uuid.UUID, lock bool) (SeatingTable, error) {
+	query := `
+    SELECT id, number, name, capacity, created_at
+    FROM seating_tables
+    WHERE id = ?`
+	if lock {
+		query = c.forUpdate(query)
+	}
+
+	var table SeatingTable
+	err := q.QueryRow(c.rebind(query), id).Scan(
+		&table.ID,
+		&table.Number,
+		&table.Name,
+		&table.Capacity,
+		&table.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return SeatingTable{}, nil
+		}
+		return SeatingTable{}, err
+	}
+
+	table.SeatsTaken, err = c.seatsTaken(q, table.ID)
+	if err != nil {
+		return SeatingTable{}, err
+	}
+
+	return table, nil
+}
+
+func (c Client) seatsTaken(q querier, tableID uuid.UUID) (int, error) {
+	query := `
+    SELECT ` + seatsTakenExpr + `
+    FROM seat_assignments sa
+    JOIN rsvps r ON (r.id = sa.rsvp_id)
+    WHERE sa.table_id = ?`
+
+	var taken int
+	err := q.QueryRow(c.rebind(query), tableID).Scan(&taken)
+	return taken, err
+}
+
+// ListSeatingTables returns every table in number order with the parties and
+// attendees seated at it.
+func (c Client) ListSeatingTables() ([]SeatingTable, error) {
+	query := `
+    SELECT
+        t.id,
+        t.number,
+        t.name,
+        t.capacity,
+        t.created_at,
+        ` + seatsTakenExpr + `
+    FROM seating_tables t
+    LEFT JOIN seat_assignments sa ON (sa.table_id = t.id)
+    LEFT JOIN rsvps r ON (r.id = sa.rsvp_id)
+    GROUP BY t.id, t.number, t.name, t.capacity, t.created_at
+    ORDER BY t.number ASC`
+
+	rows, err := c.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []SeatingTable{}
+	byID := map[uuid.UUID]int{}
+	for rows.Next() {
+		var table SeatingTable
+		if err := rows.Scan(
+			&table.ID,
+			&table.Number,
+			&table.Name,
+			&table.Capacity,
+			&table.CreatedAt,
+			&table.SeatsTaken,
+		); err != nil {
+			return nil, err
+		}
+		byID[table.ID] = len(tables)
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	assignments, err := c.listSeatAssignments()
+	if err != nil {
+		return nil, err
+	}
+	for _, assignment := range assignments {
+		if i, ok := byID[assignment.TableID]; ok {
+			tables[i].Assignments = append(tables[i].Assignments, assignment)
+		}
+	}
+
+	return tables, nil
+}
+
+func (c Client) listSeatAssignments() ([]SeatAssignment, error) {
+	query := `
+    SELECT
+        sa.id,
+        sa.table_id,
+        sa.rsvp_id,
+        sa.attendee_id,
+        r.guest_name,
+        COALESCE(ra.name, ''),
+        COALESCE(gc.name, ''),
+        CASE WHEN sa.attendee_id IS NULL THEN r.number_of_guests ELSE 1 END
+    FROM seat_assignments sa
+    JOIN rsvps r ON (r.id = sa.rsvp_id)
+    LEFT JOIN rsvp_attendees ra ON (ra.id = sa.attendee_id)
+    LEFT JOIN guest_categories gc ON (gc.id = r.category_id)
+    ORDER BY r.guest_name ASC, r.id ASC, ra.position ASC`
+
+	rows, err := c.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []SeatAssignment
+	for rows.Next() {
+		var assignment SeatAssignment
+		if err := rows.Scan(
+			&assignment.ID,
+			&assignment.TableID,
+			&assignment.RSVPID,
+			&assignment.AttendeeID,
+			&assignment.GuestName,
+			&assignment.AttendeeName,
+			&assignment.CategoryName,
+			&assignment.Seats,
+		); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, rows.Err()
+}
+
+// UpdateSeatingTable saves a table's number, name and capacity. Capacity cannot
+// drop below the seats already taken (ErrCapacityBelowSeated).
+func (c Client) UpdateSeatingTable(table SeatingTable) (SeatingTable, error) {
+	err := c.withTx(func(tx *sql.Tx) error {
+		existing, err := c.getSeatingTable(tx, table.ID, true)
+		if err != nil {
+			return err
+		}
+		if existing.ID == uuid.Nil {
+			return ErrTableNotFound
+		}
+		if table.Capacity < existing.SeatsTaken {
+			return ErrCapacityBelowSeated
+		}
+
+		query := `
+    UPDATE seating_tables
+    SET number = ?, name = ?, capacity = ?
+    WHERE id = ?`
+		_, err = tx.Exec(c.rebind(query), table.Number, table.Name, table.Capacity, table.ID)
+		return err
+	})
+	if err != nil {
+		return SeatingTable{}, err
+	}
+
+	return c.GetSeatingTable(table.ID)
+}
+
+// DeleteSeatingTable removes a table, unseating everyone at it.
+func (c Client) DeleteSeatingTable(id uuid.UUID) error {
+	return c.withTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(c.rebind(`DELETE FROM seat_assignments WHERE table_id = ?`), id); err != nil {
+			return err
+		}
+		_, err := tx.Exec(c.rebind(`DELETE FROM seating_tables WHERE id = ?`), id)
+		return err
+	})
+}
+
+// AssignRSVPToTable seats a whole approved party at a table, moving it from
+// wherever it or any of its attendees sat before. The table is locked while its
+// free seats are checked (ErrTableFull if the party does not fit).
+func (c Client) AssignRSVPToTable(tableID, rsvpID uuid.UUID) error {
+	return c.withTx(func(tx *sql.Tx) error {
+		return c.assignRSVPToTable(tx, tableID, rsvpID)
+	})
+}
+
+func (c Client) assignRSVPToTable(tx *sql.Tx, tableID, rsvpID uuid.UUID) error {
+	table, rsvp, err := c.lockSeating(tx, tableID, rsvpID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.unseatRSVP(tx, rsvp.ID); err != nil {
+		return err
+	}
+	taken, err := c.seatsTaken(tx, table.ID)
+	if err != nil {
+		return err
+	}
+	if taken+rsvp.NumberOfGuests > table.Capacity {
+		return ErrTableFull
+	}
+
+	return c.insertSeatAssignment(tx, table.ID, rsvp.ID, uuid.NullUUID{})
+}
+
+// AssignAttendeeToTable seats one named attendee at a table, moving them from
+// any table they sat at before. Their party must be approved and not already
+// seated as a whole (ErrPartySeatedTogether).
+func (c Client) AssignAttendeeToTable(tableID, attendeeID uuid.UUID) error {
+	return c.withTx(func(tx *sql.Tx) error {
+		var rsvpID uuid.UUID
+		err := tx.QueryRow(c.rebind(`SELECT rsvp_id FROM rsvp_attendees WHERE id = ?`), attendeeID).Scan(&rsvpID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrAttendeeNotFound
+			}
+			return err
+		}
+
+		table, rsvp, err := c.lockSeating(tx, tableID, rsvpID)
+		if err != nil {
+			return err
+		}
+
+		var together int
+		query := `SELECT COUNT(*) FROM seat_assignments WHERE rsvp_id = ? AND attendee_id IS NULL`
+		if err := tx.QueryRow(c.rebind(query), rsvp.ID).Scan(&together); err != nil {
+			return err
+		}
+		if together > 0 {
+			return ErrPartySeatedTogether
+		}
+
+		if _, err := tx.Exec(c.rebind(`DELETE FROM seat_assignments WHERE attendee_id = ?`), attendeeID); err != nil {
+			return err
+		}
+		taken, err := c.seatsTaken(tx, table.ID)
+		if err != nil {
+			return err
+		}
+		if taken+1 > table.Capacity {
+			return ErrTableFull
+		}
+
+		return c.insertSeatAssignment(tx, table.ID, rsvp.ID, uuid.NullUUID{UUID: attendeeID, Valid: true})
+	})
+}
+
+// lockSeating locks an RSVP and a table for a seat change, checking that both
+// exist and that the RSVP is approved. Like every transaction that locks both,
+// it takes the RSVP first and the table second, so that a seat change cannot
+// deadlock with a party resize (see checkSeatedPartyGrowth).
+func (c Client) lockSeating(tx *sql.Tx, tableID, rsvpID uuid.UUID) (SeatingTable, RSVP, error) {
+	rsvp, err := c.getRSVP(tx, rsvpID, true)
+	if err != nil {
+		return SeatingTable{}, RSVP{}, err
+	}
+	if rsvp.ID == uuid.Nil {
+		return SeatingTable{}, RSVP{}, errors.New("no RSVP found with the given ID to seat")
+	}
+	if rsvp.Status != "APPROVED" {
+		return SeatingTable{}, RSVP{}, ErrRSVPNotApproved
+	}
+
+	table, err := c.getSeatingTable(tx, tableID, true)
+	if err != nil {
+		return SeatingTable{}, RSVP{}, err
+	}
+	if table.ID == uuid.Nil {
+		return SeatingTable{}, RSVP{}, ErrTableNotFound
+	}
+
+	return table, rsvp, nil
+}
+
+func (c Client) insertSeatAssignment(q querier, tableID, rsvpID uuid.UUID, attendeeID uuid.NullUUID) error {
+	query := `
+    INSERT INTO seat_assignments (id, table_id, rsvp_id, attendee_id)
+    VALUES (?, ?, ?, ?)`
+	_, err := q.Exec(c.rebind(query), uuid.New(), tableID, rsvpID, attendeeID)
+	return err
+}
+
+// UnseatRSVP removes a party and all of its attendees from their tables.
+func (c Client) UnseatRSVP(rsvpID uuid.UUID) error {
+	return c.unseatRSVP(c.DB, rsvpID)
+}
+
+func (c Client) unseatRSVP(q querier, rsvpID uuid.UUID) error {
+	_, err := q.Exec(c.rebind(`DELETE FROM seat_assignments WHERE rsvp_id = ?`), rsvpID)
+	return err
+}
+
+// UnseatAttendee removes one individually seated attendee from their table.
+func (c Client) UnseatAttendee(attendeeID uuid.UUID) error {
+	_, err := c.DB.Exec(c.rebind(`DELETE FROM seat_assignments WHERE attendee_id = ?`), attendeeID)
+	return err
+}
+
+// GetRSVPTableNumbers returns the numbers of the tables an RSVP's party is
+// seated at, in order. A party split across tables has several.
+func (c Client) GetRSVPTableNumbers(rsvpID uuid.UUID) ([]int, error) {
+	query := `
+    SELECT DISTINCT t.number
+    FROM seat_assignments sa
+    JOIN seating_tables t ON (t.id = sa.table_id)
+    WHERE sa.rsvp_id = ?
+    ORDER BY t.number ASC`
+
+	rows, err := c.DB.Query(c.rebind(query), rsvpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var numbers []int
+	for rows.Next() {
+		var number int
+		if err := rows.Scan(&number); err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, number)
+	}
+
+	return numbers, rows.Err()
+}
+
+// checkSeatedPartyGrowth returns ErrTableFull if a party seated together could
+// not grow by extra guests at its current table. The RSVP must already be locked
+// in tx; the table is locked after it, in the order lockSeating uses.
+func (c Client) checkSeatedPartyGrowth(tx *sql.Tx, rsvpID uuid.UUID, extra int) error {
+	var tableID uuid.UUID
+	query := `SELECT table_id FROM seat_assignments WHERE rsvp_id = ? AND attendee_id IS NULL`
+	err := tx.QueryRow(c.rebind(query), rsvpID).Scan(&tableID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	table, err := c.getSeatingTable(tx, tableID, true)
+	if err != nil {
+		return err
+	}
+	if table.SeatsTaken+extra > table.Capacity {
+		return ErrTableFull
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Seating constraint kinds.
+const (
+	ConstraintMustSitWith = "MUST_SIT_WITH"
+	ConstraintKeepApart   = "KEEP_APART"
+)
+
+// SeatingConstraint asks for two parties to share a table or to be kept apart.
+type SeatingConstraint struct {
+	ID             uuid.UUID `json:"id"`
+	Kind           string    `json:"kind"`
+	RSVPID         uuid.UUID `json:"rsvp_id"`
+	OtherRSVPID    uuid.UUID `json:"other_rsvp_id"`
+	GuestName      string    `json:"guest_name"`
+	OtherGuestName string    `json:"other_guest_name"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PlanAssignment seats a whole party at a table as part of a seating plan.
+type PlanAssignment struct {
+	RSVPID  uuid.UUID `json:"rsvpId"`
+	TableID uuid.UUID `json:"tableId"`
+}
+
+// CreateSeatingConstraint records a constraint between two parties. A pair can
+// only have one constraint, whichever way round it is given.
+func (c Client) CreateSeatingConstraint(kind string, rsvpID, otherRSVPID uuid.UUID) (SeatingConstraint, error) {
+	if rsvpID == otherRSVPID {
+		return SeatingConstraint{}, errors.New("a seating constraint needs two different RSVPs")
+	}
+	if otherRSVPID.String() < rsvpID.String() {
+		rsvpID, otherRSVPID = otherRSVPID, rsvpID
+	}
+
+	id := uuid.New()
+	query := `
+    INSERT INTO seating_constraints (id, kind, rsvp_id, other_rsvp_id)
+    VALUES (?, ?, ?, ?)`
+	if _, err := c.DB.Exec(c.rebind(query), id, kind, rsvpID, otherRSVPID); err != nil {
+		return SeatingConstraint{}, err
+	}
+
+	constraints, err := c.listSeatingConstraints("sc.id = ?", id)
+	if err != nil || len(constraints) == 0 {
+		return SeatingConstraint{}, err
+	}
+	return constraints[0], nil
+}
+
+// ListSeatingConstraints returns every seating constraint, newest first.
+func (c Client) ListSeatingConstraints() ([]SeatingConstraint, error) {
+	return c.listSeatingConstraints("", nil)
+}
+
+func (c Client) listSeatingConstraints(where string, arg any) ([]SeatingConstraint, error) {
+	query := `
+    SELECT
+        sc.id,
+        sc.kind,
+        sc.rsvp_id,
+        sc.other_rsvp_id,
+        r.guest_name,
+        o.guest_name,
+        sc.created_at
+    FROM seating_constraints sc
+    JOIN rsvps r ON (r.id = sc.rsvp_id)
+    JOIN rsvps o ON (o.id = sc.other_rsvp_id)`
+	var args []any
+	if where != "" {
+		query += "\n    WHERE " + where
+		args = append(args, arg)
+	}
+	query += "\n    ORDER BY sc.created_at DESC, sc.id ASC"
+
+	rows, err := c.DB.Query(c.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	constraints := []SeatingConstraint{}
+	for rows.Next() {
+		var constraint SeatingConstraint
+		if err := rows.Scan(
+			&constraint.ID,
+			&constraint.Kind,
+			&constraint.RSVPID,
+			&constraint.OtherRSVPID,
+			&constraint.GuestName,
+			&constraint.OtherGuestName,
+			&constraint.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	return constraints, rows.Err()
+}
+
+// DeleteSeatingConstraint removes a seating constraint.
+func (c Client) DeleteSeatingConstraint(id uuid.UUID) error {
+	_, err := c.DB.Exec(c.rebind(`DELETE FROM seating_constraints WHERE id = ?`), id)
+	return err
+}
+
+// ApplySeatingPlan seats whole parties at tables in one transaction, so either
+// the whole plan is applied or none of it is. With replaceExisting every current
+// seat assignment is cleared first; otherwise parties in the plan move and
+// everyone else stays put. Each table's capacity is checked as parties are added.
+func (c Client) ApplySeatingPlan(assignments []PlanAssignment, replaceExisting bool) error {
+	return c.withTx(func(tx *sql.Tx) error {
+		// Every party is locked before any table, as lockSeating does for one,
+		// and in ID order so that two plans cannot deadlock with each other.
+		rsvpIDs := make([]uuid.UUID, len(assignments))
+		for i, assignment := range assignments {
+			rsvpIDs[i] = assignment.RSVPID
+		}
+		for _, id := range uniqueIDs(rsvpIDs) {
+			if _, err := c.getRSVP(tx, id, true); err != nil {
+				return err
+			}
+		}
+
+		if replaceExisting {
+			if _, err := tx.Exec(`DELETE FROM seat_assignments`); err != nil {
+				return err
+			}
+		} else {
+			// Lift every moving party first so swaps between full tables work.
+			for _, assignment := range assignments {
+				if err := c.unseatRSVP(tx, assignment.RSVPID); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, assignment := range assignments {
+			if err := c.assignRSVPToTable(tx, assignment.TableID, assignment.RSVPID); err != nil {
+				return fmt.Errorf("seating RSVP %s: %w", assignment.RSVPID, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"github.com/google/uuid"
+)
+
+// CategoryStats summarises the RSVPs held by a single guest category.
+type CategoryStats struct {
+	CategoryID        uuid.UUID `json:"category_id"`
+	Name              string    `json:"name"`
+	Side              string    `json:"side"`
+	DefaultCategory   bool      `json:"default_category"`
+	Capacity          int       `json:"capacity"`
+	ApprovedParties   int       `json:"approved_parties"`
+	ApprovedGuests    int       `json:"approved_guests"`
+	PendingParties    int       `json:"pending_parties"`
+	PendingGuests     int       `json:"pending_guests"`
+	WaitlistedParties int       `json:"waitlisted_parties"`
+	WaitlistedGuests  int       `json:"waitlisted_guests"`
+	RejectedParties   int       `json:"rejected_parties"`
+	// RemainingSeats is nil for default categories, which are not capacity-limited.
+	RemainingSeats *int `json:"remaining_seats"`
+}
+
+// SideStats rolls category statistics up to one side of the couple. Capacity and
+// RemainingSeats only cover capacity-limited categories.
+type SideStats struct {
+	Side              string `json:"side"`
+	Capacity          int    `json:"capacity"`
+	ApprovedParties   int    `json:"approved_parties"`
+	ApprovedGuests    int    `json:"approved_guests"`
+	PendingParties    int    `json:"pending_parties"`
+	PendingGuests     int    `json:"pending_guests"`
+	WaitlistedParties int    `json:"waitlisted_parties"`
+	WaitlistedGuests  int    `json:"waitlisted_guests"`
+	RejectedParties   int    `json:"rejected_parties"`
+	RemainingSeats    int    `json:"remaining_seats"`
+}
+
+// DailySubmissions counts the RSVPs submitted on one calendar day (UTC).
+type DailySubmissions struct {
+	Date        string `json:"date"`
+	Submissions int    `json:"submissions"`
+	Guests      int    `json:"guests"`
+}
+
+// DashboardStats is the aggregate view shown on the couple's dashboard.
+type DashboardStats struct {
+	Categories  []CategoryStats    `json:"categories"`
+	Sides       []SideStats        `json:"sides"`
+	Submissions []DailySubmissions `json:"submissions_by_day"`
+	CheckIn     CheckInStats       `json:"check_in"`
+}
+
+// GetDashboardStats computes per-category, per-side, daily and check-in totals
+// for the guests of one side, the way every other admin view is scoped.
+func (c Client) GetDashboardStats(side string) (DashboardStats, error) {
+	categories, err := c.getCategoryStats(side)
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	submissions, err := c.getDailySubmissions(side)
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	checkIn, err := c.getCheckInStats(side)
+	if err != nil {
+		return DashboardStats{}, err
+	}
+
+	return DashboardStats{
+		Categories:  categories,
+		Sides:       rollUpSides(categories),
+		Submissions: submissions,
+		CheckIn:     checkIn,
+	}, nil
+}
+
+func (c Client) getCategoryStats(side string) ([]CategoryStats, error) {
+	query := `
+    SELECT
+        gc.id,
+        gc.name,
+        gc.side,
+        gc.default_category,
+        gc.max_guests,
+        COUNT(CASE WHEN r.status = 'APPROVED' THEN 1 END),
+        COALESCE(SUM(CASE WHEN r.status = 'APPROVED' THEN r.number_of_guests END), 0),
+        COUNT(CASE WHEN r.status = 'PENDING' THEN 1 END),
+        COALESCE(SUM(CASE WHEN r.status = 'PENDING' THEN r.number_of_guests END), 0),
+        COUNT(CASE WHEN r.status = 'WAITLISTED' THEN 1 END),
+        COALESCE(SUM(CASE WHEN r.status = 'WAITLISTED' THEN r.number_of_guests END), 0),
+        COUNT(CASE WHEN r.status = 'REJECTED' THEN 1 END)
+    FROM guest_categories gc
+    LEFT JOIN rsvps r ON (r.category_id = gc.id)
+    WHERE gc.side = ? AND gc.deleted_at IS NULL
+    GROUP BY gc.id, gc.name, gc.side, gc.default_category, gc.max_guests
+    ORDER BY gc.side ASC, gc.name ASC`
+
+	rows, err := c.DB.Query(c.rebind(query), side)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []CategoryStats{}
+	for rows.Next() {
+		var stats CategoryStats
+		if err := rows.Scan(
+			&stats.CategoryID,
+			&stats.Name,
+			&stats.Side,
+			&stats.DefaultCategory,
+			&stats.Capacity,
+			&stats.ApprovedParties,
+			&stats.ApprovedGuests,
+			&stats.PendingParties,
+			&stats.PendingGuests,
+			&stats.WaitlistedParties,
+			&stats.WaitlistedGuests,
+			&stats.RejectedParties,
+		); err != nil {
+			return nil, err
+		}
+		if !stats.DefaultCategory {
+			remaining := max(stats.Capacity-stats.ApprovedGuests, 0)
+			stats.RemainingSeats = &remaining
+		}
+		categories = append(categories, stats)
+	}
+
+	return categories, rows.Err()
+}
+
+func (c Client) getDailySubmissions(side string) ([]DailySubmissions, error) {
+	day := "date(rsvps.submitted_at)"
+	if c.dialect == dialectPostgres {
+		day = "to_char(rsvps.submitted_at, 'YYYY-MM-DD')"
+	}
+
+	query := `
+    SELECT ` + day + ` AS day, COUNT(*), COALESCE(SUM(rsvps.number_of_guests), 0)
+    FROM rsvps
+    JOIN guest_categories gc ON (gc.id = rsvps.category_id)
+    WHERE gc.side = ?
+    GROUP BY day
+    ORDER BY day ASC`
+
+	rows, err := c.DB.Query(c.rebind(query), side)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []DailySubmissions{}
+	for rows.Next() {
+		var d DailySubmissions
+		if err := rows.Scan(&d.Date, &d.Submissions, &d.Guests); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
+
+// rollUpSides sums category statistics per side, keeping the categories' side order.
+func rollUpSides(categories []CategoryStats) []SideStats {
+	sides := []SideStats{}
+	index := map[string]int{}
+	for _, cat := range categories {
+		i, ok := index[cat.Side]
+		if !ok {
+			i = len(sides)
+			index[cat.Side] = i
+			sides = append(sides, SideStats{Side: cat.Side})
+		}
+		side := &sides[i]
+		side.ApprovedParties += cat.ApprovedParties
+		side.ApprovedGuests += cat.ApprovedGuests
+		side.PendingParties += cat.PendingParties
+		side.PendingGuests += cat.PendingGuests
+		side.WaitlistedParties += cat.WaitlistedParties
+		side.WaitlistedGuests += cat.WaitlistedGuests
+		side.RejectedParties += cat.RejectedParties
+		if cat.RemainingSeats != nil {
+			side.Capacity += cat.Capacity
+			side.RemainingSeats += *cat.RemainingSeats
+		}
+	}
+	return sides
+}
@@ -0,0 +1,369 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// WaitlistEntry is a waitlisted RSVP together with its place in its category's queue.
+type WaitlistEntry struct {
+	RSVP
+	Position     int    `json:"position"`
+	CategoryName string `json:"category_name"`
+}
+
+// ListWaitlist returns every waitlisted RSVP for a side, grouped by category and
+// ordered by submission time so that Position reflects the promotion order.
+func (c Client) ListWaitlist(side string) ([]WaitlistEntry, error) {
+	query := `
+    SELECT
+        rsvps.id,
+        guest_name,
+        number_of_guests,
+        email,
+        phone,
+        status,
+        category_id,
+        submitted_at,
+        gc.name
+    FROM rsvps
+    JOIN guest_categories gc ON (gc.id = rsvps.category_id)
+    WHERE rsvps.status = 'WAITLISTED' AND gc.side = ?
+    ORDER BY gc.name ASC, rsvps.submitted_at ASC, rsvps.id ASC`
+
+	rows, err := c.DB.Query(c.rebind(query), side)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := map[uuid.UUID]int{}
+	var entries []WaitlistEntry
+	for rows.Next() {
+		var entry WaitlistEntry
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.GuestName,
+			&entry.NumberOfGuests,
+			&entry.Email,
+			&entry.Phone,
+			&entry.Status,
+			&entry.CategoryID,
+			&entry.SubmittedAt,
+			&entry.CategoryName,
+		); err != nil {
+			return nil, err
+		}
+		positions[entry.CategoryID.UUID]++
+		entry.Position = positions[entry.CategoryID.UUID]
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetWaitlistPosition returns an RSVP's 1-based place in its category's waitlist,
+// or 0 if the RSVP is not waitlisted.
+func (c Client) GetWaitlistPosition(rsvp RSVP) (int, error) {
+	if rsvp.Status != "WAITLISTED" || !rsvp.CategoryID.Valid {
+		return 0, nil
+	}
+
+	query := `
+    SELECT COUNT(*)
+    FROM rsvps queued
+    JOIN rsvps me ON (me.category_id = queued.category_id)
+    WHERE me.id = ? AND queued.status = 'WAITLISTED'
+      AND (queued.submitted_at < me.submitted_at
+           OR (queued.submitted_at = me.submitted_at AND queued.id <= me.id))`
+
+	var position int
+	err := c.DB.QueryRow(c.rebind(query), rsvp.ID).Scan(&position)
+	if err != nil {
+		return 0, err
+	}
+	return position, nil
+}
+
+// promoteWaitlist approves waitlisted RSVPs in submission order while they fit in
+// the category's remaining capacity and in the capacity of the events they
+// attend. Promotion is strictly first-come-first-served: it stops at the first
+// party that does not fit rather than letting smaller, later parties jump the
+// queue. Each promoted guest's confirmation email is queued in tx. The category
+// must already be locked by the caller.
+func (c Client) promoteWaitlist(tx *sql.Tx, category GuestCategory) ([]RSVP, error) {
+	if category.DefaultCategory {
+		return nil, nil
+	}
+
+	approved, err := c.approvedGuestCount(tx, category.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+    SELECT
+        id,
+        guest_name,
+        number_of_guests,
+        email,
+        phone,
+        status,
+        category_id,
+        submitted_at
+    FROM rsvps
+    WHERE category_id = ? AND status = 'WAITLISTED'
+    ORDER BY submitted_at ASC, id ASC`
+
+	rows, err := tx.Query(c.rebind(query), category.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var waiting []RSVP
+	for rows.Next() {
+		var rsvp RSVP
+		if err := rows.Scan(
+			&rsvp.ID,
+			&rsvp.GuestName,
+			&rsvp.NumberOfGuests,
+			&rsvp.Email,
+			&rsvp.Phone,
+			&rsvp.Status,
+			&rsvp.CategoryID,
+			&rsvp.SubmittedAt,
+		); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		waiting = append(waiting, rsvp)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var promoted []RSVP
+	for _, rsvp := range waiting {
+		if approved+rsvp.NumberOfGuests > category.MaxGuests {
+			break
+		}
+		events, err := c.rsvpEventIDs(tx, rsvp.ID)
+		if err != nil {
+			return nil, err
+		}
+		fits, err := c.eventsFit(tx, events, rsvp.NumberOfGuests)
+		if err != nil {
+			return nil, err
+		}
+		if !fits {
+			break
+		}
+		if _, err := tx.Exec(c.rebind(`UPDATE rsvps SET status = 'APPROVED' WHERE id = ?`), rsvp.ID); err != nil {
+			return nil, err
+		}
+		approved += rsvp.NumberOfGuests
+		rsvp.Status = "APPROVED"
+		if err := c.queueRSVPEmail(tx, EmailRSVPConfirmed, rsvp); err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, rsvp)
+	}
+
+	return promoted, nil
+}
+
+// releaseSeats runs waitlist promotion after an RSVP gave up seats: first in
+// its category, then in every other category with waitlisted RSVPs attending
+// one of the events it left.
+func (c Client) releaseSeats(tx *sql.Tx, categoryID uuid.NullUUID, eventIDs []uuid.UUID) ([]RSVP, error) {
+	var categoryIDs []uuid.UUID
+	if categoryID.Valid {
+		categoryIDs = append(categoryIDs, categoryID.UUID)
+	}
+	if len(eventIDs) > 0 {
+		placeholders, args := inList(eventIDs)
+		query := `
+    SELECT DISTINCT rsvps.category_id
+    FROM rsvps
+    JOIN rsvp_events re ON (re.rsvp_id = rsvps.id)
+    WHERE rsvps.status = 'WAITLISTED' AND rsvps.category_id IS NOT NULL
+      AND re.event_id IN (` + placeholders + `)
+    ORDER BY rsvps.category_id`
+		waiting, err := c.queryIDs(tx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range waiting {
+			if !categoryID.Valid || id != categoryID.UUID {
+				categoryIDs = append(categoryIDs, id)
+			}
+		}
+	}
+
+	var promoted []RSVP
+	for _, id := range categoryIDs {
+		category, err := c.getCategory(tx, id, true)
+		if err != nil {
+			return nil, err
+		}
+		if category.ID == uuid.Nil {
+			continue
+		}
+
+		categoryPromoted, err := c.promoteWaitlist(tx, category)
+		if err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, categoryPromoted...)
+	}
+
+	return promoted, nil
+}
+
+// RejectRSVP marks an RSVP as REJECTED and unseats it. If it was holding approved
+// seats, the freed capacity is offered to the waitlists of its category and
+// events; the promoted RSVPs are returned. Rejection and promotion emails are
+// queued with the change.
+func (c Client) RejectRSVP(id uuid.UUID) (RSVP, []RSVP, error) {
+	var rsvp RSVP
+	var promoted []RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		var err error
+		rsvp, err = c.getRSVP(tx, id, true)
+		if err != nil {
+			return err
+		}
+		if rsvp.ID == uuid.Nil {
+			return errors.New("no RSVP found with the given ID to reject")
+		}
+
+		wasApproved := rsvp.Status == "APPROVED"
+		if _, err := tx.Exec(c.rebind(`UPDATE rsvps SET status = 'REJECTED' WHERE id = ?`), rsvp.ID); err != nil {
+			return err
+		}
+		if err := c.unseatRSVP(tx, rsvp.ID); err != nil {
+			return err
+		}
+		rsvp.Status = "REJECTED"
+		if err := c.queueRSVPEmail(tx, EmailRSVPRejected, rsvp); err != nil {
+			return err
+		}
+
+		if wasApproved {
+			events, err := c.rsvpEventIDs(tx, rsvp.ID)
+			if err != nil {
+				return err
+			}
+			promoted, err = c.releaseSeats(tx, rsvp.CategoryID, events)
+			return err
+		}
+		return err
+	})
+	if err != nil {
+		return RSVP{}, nil, err
+	}
+
+	return rsvp, promoted, nil
+}
+
+// UpdateRSVPPartySize changes an RSVP's number of guests. Growing an approved
+// party must still fit in the category (ErrCategoryFull otherwise) and in its
+// events (ErrEventFull); shrinking a party may let the head of the waitlist in,
+// and the promoted RSVPs are returned.
+// A party cannot shrink below its named attendees (ErrTooManyAttendees), grow
+// past the seats its invitee was allotted (ErrExceedsAllotment), or, when seated
+// together, outgrow its table (ErrTableFull).
+func (c Client) UpdateRSVPPartySize(id uuid.UUID, numberOfGuests int) (RSVP, []RSVP, error) {
+	var rsvp RSVP
+	var promoted []RSVP
+	err := c.withTx(func(tx *sql.Tx) error {
+		var err error
+		rsvp, err = c.getRSVP(tx, id, true)
+		if err != nil {
+			return err
+		}
+		if rsvp.ID == uuid.Nil {
+			return errors.New("no RSVP found with the given ID to update")
+		}
+
+		promoted, err = c.updateRSVPPartySize(tx, &rsvp, numberOfGuests)
+		return err
+	})
+	if err != nil {
+		return RSVP{}, nil, err
+	}
+
+	return rsvp, promoted, nil
+}
+
+// updateRSVPPartySize is UpdateRSVPPartySize for an RSVP already locked in tx.
+// rsvp is updated to the new size.
+func (c Client) updateRSVPPartySize(tx *sql.Tx, rsvp *RSVP, numberOfGuests int) ([]RSVP, error) {
+	var category GuestCategory
+	if rsvp.CategoryID.Valid {
+		var err error
+		category, err = c.getCategory(tx, rsvp.CategoryID.UUID, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	attendees, err := c.countAttendees(tx, rsvp.ID)
+	if err != nil {
+		return nil, err
+	}
+	if attendees > numberOfGuests {
+		return nil, ErrTooManyAttendees
+	}
+
+	guest, err := c.getGuest(tx, "guests.rsvp_id = ?", rsvp.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	if guest.ID != uuid.Nil && numberOfGuests > guest.AllottedSeats {
+		return nil, ErrExceedsAllotment
+	}
+
+	events, err := c.rsvpEventIDs(tx, rsvp.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	growing := numberOfGuests > rsvp.NumberOfGuests
+	if growing && rsvp.Status == "APPROVED" && category.ID != uuid.Nil && !category.DefaultCategory {
+		approved, err := c.approvedGuestCount(tx, category.ID)
+		if err != nil {
+			return nil, err
+		}
+		if approved-rsvp.NumberOfGuests+numberOfGuests > category.MaxGuests {
+			return nil, ErrCategoryFull
+		}
+	}
+	if growing && rsvp.Status == "APPROVED" {
+		fits, err := c.eventsFit(tx, events, numberOfGuests-rsvp.NumberOfGuests)
+		if err != nil {
+			return nil, err
+		}
+		if !fits {
+			return nil, ErrEventFull
+		}
+	}
+	if growing {
+		if err := c.checkSeatedPartyGrowth(tx, rsvp.ID, numberOfGuests-rsvp.NumberOfGuests); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(c.rebind(`UPDATE rsvps SET number_of_guests = ? WHERE id = ?`), numberOfGuests, rsvp.ID); err != nil {
+		return nil, err
+	}
+	rsvp.NumberOfGuests = numberOfGuests
+
+	if growing {
+		return nil, nil
+	}
+	return c.releaseSeats(tx, rsvp.CategoryID, events)
+}
@@ -0,0 +1,449 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"reflect"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+// ErrUnknownTemplate is returned for a template name that is not built in.
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// maxSubjectLength caps a custom subject line.
+const maxSubjectLength = 200
+
+// templateSpec describes a built-in email template.
+type templateSpec struct {
+	// name is also the file name, without .html, under templates/.
+	name        string
+	description string
+	// subject is the default subject line, itself a template.
+	subject string
+	// sample is example data for previews and validation. Its type is the data
+	// the template is rendered with, so it also decides which fields are allowed.
+	sample any
+	// required are the fields a custom body must use, because the email is
+	// pointless without them.
+	required []string
+	// sampleEvents are listed in the footer of previews, for emails that list
+	// the guest's events.
+	sampleEvents     []EventDetails
+	showLocationLink bool
+	// editable is false for emails that belong to no couple, such as the admin
+	// sign-in code.
+	editable bool
+}
+
+var sampleEvents = []EventDetails{
+	{Name: "Traditional Ceremony", Venue: "Nelos Place", When: "Sat, Nov 22, 10:00 AM", Address: "Ikeja, Lagos"},
+	{Name: "Reception", Venue: "Nelos Place", When: "Sat, Nov 22, 4:00 PM", Address: "Ikeja, Lagos"},
+}
+
+var sampleRSVPs = []RSVPSummary{
+	{GuestName: "Ada Lovelace", NumberOfGuests: 2, Status: "PENDING", SubmittedAt: "Nov 1, 9:30 AM"},
+	{GuestName: "Alan Turing", NumberOfGuests: 1, Status: "APPROVED", SubmittedAt: "Nov 1, 2:15 PM"},
+}
+
+// templateSpecs lists every built-in template. VerifyTemplates checks that each
+// one exists and renders.
+var templateSpecs = []templateSpec{
+	{
+		name:        "otp",
+		description: "Sign-in code for the admin dashboard",
+		subject:     "Your Sign-In Code for BTS Wedding Admin",
+		sample:      otpData{OTP: "123456"},
+	},
+	{
+		name:        "rsvp_pending",
+		description: "Sent to a guest whose RSVP is waiting for review",
+		subject:     "We've Received Your RSVP!",
+		sample:      guestData{GuestName: "Ada Lovelace"},
+		editable:    true,
+	},
+	{
+		name:        "rsvp_waitlisted",
+		description: "Sent to a guest whose invitation was full",
+		subject:     "You're on the Waitlist",
+		sample:      rsvpWaitlistedData{GuestName: "Ada Lovelace", Position: 3},
+		editable:    true,
+	},
+	{
+		name:        "rsvp_confirmed",
+		description: "Sent with the entry QR code when an RSVP is approved",
+		subject:     "Your RSVP is Confirmed - See you there!",
+		sample: rsvpConfirmedData{
+			GuestName:      "Ada Lovelace",
+			NumberOfGuests: 2,
+			// Previews are shown in a browser, which cannot resolve the cid: URL
+			// the sent email uses.
+			QRCode:      template.URL("data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mP8z8DwHwAFBQIAX8jx0gAAAABJRU5ErkJggg=="),
+			Phone:       "08031234567",
+			TableNumber: "7",
+			HasCalendar: true,
+		},
+		required:         []string{"QRCode"},
+		sampleEvents:     sampleEvents,
+		showLocationLink: true,
+		editable:         true,
+	},
+	{
+		name:        "rsvp_rejected",
+		description: "Sent when an RSVP is declined",
+		subject:     "An Update on Your RSVP",
+		sample:      guestData{GuestName: "Ada Lovelace"},
+		editable:    true,
+	},
+	{
+		name:        "rsvp_manage",
+		description: "One-time code a guest uses to change their RSVP",
+		subject:     "Manage Your RSVP",
+		sample:      rsvpManageData{GuestName: "Ada Lovelace", OTP: "123456", Link: "https://example.com/rsvp/manage"},
+		required:    []string{"OTP"},
+		editable:    true,
+	},
+	{
+		name:        "rsvp_reminder",
+		description: "Reminder sent to approved guests ahead of the wedding",
+		subject:     "See You {{.Countdown}}!",
+		sample: SendRSVPReminderParam{
+			GuestName:      "Ada Lovelace",
+			NumberOfGuests: 2,
+			Countdown:      "in 7 Days",
+			Events:         sampleEvents,
+		},
+		sampleEvents: sampleEvents,
+		editable:     true,
+	},
+	{
+		name:        "rsvp_changed",
+		description: "Tells the couple that a guest changed or cancelled their RSVP",
+		subject:     "RSVP Update: {{.GuestName}}",
+		sample: SendRSVPChangedParam{
+			CoupleName:     "Diamond",
+			GuestName:      "Ada Lovelace",
+			Change:         "changed their party size to 3",
+			Status:         "APPROVED",
+			NumberOfGuests: 3,
+		},
+		editable: true,
+	},
+	{
+		name:        "rsvp_nudge",
+		description: "Reminds the couple of RSVPs that have been pending for a while",
+		subject:     "{{len .RSVPs}} RSVP(s) Awaiting Your Review",
+		sample:      SendPendingNudgeParam{CoupleName: "Diamond", RSVPs: sampleRSVPs},
+		editable:    true,
+	},
+	{
+		name:        "rsvp_digest",
+		description: "The couple's daily summary of new RSVPs",
+		subject:     "Daily RSVP Digest: {{len .RSVPs}} New",
+		sample:      SendDailyDigestParam{CoupleName: "Diamond", Date: "Saturday, November 1", RSVPs: sampleRSVPs, Pending: 1},
+		editable:    true,
+	},
+}
+
+func lookupSpec(name string) (templateSpec, bool) {
+	for _, spec := range templateSpecs {
+		if spec.name == name {
+			return spec, true
+		}
+	}
+	return templateSpec{}, false
+}
+
+// defaultBody reads the built-in body of the template.
+func (spec templateSpec) defaultBody() (string, error) {
+	body, err := templateFS.ReadFile("templates/" + spec.name + ".html")
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// TemplateOverride is a couple's own subject and body for a built-in template.
+type TemplateOverride struct {
+	Subject string
+	Body    string
+}
+
+// Overrides maps template names to the wording that replaces them.
+type Overrides map[string]TemplateOverride
+
+// WithOverrides returns a Mailer that renders the given templates with the
+// overriding wording instead of the built-in one.
+func (m Mailer) WithOverrides(overrides Overrides) Mailer {
+	m.overrides = overrides
+	return m
+}
+
+// TemplateInfo describes a template a couple can edit, with its built-in wording.
+type TemplateInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Subject     string `json:"subject"`
+	Body        string `json:"body"`
+	// Fields are the fields the template may use, e.g. "GuestName" or, inside
+	// {{range .RSVPs}}, "RSVPs[].GuestName".
+	Fields []string `json:"fields"`
+	// Required are the fields a custom body must use.
+	Required []string `json:"required,omitempty"`
+}
+
+// EditableTemplates lists the templates a couple can override.
+func EditableTemplates() ([]TemplateInfo, error) {
+	var infos []TemplateInfo
+	for _, spec := range templateSpecs {
+		if !spec.editable {
+			continue
+		}
+		info, err := spec.info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// EditableTemplate describes the named template, returning ErrUnknownTemplate
+// if there is no such template a couple can edit.
+func EditableTemplate(name string) (TemplateInfo, error) {
+	spec, ok := lookupSpec(name)
+	if !ok || !spec.editable {
+		return TemplateInfo{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	return spec.info()
+}
+
+func (spec templateSpec) info() (TemplateInfo, error) {
+	body, err := spec.defaultBody()
+	if err != nil {
+		return TemplateInfo{}, err
+	}
+	return TemplateInfo{
+		Name:        spec.name,
+		Description: spec.description,
+		Subject:     spec.subject,
+		Body:        body,
+		Fields:      fieldPaths(reflect.TypeOf(spec.sample), ""),
+		Required:    spec.required,
+	}, nil
+}
+
+// fieldPaths lists the exported fields of a struct type, descending into
+// slices of structs.
+func fieldPaths(t reflect.Type, prefix string) []string {
+	var paths []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		path := prefix + field.Name
+		paths = append(paths, path)
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct {
+			paths = append(paths, fieldPaths(field.Type.Elem(), path+"[].")...)
+		}
+	}
+	return paths
+}
+
+// fieldNames is the set of field names a template for spec may reference.
+func (spec templateSpec) fieldNames() map[string]bool {
+	names := map[string]bool{}
+	for _, path := range fieldPaths(reflect.TypeOf(spec.sample), "") {
+		names[path[strings.LastIndex(path, ".")+1:]] = true
+	}
+	return names
+}
+
+// ValidateTemplate checks that a custom subject and body for the named
+// template parse, use only the fields the template is given and every field it
+// requires, and render with sample data.
+func ValidateTemplate(name, subject, body string) error {
+	spec, ok := lookupSpec(name)
+	if !ok || !spec.editable {
+		return fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	if strings.TrimSpace(subject) == "" {
+		return errors.New("subject is required")
+	}
+	if len(subject) > maxSubjectLength {
+		return fmt.Errorf("subject must be at most %d characters", maxSubjectLength)
+	}
+	if strings.TrimSpace(body) == "" {
+		return errors.New("body is required")
+	}
+
+	subjectTmpl, err := texttemplate.New("subject").Parse(subject)
+	if err != nil {
+		return fmt.Errorf("subject: %w", err)
+	}
+	bodyTmpl, err := template.New(name).Parse(body)
+	if err != nil {
+		return fmt.Errorf("body: %w", err)
+	}
+	if len(bodyTmpl.Templates()) > 1 || len(subjectTmpl.Templates()) > 1 {
+		return errors.New("templates may not define other templates")
+	}
+
+	allowed := spec.fieldNames()
+	if err := checkFields(subjectTmpl.Tree.Root, allowed, map[string]bool{}); err != nil {
+		return fmt.Errorf("subject: %w", err)
+	}
+	used := map[string]bool{}
+	if err := checkFields(bodyTmpl.Tree.Root, allowed, used); err != nil {
+		return fmt.Errorf("body: %w", err)
+	}
+	for _, field := range spec.required {
+		if !used[field] {
+			return fmt.Errorf("body: must use {{.%s}}", field)
+		}
+	}
+
+	if _, _, err := render(spec, subject, body, spec.sample, spec.sampleEvents); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkFields walks a parsed template and returns an error for any field that
+// is not in allowed, or for a {{template}} call. The fields it finds are added
+// to used.
+func checkFields(node parse.Node, allowed, used map[string]bool) error {
+	checkIdents := func(idents []string) error {
+		for _, ident := range idents {
+			if !allowed[ident] {
+				return fmt.Errorf("unknown field %q", ident)
+			}
+			used[ident] = true
+		}
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkFields(child, allowed, used); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return checkFields(n.Pipe, allowed, used)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			if err := checkFields(cmd, allowed, used); err != nil {
+				return err
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if err := checkFields(arg, allowed, used); err != nil {
+				return err
+			}
+		}
+	case *parse.FieldNode:
+		return checkIdents(n.Ident)
+	case *parse.VariableNode:
+		// $x.Field: the first identifier is the variable itself.
+		return checkIdents(n.Ident[1:])
+	case *parse.ChainNode:
+		if err := checkFields(n.Node, allowed, used); err != nil {
+			return err
+		}
+		return checkIdents(n.Field)
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode, allowed, used)
+	case *parse.RangeNode:
+		return checkBranch(&n.BranchNode, allowed, used)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode, allowed, used)
+	case *parse.TemplateNode:
+		return errors.New("templates may not include other templates")
+	}
+	return nil
+}
+
+func checkBranch(n *parse.BranchNode, allowed, used map[string]bool) error {
+	for _, node := range []parse.Node{n.Pipe, n.List, n.ElseList} {
+		if err := checkFields(node, allowed, used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Preview is a template rendered with sample data.
+type Preview struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	// Text is the plain-text alternative sent along with the HTML.
+	Text string `json:"text"`
+}
+
+// PreviewTemplate renders a subject and body for the named template with
+// sample data, after validating them.
+func PreviewTemplate(name, subject, body string) (Preview, error) {
+	if err := ValidateTemplate(name, subject, body); err != nil {
+		return Preview{}, err
+	}
+
+	spec, _ := lookupSpec(name)
+	renderedSubject, html, err := render(spec, subject, body, spec.sample, spec.sampleEvents)
+	if err != nil {
+		return Preview{}, err
+	}
+	text, err := plainText(html)
+	if err != nil {
+		return Preview{}, err
+	}
+	return Preview{Subject: renderedSubject, HTML: html, Text: text}, nil
+}
+
+// VerifyTemplates checks that every built-in template exists and renders with
+// sample data, so that a missing or broken template stops the server at
+// startup instead of failing when the email is first sent.
+func VerifyTemplates() error {
+	var names []string
+	for _, spec := range templateSpecs {
+		if slices.Contains(names, spec.name) {
+			return fmt.Errorf("template %s is listed twice", spec.name)
+		}
+		names = append(names, spec.name)
+
+		body, err := spec.defaultBody()
+		if err != nil {
+			return fmt.Errorf("template %s: %w", spec.name, err)
+		}
+		if _, _, err := render(spec, spec.subject, body, spec.sample, spec.sampleEvents); err != nil {
+			return fmt.Errorf("template %s: %w", spec.name, err)
+		}
+	}
+
+	// Every file should belong to a template, so that a misspelt file name is
+	// caught rather than silently ignored.
+	files, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), ".html")
+		if name != "layout" && !slices.Contains(names, name) {
+			return fmt.Errorf("template file %s is not used by any email", file.Name())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package email
+
+import "testing"
+
+func TestValidateTemplateRequiredFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		tmpl    string
+		subject string
+		body    string
+		valid   bool
+	}{
+		{"confirmation with the QR code", "rsvp_confirmed", "Confirmed", `<p>Hi {{.GuestName}}</p><img src="{{.QRCode}}">`, true},
+		{"QR code inside with", "rsvp_confirmed", "Confirmed", `{{with .QRCode}}<img src="{{.}}">{{end}}`, true},
+		{"confirmation without the QR code", "rsvp_confirmed", "Confirmed", `<p>See you there, {{.GuestName}}!</p>`, false},
+		{"QR code only in the subject", "rsvp_confirmed", "{{.QRCode}}", `<p>See you there!</p>`, false},
+		{"manage code", "rsvp_manage", "Manage your RSVP", `<p>Your code is {{.OTP}}</p>`, true},
+		{"manage link without the code", "rsvp_manage", "Manage your RSVP", `<p><a href="{{.Link}}">Manage your RSVP</a></p>`, false},
+		{"template without required fields", "rsvp_rejected", "Sorry", `<p>Sorry</p>`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTemplate(tt.tmpl, tt.subject, tt.body)
+			if (err == nil) != tt.valid {
+				t.Errorf("ValidateTemplate = %v, want valid %t", err, tt.valid)
+			}
+		})
+	}
+}
@@ -1,41 +1,44 @@
 package email
 
 import (
-	"errors"
-
-	"github.com/resend/resend-go/v2"
+	"net/mail"
 )
 
+// Mailer renders the wedding's emails and hands them to a Transport.
 type Mailer struct {
-	client   *resend.Client
-	fromName string
-	fromAddr string
+	transport Transport
+	from      mail.Address
+	// overrides replace built-in templates with a couple's wording.
+	overrides Overrides
 }
 
-func NewMailer(apiKey, fromName, fromAddr string) Mailer {
-	client := resend.NewClient(apiKey)
+func NewMailer(transport Transport, fromName, fromAddr string) Mailer {
 	return Mailer{
-		client:   client,
-		fromName: fromName,
-		fromAddr: fromAddr,
+		transport: transport,
+		from:      mail.Address{Name: fromName, Address: fromAddr},
 	}
 }
-func (m Mailer) Send(to, subject, htmlBody string) error {
-	params := &resend.SendEmailRequest{
-		From:    m.fromName + "<" + m.fromAddr + ">",
-		To:      []string{to},
-		Html:    htmlBody,
-		Subject: subject,
-	}
-
-	sent, err := m.client.Emails.Send(params)
-	if err != nil {
-		return err
-	}
 
-	if sent.Id == "" {
-		return errors.New("failed to send email via Resend")
-	}
+// Attachment is a file sent along with an email.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	// ContentID, when set, sends the attachment inline so that the HTML body
+	// can show it with a cid: URL, such as <img src="cid:ticket-qr">.
+	ContentID string
+}
 
-	return nil
+// Send delivers an email and returns the message ID the transport gave it, which
+// is how delivery webhooks refer to the email later. textBody is the plain-text
+// alternative to htmlBody and may be empty.
+func (m Mailer) Send(to, subject, htmlBody, textBody string, attachments ...Attachment) (string, error) {
+	return m.transport.Send(Message{
+		From:        m.from,
+		To:          []string{to},
+		Subject:     subject,
+		HTML:        htmlBody,
+		Text:        textBody,
+		Attachments: attachments,
+	})
 }
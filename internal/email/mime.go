@@ -0,0 +1,188 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// base64LineLength is the longest encoded line allowed in a MIME body (RFC 2045).
+const base64LineLength = 76
+
+var headerLineBreaks = strings.NewReplacer("\r", "", "\n", "")
+
+// Encode renders msg as an RFC 5322 message for transports that deliver raw
+// email. The plain-text and HTML bodies are sent as multipart/alternative,
+// inline images as multipart/related with the HTML, and other attachments as
+// multipart/mixed; a message with only an HTML body is not multipart at all.
+func (msg Message) Encode() ([]byte, error) {
+	var b bytes.Buffer
+
+	// Line breaks are dropped from header values so that text such as a guest's
+	// name in the subject cannot add headers of its own.
+	header := func(name, value string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, headerLineBreaks.Replace(value))
+	}
+	header("From", msg.From.String())
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	msg.ensureID()
+	header("Message-ID", "<"+msg.ID+">")
+	header("MIME-Version", "1.0")
+
+	body := msg.mimeBody()
+	for _, name := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if value := body.header.Get(name); value != "" {
+			header(name, value)
+		}
+	}
+	b.WriteString("\r\n")
+
+	if err := body.writeContent(&b); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// mimePart is one part of a message body: content with its headers or, when
+// parts is set, a multipart container of other parts.
+type mimePart struct {
+	header   textproto.MIMEHeader
+	content  []byte
+	encode   func(w io.Writer, content []byte) error
+	boundary string
+	parts    []mimePart
+}
+
+// mimeBody arranges msg's bodies and attachments into parts.
+func (msg Message) mimeBody() mimePart {
+	related := []mimePart{textPart("text/html", msg.HTML)}
+	var mixed []mimePart
+	for _, attachment := range msg.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part := mimePart{
+			header: textproto.MIMEHeader{
+				"Content-Type":              {contentType},
+				"Content-Transfer-Encoding": {"base64"},
+			},
+			content: attachment.Content,
+			encode:  writeBase64,
+		}
+		if attachment.ContentID != "" {
+			part.header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.Filename}))
+			part.header.Set("Content-ID", "<"+attachment.ContentID+">")
+			related = append(related, part)
+			continue
+		}
+		part.header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+		mixed = append(mixed, part)
+	}
+
+	var alternative []mimePart
+	if msg.Text != "" {
+		alternative = append(alternative, textPart("text/plain", msg.Text))
+	}
+	// Clients show the last alternative they understand, so HTML goes last.
+	alternative = append(alternative, multipartOf("related", related))
+	return multipartOf("mixed", append([]mimePart{multipartOf("alternative", alternative)}, mixed...))
+}
+
+func textPart(contentType, text string) mimePart {
+	return mimePart{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		content: []byte(text),
+		encode:  writeQuotedPrintable,
+	}
+}
+
+// multipartOf wraps parts in a multipart container of the given subtype, or
+// returns the part itself when there is only one.
+func multipartOf(subtype string, parts []mimePart) mimePart {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	// The boundary is picked now because the Content-Type header names it.
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	return mimePart{
+		header: textproto.MIMEHeader{
+			"Content-Type": {mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": boundary})},
+		},
+		boundary: boundary,
+		parts:    parts,
+	}
+}
+
+// writeContent writes the part's content, without its headers.
+func (p mimePart) writeContent(w io.Writer) error {
+	if p.parts == nil {
+		return p.encode(w, p.content)
+	}
+
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(p.boundary); err != nil {
+		return err
+	}
+	for _, part := range p.parts {
+		pw, err := mw.CreatePart(part.header)
+		if err != nil {
+			return err
+		}
+		if err := part.writeContent(pw); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func writeQuotedPrintable(w io.Writer, content []byte) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write(content); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+// writeBase64 writes content base64-encoded in lines of base64LineLength.
+func writeBase64(w io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 0 {
+		n := min(len(encoded), base64LineLength)
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:n]); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
+
+// ensureID gives msg a unique Message-ID in the sender's domain if it has none.
+func (msg *Message) ensureID() {
+	if msg.ID != "" {
+		return
+	}
+
+	domain := "localhost"
+	if at := strings.LastIndex(msg.From.Address, "@"); at >= 0 {
+		domain = msg.From.Address[at+1:]
+	}
+
+	random := make([]byte, 16)
+	rand.Read(random)
+	msg.ID = hex.EncodeToString(random) + "@" + domain
+}
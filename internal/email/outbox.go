@@ -0,0 +1,78 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileTransport writes each message to its own .eml file instead of sending
+// it, so that mail can be inspected during local development.
+type FileTransport struct {
+	dir string
+}
+
+// NewFileTransport writes messages into dir, creating it if needed.
+func NewFileTransport(dir string) (FileTransport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return FileTransport{}, err
+	}
+	return FileTransport{dir: dir}, nil
+}
+
+func (t FileTransport) Send(msg Message) (string, error) {
+	msg.ensureID()
+	body, err := msg.Encode()
+	if err != nil {
+		return "", err
+	}
+
+	// Names sort in the order messages were sent.
+	random := make([]byte, 4)
+	rand.Read(random)
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + hex.EncodeToString(random) + ".eml"
+
+	if err := os.WriteFile(filepath.Join(t.dir, name), body, 0o644); err != nil {
+		return "", err
+	}
+	return msg.ID, nil
+}
+
+// MemoryTransport keeps sent messages in memory, for tests.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Send(msg Message) (string, error) {
+	msg.ensureID()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = append(t.messages, msg)
+	return msg.ID, nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]Message(nil), t.messages...)
+}
+
+// Reset forgets every message sent so far.
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = nil
+}
@@ -0,0 +1,46 @@
+package email
+
+import (
+	"errors"
+
+	"github.com/resend/resend-go/v2"
+)
+
+// ResendTransport sends messages through the Resend API.
+type ResendTransport struct {
+	client *resend.Client
+}
+
+func NewResendTransport(apiKey string) ResendTransport {
+	return ResendTransport{client: resend.NewClient(apiKey)}
+}
+
+// Send returns the ID Resend assigned to the message, which its webhooks use.
+func (t ResendTransport) Send(msg Message) (string, error) {
+	params := &resend.SendEmailRequest{
+		From:    msg.From.String(),
+		To:      msg.To,
+		Html:    msg.HTML,
+		Text:    msg.Text,
+		Subject: msg.Subject,
+	}
+	for _, attachment := range msg.Attachments {
+		params.Attachments = append(params.Attachments, &resend.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Content:     attachment.Content,
+			ContentId:   attachment.ContentID,
+		})
+	}
+
+	sent, err := t.client.Emails.Send(params)
+	if err != nil {
+		return "", err
+	}
+
+	if sent.Id == "" {
+		return "", errors.New("failed to send email via Resend")
+	}
+
+	return sent.Id, nil
+}
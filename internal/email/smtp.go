@@ -0,0 +1,41 @@
+package email
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+)
+
+// SMTPTransport sends messages through an SMTP server, upgrading to TLS when
+// the server offers STARTTLS.
+type SMTPTransport struct {
+	addr string
+	auth smtp.Auth
+}
+
+// NewSMTPTransport sends through the server at addr (host:port). Without a
+// username, mail is sent unauthenticated.
+func NewSMTPTransport(addr, username, password string) (SMTPTransport, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return SMTPTransport{}, fmt.Errorf("invalid SMTP address %q: %w", addr, err)
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return SMTPTransport{addr: addr, auth: auth}, nil
+}
+
+func (t SMTPTransport) Send(msg Message) (string, error) {
+	msg.ensureID()
+	body, err := msg.Encode()
+	if err != nil {
+		return "", err
+	}
+	if err := smtp.SendMail(t.addr, t.auth, msg.From.Address, msg.To, body); err != nil {
+		return "", err
+	}
+	return msg.ID, nil
+}
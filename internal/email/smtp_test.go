@@ -0,0 +1,166 @@
+package email
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+// smtpSession is what the stub server was told during one connection.
+type smtpSession struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPStub runs a minimal SMTP server on a local port that accepts one
+// message and reports it on the returned channel.
+func startSMTPStub(t *testing.T) (string, <-chan smtpSession) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		reply("220 stub ESMTP")
+
+		var session smtpSession
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					session.data = data.String()
+					reply("250 queued")
+					continue
+				}
+				// Undo dot-stuffing (RFC 5321 section 4.5.2).
+				data.WriteString(strings.TrimPrefix(line, "."))
+				continue
+			}
+
+			command := strings.TrimSpace(line)
+			switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+			case "EHLO":
+				reply("250-stub")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				reply("235 authenticated")
+			case "MAIL":
+				session.from = command[len("MAIL FROM:"):]
+				reply("250 ok")
+			case "RCPT":
+				session.to = append(session.to, command[len("RCPT TO:"):])
+				reply("250 ok")
+			case "DATA":
+				inData = true
+				reply("354 end with .")
+			case "QUIT":
+				reply("221 bye")
+				sessions <- session
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), sessions
+}
+
+func TestSMTPTransportSend(t *testing.T) {
+	addr, sessions := startSMTPStub(t)
+	transport, err := NewTransport(TransportConfig{Driver: "smtp", SMTPAddr: addr, SMTPUsername: "user", SMTPPassword: "secret"})
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	mailer := NewMailer(transport, "Diamond & Babatunde", "rsvp@example.com")
+
+	calendar := []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n")
+	id, err := mailer.Send("guest@example.com", "Héllo, Ada", "<p>See you there</p>", "See you there",
+		Attachment{Filename: "ticket.png", ContentType: "image/png", Content: []byte{0x89, 'P', 'N', 'G'}, ContentID: "ticket-qr"},
+		Attachment{Filename: "wedding.ics", ContentType: "text/calendar", Content: calendar},
+	)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	session := <-sessions
+	if session.from != "<rsvp@example.com>" {
+		t.Errorf("MAIL FROM = %s, want <rsvp@example.com>", session.from)
+	}
+	if len(session.to) != 1 || session.to[0] != "<guest@example.com>" {
+		t.Errorf("RCPT TO = %v, want [<guest@example.com>]", session.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(session.data))
+	if err != nil {
+		t.Fatalf("parsing DATA: %v", err)
+	}
+	if got := msg.Header.Get("Message-ID"); got != "<"+id+">" {
+		t.Errorf("Message-ID = %s, want <%s>", got, id)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Héllo, Ada" {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, "Héllo, Ada")
+	}
+	if got := msg.Header.Get("To"); got != "guest@example.com" {
+		t.Errorf("To = %s, want guest@example.com", got)
+	}
+
+	// mixed(alternative(text, related(html, image)), calendar)
+	var parts []string
+	var walk func(r io.Reader, contentType string)
+	walk = func(r io.Reader, contentType string) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatalf("Content-Type %q: %v", contentType, err)
+		}
+		parts = append(parts, mediaType)
+		if !strings.HasPrefix(mediaType, "multipart/") {
+			return
+		}
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("reading %s: %v", mediaType, err)
+			}
+			if cid := part.Header.Get("Content-ID"); cid != "" && cid != "<ticket-qr>" {
+				t.Errorf("Content-ID = %s, want <ticket-qr>", cid)
+			}
+			walk(part, part.Header.Get("Content-Type"))
+		}
+	}
+	walk(msg.Body, msg.Header.Get("Content-Type"))
+
+	want := []string{"multipart/mixed", "multipart/alternative", "text/plain", "multipart/related", "text/html", "image/png", "text/calendar"}
+	if strings.Join(parts, " ") != strings.Join(want, " ") {
+		t.Errorf("parts = %v, want %v", parts, want)
+	}
+}
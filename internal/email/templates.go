@@ -3,9 +3,9 @@ package email
 import (
 	"bytes"
 	"embed"
-	"encoding/base64"
 	"fmt"
 	"html/template"
+	texttemplate "text/template"
 
 	"github.com/skip2/go-qrcode"
 )
@@ -13,146 +13,252 @@ import (
 //go:embed templates/*.html
 var templateFS embed.FS
 
+// qrCodeContentID is the Content-ID the ticket's QR code is attached inline
+// under. Many clients, Gmail included, block images in data: URLs.
+const qrCodeContentID = "ticket-qr"
+
 type SendRSVPConfirmedParam struct {
 	GuestName      string
 	NumberOfGuests int
-	RSVPID         string
+	TicketToken    string
 	Phone          string
+	// TableNumber is where the party is seated, e.g. "7" or "3, 5"; empty if not seated yet.
+	TableNumber string
+	// Events are the events the party attends. When empty the layout falls back
+	// to the wedding's single venue link.
+	Events []EventDetails
+	// Calendar is an iCalendar file of the party's events, attached when set.
+	Calendar []byte
 }
 
-// SendRSVPConfirmed sends the confirmation email with a unique QR code.
-func (m Mailer) SendRSVPConfirmed(to string, param SendRSVPConfirmedParam) error {
-	subject := "Your RSVP is Confirmed - See you there!"
+// EventDetails describes one event in an email's footer.
+type EventDetails struct {
+	Name    string
+	Venue   string
+	When    string // already formatted in the wedding's time zone
+	Address string
+	MapURL  string
+}
 
-	qrData := fmt.Sprintf(`{"rsvpID":"%s","guestName":"%s","phone":"%s"}`, param.RSVPID, param.GuestName, param.Phone)
+// rsvpConfirmedData is what the rsvp_confirmed template is rendered with.
+type rsvpConfirmedData struct {
+	GuestName      string
+	NumberOfGuests int
+	QRCode         template.URL
+	Phone          string
+	TableNumber    string
+	HasCalendar    bool
+}
 
+// SendRSVPConfirmed sends the confirmation email with a QR code carrying the
+// guest's signed ticket and, if given, a calendar file of their events.
+func (m Mailer) SendRSVPConfirmed(to string, param SendRSVPConfirmedParam) (string, error) {
 	var png []byte
-	png, err := qrcode.Encode(qrData, qrcode.Medium, 256)
+	png, err := qrcode.Encode(param.TicketToken, qrcode.Medium, 256)
 	if err != nil {
-		return fmt.Errorf("failed to generate QR code: %w", err)
+		return "", fmt.Errorf("failed to generate QR code: %w", err)
 	}
 
-	qrCodeBase64 := base64.StdEncoding.EncodeToString(png)
-	qrCodeDataURL := "data:image/png;base64," + qrCodeBase64
-
-	data := struct {
-		GuestName      string
-		NumberOfGuests int
-		QRCode         template.URL
-		Phone          string
-	}{
+	data := rsvpConfirmedData{
 		GuestName:      param.GuestName,
 		NumberOfGuests: param.NumberOfGuests,
-		QRCode:         template.URL(qrCodeDataURL),
+		QRCode:         template.URL("cid:" + qrCodeContentID),
 		Phone:          param.Phone,
+		TableNumber:    param.TableNumber,
+		HasCalendar:    len(param.Calendar) > 0,
 	}
 
-	// Parse the specific content template first
-	contentTmpl, err := template.New("rsvp_confirmed.html").ParseFS(templateFS, "templates/rsvp_confirmed.html")
-	if err != nil {
-		return err
+	attachments := []Attachment{{
+		Filename:    "ticket-qr.png",
+		ContentType: "image/png",
+		Content:     png,
+		ContentID:   qrCodeContentID,
+	}}
+	if len(param.Calendar) > 0 {
+		attachments = append(attachments, Attachment{
+			Filename:    "wedding.ics",
+			ContentType: "text/calendar",
+			Content:     param.Calendar,
+		})
 	}
 
-	var contentBody bytes.Buffer
-	if err := contentTmpl.Execute(&contentBody, data); err != nil {
-		return err
-	}
+	return m.sendTemplate(to, "rsvp_confirmed", data, param.Events, attachments...)
+}
 
-	// Now parse the main layout and inject the content
-	layoutData := struct {
-		Body             template.HTML
-		ShowLocationLink bool
-	}{
-		Body:             template.HTML(contentBody.String()),
-		ShowLocationLink: true,
-	}
+// SendLoginOTP sends the one-time password for admin login using the main layout.
+func (m Mailer) SendLoginOTP(to, otp string) (string, error) {
+	return m.sendTemplate(to, "otp", otpData{OTP: otp}, nil)
+}
 
-	layoutTmpl, err := template.New("layout.html").ParseFS(templateFS, "templates/layout.html")
-	if err != nil {
-		return err
-	}
+type otpData struct {
+	OTP string
+}
 
-	var finalBody bytes.Buffer
-	if err := layoutTmpl.Execute(&finalBody, layoutData); err != nil {
-		return err
-	}
+// guestData is what templates that only greet the guest are rendered with.
+type guestData struct {
+	GuestName string
+}
 
-	// Send the final, assembled email
-	return m.Send(to, subject, finalBody.String())
+// SendRSVPReceived notifies a guest that their RSVP is pending, using the main layout.
+func (m Mailer) SendRSVPReceived(to, guestName string) (string, error) {
+	return m.sendTemplate(to, "rsvp_pending", guestData{GuestName: guestName}, nil)
 }
 
-// SendLoginOTP sends the one-time password for admin login using the main layout.
-func (m Mailer) SendLoginOTP(to, otp string) error {
-	subject := "Your Sign-In Code for BTS Wedding Admin"
-	data := struct {
-		OTP string
-	}{OTP: otp}
-
-	// The 'body' here is the final, fully-rendered HTML
-	body, err := m.parseLayout("otp.html", data)
-	if err != nil {
-		return err
-	}
-	return m.Send(to, subject, body)
+type rsvpWaitlistedData struct {
+	GuestName string
+	Position  int
 }
 
-// SendRSVPReceived notifies a guest that their RSVP is pending, using the main layout.
-func (m Mailer) SendRSVPReceived(to, guestName string) error {
-	subject := "We've Received Your RSVP!"
-	data := struct {
-		GuestName string
-	}{GuestName: guestName}
+// SendRSVPWaitlisted tells a guest their invitation is full and where they stand in the queue.
+func (m Mailer) SendRSVPWaitlisted(to, guestName string, position int) (string, error) {
+	return m.sendTemplate(to, "rsvp_waitlisted", rsvpWaitlistedData{GuestName: guestName, Position: position}, nil)
+}
 
-	body, err := m.parseLayout("rsvp_pending.html", data)
-	if err != nil {
-		return err
-	}
-	return m.Send(to, subject, body)
+type rsvpManageData struct {
+	GuestName string
+	OTP       string
+	Link      string
+}
+
+// SendRSVPManageLink sends a guest the one-time code (and link, if configured) for managing their RSVP.
+func (m Mailer) SendRSVPManageLink(to, guestName, otp, link string) (string, error) {
+	return m.sendTemplate(to, "rsvp_manage", rsvpManageData{GuestName: guestName, OTP: otp, Link: link}, nil)
+}
+
+type SendRSVPChangedParam struct {
+	CoupleName     string
+	GuestName      string
+	Change         string
+	Status         string
+	NumberOfGuests int
+}
+
+// SendRSVPChanged tells the couple that a guest changed or cancelled their RSVP.
+func (m Mailer) SendRSVPChanged(to string, param SendRSVPChangedParam) (string, error) {
+	return m.sendTemplate(to, "rsvp_changed", param, nil)
 }
 
 // SendRSVPRejected notifies a guest that their RSVP was rejected, using the main layout.
-func (m Mailer) SendRSVPRejected(to, guestName string) error {
-	subject := "An Update on Your RSVP"
-	data := struct {
-		GuestName        string
-		ShowLocationLink bool
-	}{GuestName: guestName}
+func (m Mailer) SendRSVPRejected(to, guestName string) (string, error) {
+	return m.sendTemplate(to, "rsvp_rejected", guestData{GuestName: guestName}, nil)
+}
+
+type SendRSVPReminderParam struct {
+	GuestName      string
+	NumberOfGuests int
+	// Countdown says when the wedding is, e.g. "Tomorrow" or "in 7 Days".
+	Countdown string
+	Events    []EventDetails
+}
+
+// SendRSVPReminder reminds an approved guest that the wedding is coming up.
+func (m Mailer) SendRSVPReminder(to string, param SendRSVPReminderParam) (string, error) {
+	return m.sendTemplate(to, "rsvp_reminder", param, param.Events)
+}
+
+// RSVPSummary is one guest's line in a digest or nudge sent to the couple.
+type RSVPSummary struct {
+	GuestName      string
+	NumberOfGuests int
+	Status         string
+	SubmittedAt    string
+}
 
-	body, err := m.parseLayout("rsvp_rejected.html", data)
+type SendPendingNudgeParam struct {
+	CoupleName string
+	RSVPs      []RSVPSummary
+}
+
+// SendPendingNudge reminds the couple of RSVPs that have been pending for a while.
+func (m Mailer) SendPendingNudge(to string, param SendPendingNudgeParam) (string, error) {
+	return m.sendTemplate(to, "rsvp_nudge", param, nil)
+}
+
+type SendDailyDigestParam struct {
+	CoupleName string
+	// Date is the day the digest is for, already formatted.
+	Date  string
+	RSVPs []RSVPSummary
+	// Pending counts every RSVP still awaiting review, not just the new ones.
+	Pending int
+}
+
+// SendDailyDigest summarises the couple's new RSVPs from the past day.
+func (m Mailer) SendDailyDigest(to string, param SendDailyDigestParam) (string, error) {
+	return m.sendTemplate(to, "rsvp_digest", param, nil)
+}
+
+// sendTemplate renders the named template, using the couple's wording if the
+// Mailer has an override for it, and sends the result with a plain-text
+// alternative generated from it.
+func (m Mailer) sendTemplate(to, name string, data any, events []EventDetails, attachments ...Attachment) (string, error) {
+	spec, ok := lookupSpec(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	subject, body := spec.subject, ""
+	if override, ok := m.overrides[name]; ok {
+		subject, body = override.Subject, override.Body
+	} else {
+		source, err := spec.defaultBody()
+		if err != nil {
+			return "", err
+		}
+		body = source
+	}
+
+	renderedSubject, html, err := render(spec, subject, body, data, events)
+	if err != nil {
+		return "", fmt.Errorf("rendering %s: %w", name, err)
+	}
+	text, err := plainText(html)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("rendering %s as text: %w", name, err)
 	}
-	return m.Send(to, subject, body)
+	return m.Send(to, renderedSubject, html, text, attachments...)
 }
 
-// parseLayout is the new helper function that injects content into the main layout.
-func (m Mailer) parseLayout(contentFile string, data interface{}) (string, error) {
-	contentTmpl, err := template.New(contentFile).ParseFS(templateFS, "templates/"+contentFile)
+// render executes a subject and body written for spec with data, and wraps the
+// body in the main layout.
+func render(spec templateSpec, subject, body string, data any, events []EventDetails) (string, string, error) {
+	subjectTmpl, err := texttemplate.New("subject").Parse(subject)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	var renderedSubject bytes.Buffer
+	if err := subjectTmpl.Execute(&renderedSubject, data); err != nil {
+		return "", "", err
 	}
 
+	contentTmpl, err := template.New(spec.name).Parse(body)
+	if err != nil {
+		return "", "", err
+	}
 	var contentBody bytes.Buffer
 	if err := contentTmpl.Execute(&contentBody, data); err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	layoutTmpl, err := template.New("layout.html").ParseFS(templateFS, "templates/layout.html")
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	layoutData := struct {
 		Body             template.HTML
 		ShowLocationLink bool
+		Events           []EventDetails
 	}{
-		Body: template.HTML(contentBody.String()),
+		Body:             template.HTML(contentBody.String()),
+		ShowLocationLink: spec.showLocationLink,
+		Events:           events,
 	}
 
 	var finalBody bytes.Buffer
 	if err := layoutTmpl.Execute(&finalBody, layoutData); err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return finalBody.String(), nil
+	return renderedSubject.String(), finalBody.String(), nil
 }
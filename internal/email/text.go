@@ -0,0 +1,117 @@
+package email
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// blankLines matches runs of blank lines, which plainText shortens to one.
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	spaces     = regexp.MustCompile(`\s+`)
+)
+
+// plainText turns a rendered email into the text/plain alternative sent along
+// with it: paragraphs and rows are kept on their own lines, links are followed
+// by their address, and images are replaced by their alt text.
+func plainText(body string) (string, error) {
+	var b strings.Builder
+	// lineBreak ends the current line; paragraph also leaves a blank line.
+	lineBreak := func() { b.WriteString("\n") }
+	paragraph := func() { b.WriteString("\n\n") }
+	// links holds the address of each <a> being written, to print after its text.
+	var links []string
+	// skip counts open elements whose text is not shown, such as <style>.
+	skip := 0
+	firstCell := true
+
+	z := html.NewTokenizer(strings.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return "", err
+			}
+			text := strings.TrimSpace(blankLines.ReplaceAllString(trimLines(b.String()), "\n\n"))
+			return text + "\n", nil
+
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			b.WriteString(spaces.ReplaceAllString(string(z.Text()), " "))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			attrs := map[string]string{}
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = z.TagAttr()
+				attrs[string(key)] = string(value)
+			}
+
+			switch atom.Lookup(name) {
+			case atom.Head, atom.Style, atom.Script, atom.Title:
+				skip++
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.P, atom.Table, atom.Ul, atom.Ol:
+				paragraph()
+			case atom.Div, atom.Br:
+				lineBreak()
+			case atom.Tr:
+				lineBreak()
+				firstCell = true
+			case atom.Td, atom.Th:
+				if !firstCell {
+					b.WriteString(" | ")
+				}
+				firstCell = false
+			case atom.Li:
+				b.WriteString("\n- ")
+			case atom.A:
+				links = append(links, attrs["href"])
+			case atom.Img:
+				if alt := attrs["alt"]; alt != "" {
+					b.WriteString("[" + alt + "]")
+				}
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Head, atom.Style, atom.Script, atom.Title:
+				if skip > 0 {
+					skip--
+				}
+			case atom.H1, atom.H2, atom.H3, atom.H4, atom.P, atom.Table, atom.Ul, atom.Ol:
+				paragraph()
+			case atom.Div:
+				lineBreak()
+			case atom.A:
+				if len(links) == 0 {
+					continue
+				}
+				href := links[len(links)-1]
+				links = links[:len(links)-1]
+				// Inline images and in-page anchors mean nothing in plain text.
+				if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "cid:") {
+					b.WriteString(" (" + href + ")")
+				}
+			}
+		}
+	}
+}
+
+// trimLines removes the spaces left at either end of each line and collapses
+// those between words, which text split across elements leaves behind.
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,71 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+)
+
+// Message is a rendered email ready to be delivered.
+type Message struct {
+	// ID is the Message-ID, without angle brackets. Transports that build the
+	// raw message generate one when it is empty.
+	ID      string
+	From    mail.Address
+	To      []string
+	Subject string
+	HTML    string
+	// Text is the plain-text alternative to HTML, for clients that do not
+	// show HTML. It is optional.
+	Text        string
+	Attachments []Attachment
+}
+
+// Transport delivers rendered messages, for example through an email API, an
+// SMTP server or to disk. Send returns the ID the message was sent under.
+type Transport interface {
+	Send(msg Message) (string, error)
+}
+
+// TransportConfig selects and configures a Transport for NewTransport.
+type TransportConfig struct {
+	// Driver is one of "resend" (the default), "smtp", "file" or "memory".
+	Driver string
+
+	ResendAPIKey string
+
+	// SMTPAddr is the server's host:port. SMTPUsername and SMTPPassword are
+	// optional; without them mail is sent unauthenticated.
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// OutboxDir is where the file driver writes messages.
+	OutboxDir string
+}
+
+// NewTransport builds the Transport named by cfg.Driver, checking that the
+// settings it needs are present.
+func NewTransport(cfg TransportConfig) (Transport, error) {
+	switch cfg.Driver {
+	case "", "resend":
+		if cfg.ResendAPIKey == "" {
+			return nil, errors.New("the resend mail transport needs an API key")
+		}
+		return NewResendTransport(cfg.ResendAPIKey), nil
+	case "smtp":
+		if cfg.SMTPAddr == "" {
+			return nil, errors.New("the smtp mail transport needs a server address")
+		}
+		return NewSMTPTransport(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword)
+	case "file":
+		if cfg.OutboxDir == "" {
+			return nil, errors.New("the file mail transport needs an outbox directory")
+		}
+		return NewFileTransport(cfg.OutboxDir)
+	case "memory":
+		return NewMemoryTransport(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q (use resend, smtp, file or memory)", cfg.Driver)
+	}
+}
@@ -0,0 +1,108 @@
+package email
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWebhookVerifierVerify(t *testing.T) {
+	verifier, err := NewWebhookVerifier("whsec_" + base64.StdEncoding.EncodeToString([]byte("signing secret")))
+	if err != nil {
+		t.Fatalf("NewWebhookVerifier: %v", err)
+	}
+	other, err := NewWebhookVerifier(base64.StdEncoding.EncodeToString([]byte("rolled secret")))
+	if err != nil {
+		t.Fatalf("NewWebhookVerifier: %v", err)
+	}
+
+	sentAt := time.Unix(1760000000, 0)
+	body := []byte(`{"type":"email.delivered","data":{"email_id":"msg_1"}}`)
+
+	tests := []struct {
+		name   string
+		header func() http.Header
+		body   []byte
+		now    time.Time
+		valid  bool
+	}{
+		{
+			name:   "round trip",
+			header: func() http.Header { return verifier.Sign("msg_1", body, sentAt) },
+			body:   body,
+			now:    sentAt.Add(time.Minute),
+			valid:  true,
+		},
+		{
+			name:   "tampered body",
+			header: func() http.Header { return verifier.Sign("msg_1", body, sentAt) },
+			body:   []byte(`{"type":"email.bounced","data":{"email_id":"msg_1"}}`),
+			now:    sentAt,
+		},
+		{
+			name:   "stale timestamp",
+			header: func() http.Header { return verifier.Sign("msg_1", body, sentAt) },
+			body:   body,
+			now:    sentAt.Add(webhookTolerance + time.Second),
+		},
+		{
+			name:   "timestamp from the future",
+			header: func() http.Header { return verifier.Sign("msg_1", body, sentAt) },
+			body:   body,
+			now:    sentAt.Add(-webhookTolerance - time.Second),
+		},
+		{
+			name:   "signed with another secret",
+			header: func() http.Header { return other.Sign("msg_1", body, sentAt) },
+			body:   body,
+			now:    sentAt,
+		},
+		{
+			name: "one good signature among several",
+			header: func() http.Header {
+				header := verifier.Sign("msg_1", body, sentAt)
+				good := header.Get("svix-signature")
+				stale := other.Sign("msg_1", body, sentAt).Get("svix-signature")
+				header.Set("svix-signature", "v2,unknown "+stale+" "+good)
+				return header
+			},
+			body:  body,
+			now:   sentAt,
+			valid: true,
+		},
+		{
+			name: "good signature under another version",
+			header: func() http.Header {
+				header := verifier.Sign("msg_1", body, sentAt)
+				header.Set("svix-signature", "v2"+header.Get("svix-signature")[len("v1"):])
+				return header
+			},
+			body: body,
+			now:  sentAt,
+		},
+		{
+			name: "missing id",
+			header: func() http.Header {
+				header := verifier.Sign("msg_1", body, sentAt)
+				header.Del("svix-id")
+				return header
+			},
+			body: body,
+			now:  sentAt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifier.Verify(tt.header(), tt.body, tt.now)
+			switch {
+			case tt.valid && err != nil:
+				t.Errorf("Verify = %v, want nil", err)
+			case !tt.valid && !errors.Is(err, ErrInvalidWebhookSignature):
+				t.Errorf("Verify = %v, want %v", err, ErrInvalidWebhookSignature)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+// Package seating suggests reception table plans. It works on plain parties,
+// tables and constraints so it can be used without a database.
+package seating
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Constraint kinds the couple can set between two parties.
+const (
+	MustSitWith = "MUST_SIT_WITH"
+	KeepApart   = "KEEP_APART"
+)
+
+// Reasons a party can be left out of a plan.
+const (
+	ReasonConflictingConstraints = "must sit with a party it is also kept apart from"
+	ReasonPinnedToSeveralTables  = "must sit with parties already seated at different tables"
+	ReasonNoTableFits            = "no table has enough free seats without breaking a keep-apart constraint"
+)
+
+// Party is a group of guests who should sit together, such as an RSVP.
+type Party struct {
+	ID uuid.UUID
+	// Size is the number of seats the party takes.
+	Size int
+	// Group is usually the guest category; parties of a group are seated near
+	// each other when possible.
+	Group uuid.UUID
+	Side  string
+}
+
+// Table is a table the plan may fill. Seated lists parties already sitting there,
+// which the plan keeps; their sizes count against Capacity.
+type Table struct {
+	ID       uuid.UUID
+	Capacity int
+	Seated   []Party
+}
+
+// Constraint asks for two parties to share a table (MustSitWith) or not (KeepApart).
+type Constraint struct {
+	Kind string
+	A, B uuid.UUID
+}
+
+// Assignment places one party at one table.
+type Assignment struct {
+	PartyID uuid.UUID
+	TableID uuid.UUID
+}
+
+// Unplaced is a party the plan could not seat, and why.
+type Unplaced struct {
+	PartyID uuid.UUID
+	Reason  string
+}
+
+// Plan is a suggested seating. Parties already seated at a table are not repeated.
+type Plan struct {
+	Assignments []Assignment
+	Unplaced    []Unplaced
+}
+
+// cluster is a set of parties joined by MustSitWith constraints that must be
+// placed at one table.
+type cluster struct {
+	parties []Party
+	size    int
+	group   uuid.UUID
+	side    string
+	// pinned is the table a cluster member already sits at, if any.
+	pinned    *tableState
+	conflict  string
+	firstSeen int
+}
+
+type tableState struct {
+	Table
+	order   int
+	free    int
+	parties map[uuid.UUID]bool
+	groups  map[uuid.UUID]bool
+	sides   map[string]bool
+}
+
+// Suggest plans seats for parties at tables without exceeding any table's
+// capacity. Parties joined by MustSitWith constraints are placed together and
+// never at a table with a party they are kept apart from. Clusters are placed
+// largest first, group by group, preferring tables that already hold their
+// group, then their side, then empty tables, and the tightest fit within each
+// of those. Parties that cannot be placed are reported in Plan.Unplaced.
+func Suggest(tables []Table, parties []Party, constraints []Constraint) Plan {
+	states := make([]*tableState, len(tables))
+	seatedAt := map[uuid.UUID][]*tableState{}
+	for i, table := range tables {
+		state := &tableState{
+			Table:   table,
+			order:   i,
+			free:    table.Capacity,
+			parties: map[uuid.UUID]bool{},
+			groups:  map[uuid.UUID]bool{},
+			sides:   map[string]bool{},
+		}
+		for _, party := range table.Seated {
+			state.add(party)
+			seatedAt[party.ID] = append(seatedAt[party.ID], state)
+		}
+		states[i] = state
+	}
+
+	apart := map[uuid.UUID][]uuid.UUID{}
+	uf := newUnionFind()
+	for _, constraint := range constraints {
+		switch constraint.Kind {
+		case MustSitWith:
+			uf.union(constraint.A, constraint.B)
+		case KeepApart:
+			apart[constraint.A] = append(apart[constraint.A], constraint.B)
+			apart[constraint.B] = append(apart[constraint.B], constraint.A)
+		}
+	}
+
+	clusters := buildClusters(parties, uf, seatedAt, apart)
+
+	// Order groups by their total size so large categories claim tables first,
+	// then place each group's clusters largest first.
+	groupSize := map[uuid.UUID]int{}
+	for _, c := range clusters {
+		groupSize[c.group] += c.size
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		a, b := clusters[i], clusters[j]
+		if (a.pinned != nil) != (b.pinned != nil) {
+			return a.pinned != nil
+		}
+		if a.side != b.side {
+			return a.side < b.side
+		}
+		if a.group != b.group {
+			if groupSize[a.group] != groupSize[b.group] {
+				return groupSize[a.group] > groupSize[b.group]
+			}
+			return a.firstSeen < b.firstSeen
+		}
+		if a.size != b.size {
+			return a.size > b.size
+		}
+		return a.firstSeen < b.firstSeen
+	})
+
+	var plan Plan
+	for _, c := range clusters {
+		if c.conflict != "" {
+			plan.unplace(c, c.conflict)
+			continue
+		}
+
+		table := c.pickTable(states, apart)
+		if table == nil {
+			plan.unplace(c, ReasonNoTableFits)
+			continue
+		}
+		for _, party := range c.parties {
+			table.add(party)
+			plan.Assignments = append(plan.Assignments, Assignment{PartyID: party.ID, TableID: table.ID})
+		}
+	}
+
+	return plan
+}
+
+// buildClusters groups the parties to place by their MustSitWith connections,
+// noting clusters that are tied to an existing table or cannot be satisfied.
+func buildClusters(parties []Party, uf *unionFind, seatedAt map[uuid.UUID][]*tableState, apart map[uuid.UUID][]uuid.UUID) []*cluster {
+	byRoot := map[uuid.UUID]*cluster{}
+	var clusters []*cluster
+	for i, party := range parties {
+		root := uf.find(party.ID)
+		c, ok := byRoot[root]
+		if !ok {
+			c = &cluster{firstSeen: i}
+			byRoot[root] = c
+			clusters = append(clusters, c)
+		}
+		c.parties = append(c.parties, party)
+		c.size += party.Size
+	}
+
+	// Parties already seated pin their cluster to their table.
+	for id, tables := range seatedAt {
+		c, ok := byRoot[uf.find(id)]
+		if !ok {
+			continue
+		}
+		for _, table := range tables {
+			if c.pinned != nil && c.pinned != table {
+				c.conflict = ReasonPinnedToSeveralTables
+			}
+			c.pinned = table
+		}
+	}
+
+	for _, c := range clusters {
+		// The group and side of the largest party stand for the cluster.
+		largest := c.parties[0]
+		for _, party := range c.parties {
+			if party.Size > largest.Size {
+				largest = party
+			}
+		}
+		c.group, c.side = largest.Group, largest.Side
+
+		for _, party := range c.parties {
+			for _, other := range apart[party.ID] {
+				if uf.find(other) == uf.find(party.ID) {
+					c.conflict = ReasonConflictingConstraints
+				}
+			}
+		}
+	}
+
+	return clusters
+}
+
+// pickTable chooses where a cluster should sit, or returns nil if no table can
+// take it.
+func (c *cluster) pickTable(tables []*tableState, apart map[uuid.UUID][]uuid.UUID) *tableState {
+	candidates := tables
+	if c.pinned != nil {
+		candidates = []*tableState{c.pinned}
+	}
+
+	var best *tableState
+	bestTier := 0
+	for _, table := range candidates {
+		if table.free < c.size || c.keptApartFrom(table, apart) {
+			continue
+		}
+
+		tier := 1 // a table of the other side
+		switch {
+		case table.groups[c.group]:
+			tier = 4
+		case table.sides[c.side]:
+			tier = 3
+		case len(table.parties) == 0:
+			tier = 2
+		}
+
+		if best == nil || tier > bestTier || (tier == bestTier && table.free < best.free) {
+			best, bestTier = table, tier
+		}
+	}
+
+	return best
+}
+
+func (c *cluster) keptApartFrom(table *tableState, apart map[uuid.UUID][]uuid.UUID) bool {
+	for _, party := range c.parties {
+		for _, other := range apart[party.ID] {
+			if table.parties[other] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *tableState) add(party Party) {
+	t.free -= party.Size
+	t.parties[party.ID] = true
+	t.groups[party.Group] = true
+	t.sides[party.Side] = true
+}
+
+func (p *Plan) unplace(c *cluster, reason string) {
+	for _, party := range c.parties {
+		p.Unplaced = append(p.Unplaced, Unplaced{PartyID: party.ID, Reason: reason})
+	}
+}
+
+// unionFind joins parties into disjoint sets.
+type unionFind struct {
+	parent map[uuid.UUID]uuid.UUID
+}
+
+func newUnionFind() *unionFind {
+	return &unionFind{parent: map[uuid.UUID]uuid.UUID{}}
+}
+
+func (u *unionFind) find(id uuid.UUID) uuid.UUID {
+	parent, ok := u.parent[id]
+	if !ok || parent == id {
+		return id
+	}
+	root := u.find(parent)
+	u.parent[id] = root
+	return root
+}
+
+func (u *unionFind) union(a, b uuid.UUID) {
+	rootA, rootB := u.find(a), u.find(b)
+	if rootA != rootB {
+		u.parent[rootA] = rootB
+	}
+}
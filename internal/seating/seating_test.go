@@ -0,0 +1,211 @@
+package seating
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// id returns a fixed ID so test cases can refer to parties and tables by number.
+func id(n byte) uuid.UUID {
+	var u uuid.UUID
+	u[15] = n
+	return u
+}
+
+func party(n byte, size int, group byte, side string) Party {
+	return Party{ID: id(n), Size: size, Group: id(100 + group), Side: side}
+}
+
+func table(n byte, capacity int, seated ...Party) Table {
+	return Table{ID: id(200 + n), Capacity: capacity, Seated: seated}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name        string
+		tables      []Table
+		parties     []Party
+		constraints []Constraint
+		// unplaced maps the parties that must be left out to the reason given.
+		unplaced map[uuid.UUID]string
+	}{
+		{
+			name:    "fills tables without exceeding capacity",
+			tables:  []Table{table(1, 8), table(2, 8)},
+			parties: []Party{party(1, 5, 1, "BRIDE"), party(2, 4, 1, "BRIDE"), party(3, 3, 2, "GROOM"), party(4, 4, 2, "GROOM")},
+		},
+		{
+			name:     "party larger than every table",
+			tables:   []Table{table(1, 4), table(2, 4)},
+			parties:  []Party{party(1, 6, 1, "BRIDE"), party(2, 2, 1, "BRIDE")},
+			unplaced: map[uuid.UUID]string{id(1): ReasonNoTableFits},
+		},
+		{
+			name:     "already seated parties count against capacity",
+			tables:   []Table{table(1, 6, party(9, 5, 1, "BRIDE"))},
+			parties:  []Party{party(1, 2, 1, "BRIDE"), party(2, 1, 1, "BRIDE")},
+			unplaced: map[uuid.UUID]string{id(1): ReasonNoTableFits},
+		},
+		{
+			name:    "must sit with keeps a cluster together",
+			tables:  []Table{table(1, 4), table(2, 6)},
+			parties: []Party{party(1, 2, 1, "BRIDE"), party(2, 2, 2, "GROOM"), party(3, 2, 3, "BRIDE"), party(4, 3, 1, "BRIDE")},
+			constraints: []Constraint{
+				{Kind: MustSitWith, A: id(1), B: id(2)},
+				{Kind: MustSitWith, A: id(2), B: id(3)},
+			},
+		},
+		{
+			name:        "cluster too large for any table",
+			tables:      []Table{table(1, 4), table(2, 4)},
+			parties:     []Party{party(1, 3, 1, "BRIDE"), party(2, 3, 1, "BRIDE")},
+			constraints: []Constraint{{Kind: MustSitWith, A: id(1), B: id(2)}},
+			unplaced:    map[uuid.UUID]string{id(1): ReasonNoTableFits, id(2): ReasonNoTableFits},
+		},
+		{
+			name:        "keep apart separates parties of one group",
+			tables:      []Table{table(1, 10), table(2, 10)},
+			parties:     []Party{party(1, 2, 1, "BRIDE"), party(2, 2, 1, "BRIDE"), party(3, 2, 1, "BRIDE")},
+			constraints: []Constraint{{Kind: KeepApart, A: id(1), B: id(2)}},
+		},
+		{
+			name:        "keep apart with an already seated party",
+			tables:      []Table{table(1, 10, party(9, 2, 1, "BRIDE")), table(2, 3)},
+			parties:     []Party{party(1, 2, 1, "BRIDE")},
+			constraints: []Constraint{{Kind: KeepApart, A: id(1), B: id(9)}},
+		},
+		{
+			name:        "keep apart leaves no table",
+			tables:      []Table{table(1, 10, party(9, 2, 1, "BRIDE"))},
+			parties:     []Party{party(1, 2, 1, "BRIDE")},
+			constraints: []Constraint{{Kind: KeepApart, A: id(1), B: id(9)}},
+			unplaced:    map[uuid.UUID]string{id(1): ReasonNoTableFits},
+		},
+		{
+			name:    "conflicting constraints",
+			tables:  []Table{table(1, 10), table(2, 10)},
+			parties: []Party{party(1, 2, 1, "BRIDE"), party(2, 2, 1, "BRIDE"), party(3, 2, 1, "BRIDE"), party(4, 1, 2, "GROOM")},
+			constraints: []Constraint{
+				{Kind: MustSitWith, A: id(1), B: id(2)},
+				{Kind: MustSitWith, A: id(2), B: id(3)},
+				{Kind: KeepApart, A: id(1), B: id(3)},
+			},
+			unplaced: map[uuid.UUID]string{
+				id(1): ReasonConflictingConstraints,
+				id(2): ReasonConflictingConstraints,
+				id(3): ReasonConflictingConstraints,
+			},
+		},
+		{
+			name:        "pinned to an already seated party's table",
+			tables:      []Table{table(1, 4, party(9, 2, 1, "BRIDE")), table(2, 10)},
+			parties:     []Party{party(1, 2, 2, "GROOM")},
+			constraints: []Constraint{{Kind: MustSitWith, A: id(1), B: id(9)}},
+		},
+		{
+			name:        "pinned table is full",
+			tables:      []Table{table(1, 3, party(9, 2, 1, "BRIDE")), table(2, 10)},
+			parties:     []Party{party(1, 2, 2, "GROOM")},
+			constraints: []Constraint{{Kind: MustSitWith, A: id(1), B: id(9)}},
+			unplaced:    map[uuid.UUID]string{id(1): ReasonNoTableFits},
+		},
+		{
+			name:    "pinned to several tables",
+			tables:  []Table{table(1, 10, party(8, 2, 1, "BRIDE")), table(2, 10, party(9, 2, 1, "BRIDE"))},
+			parties: []Party{party(1, 2, 1, "BRIDE"), party(2, 2, 1, "BRIDE")},
+			constraints: []Constraint{
+				{Kind: MustSitWith, A: id(1), B: id(8)},
+				{Kind: MustSitWith, A: id(1), B: id(9)},
+			},
+			unplaced: map[uuid.UUID]string{id(1): ReasonPinnedToSeveralTables},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := Suggest(tt.tables, tt.parties, tt.constraints)
+			checkPlan(t, tt.tables, tt.parties, tt.constraints, plan)
+
+			gotUnplaced := map[uuid.UUID]string{}
+			for _, u := range plan.Unplaced {
+				gotUnplaced[u.PartyID] = u.Reason
+			}
+			for partyID, reason := range tt.unplaced {
+				if gotUnplaced[partyID] != reason {
+					t.Errorf("party %s: unplaced reason %q, want %q", partyID, gotUnplaced[partyID], reason)
+				}
+			}
+			for partyID, reason := range gotUnplaced {
+				if _, ok := tt.unplaced[partyID]; !ok {
+					t.Errorf("party %s was not placed (%s), want it placed", partyID, reason)
+				}
+			}
+		})
+	}
+}
+
+// checkPlan verifies the rules every plan must keep: each party is placed or
+// reported exactly once, no table is over capacity, and placed parties honour
+// their constraints.
+func checkPlan(t *testing.T, tables []Table, parties []Party, constraints []Constraint, plan Plan) {
+	t.Helper()
+
+	tableOf := map[uuid.UUID]uuid.UUID{}
+	used := map[uuid.UUID]int{}
+	capacity := map[uuid.UUID]int{}
+	for _, table := range tables {
+		capacity[table.ID] = table.Capacity
+		for _, seated := range table.Seated {
+			tableOf[seated.ID] = table.ID
+			used[table.ID] += seated.Size
+		}
+	}
+
+	size := map[uuid.UUID]int{}
+	for _, party := range parties {
+		size[party.ID] = party.Size
+	}
+
+	seen := map[uuid.UUID]int{}
+	for _, a := range plan.Assignments {
+		seen[a.PartyID]++
+		if _, ok := capacity[a.TableID]; !ok {
+			t.Errorf("party %s assigned to unknown table %s", a.PartyID, a.TableID)
+		}
+		tableOf[a.PartyID] = a.TableID
+		used[a.TableID] += size[a.PartyID]
+	}
+	for _, u := range plan.Unplaced {
+		seen[u.PartyID]++
+	}
+	for _, party := range parties {
+		if seen[party.ID] != 1 {
+			t.Errorf("party %s appears %d times in the plan, want once", party.ID, seen[party.ID])
+		}
+	}
+
+	for tableID, n := range used {
+		if n > capacity[tableID] {
+			t.Errorf("table %s seats %d, more than its capacity %d", tableID, n, capacity[tableID])
+		}
+	}
+
+	for _, c := range constraints {
+		tableA, okA := tableOf[c.A]
+		tableB, okB := tableOf[c.B]
+		if !okA || !okB {
+			continue
+		}
+		switch c.Kind {
+		case MustSitWith:
+			if tableA != tableB {
+				t.Errorf("%s and %s must sit together but are at %s and %s", c.A, c.B, tableA, tableB)
+			}
+		case KeepApart:
+			if tableA == tableB {
+				t.Errorf("%s and %s must be kept apart but share %s", c.A, c.B, tableA)
+			}
+		}
+	}
+}
@@ -1,17 +1,22 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // the runtime image has no zoneinfo for EVENT_TIMEZONE
 
+	"github.com/tunedev/bts2025/server/internal/auth"
 	"github.com/tunedev/bts2025/server/internal/database"
 	"github.com/tunedev/bts2025/server/internal/email"
 	"github.com/tunedev/bts2025/server/internal/logger"
 
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
@@ -21,17 +26,38 @@ type apiConfig struct {
 	port      string
 	mailer    email.Mailer
 	logger    *slog.Logger
+	ticketKey ed25519.PrivateKey
+	eventDate time.Time
+	// eventLocation is the time zone event times are shown in to guests.
+	eventLocation *time.Location
+	siteURL       string
+	schedule      scheduleConfig
+	// outboxWake nudges the outbox worker to deliver newly queued emails.
+	outboxWake chan struct{}
+	// webhookVerifier checks delivery webhooks; nil if they are not configured.
+	webhookVerifier *email.WebhookVerifier
 }
 
 func main() {
 	godotenv.Load(".env")
 
-	pathToDB := os.Getenv("DB_PATH")
-	if pathToDB == "" {
+	dbURL := os.Getenv("DB_URL")
+	if dbURL == "" {
+		// DB_PATH predates PostgreSQL support and is still honoured for SQLite deployments.
+		dbURL = os.Getenv("DB_PATH")
+	}
+	if dbURL == "" {
 		log.Fatal("DB_URL must be set")
 	}
 
-	db, err := database.NewClient(pathToDB)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(dbURL, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	db, err := database.NewClient(dbURL)
 	if err != nil {
 		log.Fatalf("Couldn't connect to database: %v", err)
 	}
@@ -51,9 +77,40 @@ func main() {
 		log.Fatal("PORT environment variable is not set")
 	}
 
-	resendAPIKey := os.Getenv("RESEND_API_KEY")
-	if resendAPIKey == "" {
-		log.Fatal("RESEND_API_KEY environment variable is not set")
+	mailOutboxDir := os.Getenv("MAIL_OUTBOX_DIR")
+	if mailOutboxDir == "" {
+		mailOutboxDir = "mail-outbox"
+	}
+
+	// MAIL_TRANSPORT picks how email is delivered: resend (the default, which
+	// needs RESEND_API_KEY), smtp (SMTP_ADDR plus optional SMTP_USERNAME and
+	// SMTP_PASSWORD), file (writes .eml files to MAIL_OUTBOX_DIR) or memory.
+	mailTransport, err := email.NewTransport(email.TransportConfig{
+		Driver:       os.Getenv("MAIL_TRANSPORT"),
+		ResendAPIKey: os.Getenv("RESEND_API_KEY"),
+		SMTPAddr:     os.Getenv("SMTP_ADDR"),
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		OutboxDir:    mailOutboxDir,
+	})
+	if err != nil {
+		log.Fatalf("Couldn't set up the mail transport (check MAIL_TRANSPORT and its settings): %v", err)
+	}
+
+	// EMAIL_WEBHOOK_SECRET is optional; it is the signing secret of the mail
+	// provider's delivery webhook (whsec_...). Without it, the webhook endpoint
+	// refuses every request.
+	var webhookVerifier *email.WebhookVerifier
+	if secret := os.Getenv("EMAIL_WEBHOOK_SECRET"); secret != "" {
+		verifier, err := email.NewWebhookVerifier(secret)
+		if err != nil {
+			log.Fatalf("Couldn't parse EMAIL_WEBHOOK_SECRET: %v", err)
+		}
+		webhookVerifier = &verifier
+	}
+
+	if err := email.VerifyTemplates(); err != nil {
+		log.Fatalf("Email templates are broken: %v", err)
 	}
 
 	weddingFromEmail := os.Getenv("WEDDING_FROM_EMAIL")
@@ -66,15 +123,86 @@ func main() {
 		emailFromName = "noReply"
 	}
 
+	ticketSigningKey := os.Getenv("TICKET_SIGNING_KEY")
+	if ticketSigningKey == "" {
+		log.Fatal("TICKET_SIGNING_KEY environment variable is not set (generate one with `openssl rand -base64 32`)")
+	}
+	ticketKey, err := auth.ParseTicketSigningKey(ticketSigningKey)
+	if err != nil {
+		log.Fatalf("Couldn't parse TICKET_SIGNING_KEY: %v", err)
+	}
+
+	eventDateString := os.Getenv("EVENT_DATE")
+	if eventDateString == "" {
+		log.Fatal("EVENT_DATE environment variable is not set")
+	}
+	eventDate, err := time.Parse(time.DateOnly, eventDateString)
+	if err != nil {
+		log.Fatalf("EVENT_DATE must be formatted as YYYY-MM-DD: %v", err)
+	}
+
+	// EVENT_TIMEZONE is optional; it names the IANA zone (e.g. Africa/Lagos) that
+	// event start times are shown in and defaults to UTC.
+	eventLocation := time.UTC
+	if name := os.Getenv("EVENT_TIMEZONE"); name != "" {
+		eventLocation, err = time.LoadLocation(name)
+		if err != nil {
+			log.Fatalf("Couldn't load EVENT_TIMEZONE: %v", err)
+		}
+	}
+
+	// SITE_URL is optional; when set, guest emails include a one-click link to the RSVP portal.
+	siteURL := strings.TrimSuffix(os.Getenv("SITE_URL"), "/")
+
+	// REMINDER_OFFSETS, PENDING_NUDGE_AFTER and DIGEST_HOUR control the scheduled
+	// emails; each can be set to "off".
+	reminderOffsets := os.Getenv("REMINDER_OFFSETS")
+	if reminderOffsets == "" {
+		reminderOffsets = "7d,1d"
+	}
+	schedule := scheduleConfig{}
+	schedule.reminderOffsets, err = parseOffsets(reminderOffsets)
+	if err != nil {
+		log.Fatalf("Couldn't parse REMINDER_OFFSETS: %v", err)
+	}
+
+	nudgeAfter := os.Getenv("PENDING_NUDGE_AFTER")
+	if nudgeAfter == "" {
+		nudgeAfter = "3d"
+	}
+	if nudgeAfter != "off" {
+		schedule.nudgeAfter, err = parseOffset(nudgeAfter)
+		if err != nil {
+			log.Fatalf("Couldn't parse PENDING_NUDGE_AFTER: %v", err)
+		}
+	}
+
+	schedule.digestHour = 8
+	if hour := os.Getenv("DIGEST_HOUR"); hour == "off" {
+		schedule.digestHour = -1
+	} else if hour != "" {
+		schedule.digestHour, err = strconv.Atoi(hour)
+		if err != nil || schedule.digestHour < 0 || schedule.digestHour > 23 {
+			log.Fatal("DIGEST_HOUR must be an hour between 0 and 23, or off")
+		}
+	}
+
 	appLogger := logger.New()
 
 	cfg := apiConfig{
-		db:        db,
-		jwtSecret: jwtSecret,
-		platform:  platform,
-		port:      port,
-		mailer:    email.NewMailer(resendAPIKey, emailFromName, weddingFromEmail),
-		logger:    appLogger,
+		db:              db,
+		jwtSecret:       jwtSecret,
+		platform:        platform,
+		port:            port,
+		mailer:          email.NewMailer(mailTransport, emailFromName, weddingFromEmail),
+		logger:          appLogger,
+		ticketKey:       ticketKey,
+		eventDate:       eventDate,
+		eventLocation:   eventLocation,
+		siteURL:         siteURL,
+		schedule:        schedule,
+		outboxWake:      make(chan struct{}, 1),
+		webhookVerifier: webhookVerifier,
 	}
 
 	mux := http.NewServeMux()
@@ -82,6 +210,21 @@ func main() {
 	// Guest-Facing Routes
 	mux.HandleFunc("GET /api/rsvp/meta", cfg.handlerGetCategoryMeta)
 	mux.HandleFunc("POST /api/rsvp", cfg.handlerSubmitRSVP)
+	mux.HandleFunc("GET /api/rsvp/meal-options", cfg.handlerPublicMealOptions)
+	mux.HandleFunc("GET /api/tickets/public-key", cfg.handlerTicketPublicKey)
+	mux.HandleFunc("GET /api/rsvp/{id}/calendar.ics", cfg.handlerRSVPCalendar)
+
+	// Mail Provider Webhooks
+	mux.HandleFunc("POST /api/webhooks/email", cfg.handlerEmailWebhook)
+
+	// Guest Self-Service Routes
+	// Signing in is public, so each address gets a limited number of tries.
+	manageLimiter := newRateLimiter(10, 15*time.Minute)
+	mux.HandleFunc("POST /api/rsvp/manage/start", middlewareRateLimit(cfg.handlerRSVPManageStart, manageLimiter))
+	mux.HandleFunc("POST /api/rsvp/manage/verify", middlewareRateLimit(cfg.handlerRSVPManageVerify, manageLimiter))
+	mux.HandleFunc("GET /api/rsvp/manage", middlewareGuestAuth(cfg.handlerRSVPManageGet, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PATCH /api/rsvp/manage", middlewareGuestAuth(cfg.handlerRSVPManageUpdate, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/rsvp/manage", middlewareGuestAuth(cfg.handlerRSVPManageCancel, cfg.db, cfg.jwtSecret))
 
 	// Admin-Facing Routes
 	mux.HandleFunc("POST /api/admin/login/start", cfg.handlerLoginStart)
@@ -90,8 +233,55 @@ func main() {
 	// These routes should be protected by middleware
 	mux.HandleFunc("GET /api/admin/categories", middlewareAuth(cfg.handlerListCategories, cfg.db, cfg.jwtSecret))
 	mux.HandleFunc("POST /api/admin/categories", middlewareAuth(cfg.handlerCreateCategory, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PATCH /api/admin/categories/{id}", middlewareAuth(cfg.handlerUpdateCategory, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/categories/{id}", middlewareAuth(cfg.handlerDeleteCategory, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/categories/{id}/rotate-token", middlewareAuth(cfg.handlerRotateCategoryToken, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/guests", middlewareAuth(cfg.handlerListGuests, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/guests", middlewareAuth(cfg.handlerCreateGuest, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PATCH /api/admin/guests/{id}", middlewareAuth(cfg.handlerUpdateGuest, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/guests/{id}", middlewareAuth(cfg.handlerDeleteGuest, cfg.db, cfg.jwtSecret))
 	mux.HandleFunc("GET /api/admin/rsvps", middlewareAuth(cfg.handlerListRSVPs, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/rsvps/export", middlewareAuth(cfg.handlerExportRSVPs, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/rsvps/import", middlewareAuth(cfg.handlerImportRSVPs, cfg.db, cfg.jwtSecret))
 	mux.HandleFunc("POST /api/admin/rsvps/approve", middlewareAuth(cfg.handlerApproveRSVP, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PATCH /api/admin/rsvps/{id}", middlewareAuth(cfg.handlerUpdateRSVPPartySize, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/rsvps/{id}", middlewareAuth(cfg.handlerDeleteRSVP, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/waitlist", middlewareAuth(cfg.handlerListWaitlist, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/stats", middlewareAuth(cfg.handlerDashboardStats, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/meal-options", middlewareAuth(cfg.handlerListMealOptions, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/meal-options", middlewareAuth(cfg.handlerCreateMealOption, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PATCH /api/admin/meal-options/{id}", middlewareAuth(cfg.handlerUpdateMealOption, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/meal-options/{id}", middlewareAuth(cfg.handlerDeleteMealOption, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/catering", middlewareAuth(cfg.handlerCateringReport, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/seating/tables", middlewareAuth(cfg.handlerListSeatingTables, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/seating/tables", middlewareAuth(cfg.handlerCreateSeatingTable, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PATCH /api/admin/seating/tables/{id}", middlewareAuth(cfg.handlerUpdateSeatingTable, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/seating/tables/{id}", middlewareAuth(cfg.handlerDeleteSeatingTable, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/seating/roster", middlewareAuth(cfg.handlerSeatingRoster, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/seating/constraints", middlewareAuth(cfg.handlerListSeatingConstraints, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/seating/constraints", middlewareAuth(cfg.handlerCreateSeatingConstraint, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/seating/constraints/{id}", middlewareAuth(cfg.handlerDeleteSeatingConstraint, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/seating/suggest", middlewareAuth(cfg.handlerSuggestSeating, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/seating/apply", middlewareAuth(cfg.handlerApplySeating, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/events", middlewareAuth(cfg.handlerListEvents, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/events", middlewareAuth(cfg.handlerCreateEvent, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PATCH /api/admin/events/{id}", middlewareAuth(cfg.handlerUpdateEvent, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/events/{id}", middlewareAuth(cfg.handlerDeleteEvent, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PUT /api/admin/rsvps/{id}/table", middlewareAuth(cfg.handlerAssignRSVPTable, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/rsvps/{id}/table", middlewareAuth(cfg.handlerUnassignRSVPTable, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PUT /api/admin/attendees/{id}/table", middlewareAuth(cfg.handlerAssignAttendeeTable, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/attendees/{id}/table", middlewareAuth(cfg.handlerUnassignAttendeeTable, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/email-templates", middlewareAuth(cfg.handlerListEmailTemplates, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/email-templates/{name}", middlewareAuth(cfg.handlerGetEmailTemplate, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("PUT /api/admin/email-templates/{name}", middlewareAuth(cfg.handlerSaveEmailTemplate, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("DELETE /api/admin/email-templates/{name}", middlewareAuth(cfg.handlerResetEmailTemplate, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/email-templates/{name}/preview", middlewareAuth(cfg.handlerPreviewEmailTemplate, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/admin/emails", middlewareAuth(cfg.handlerListOutboxEmails, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("POST /api/admin/emails/{id}/resend", middlewareAuth(cfg.handlerResendOutboxEmail, cfg.db, cfg.jwtSecret))
+
+	// Door Check-in Routes
+	mux.HandleFunc("POST /api/checkin", middlewareAuth(cfg.handlerCheckIn, cfg.db, cfg.jwtSecret))
+	mux.HandleFunc("GET /api/checkin/stats", middlewareAuth(cfg.handlerCheckInStats, cfg.db, cfg.jwtSecret))
 
 	mux.HandleFunc("GET /api/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -107,6 +297,9 @@ func main() {
 		Handler: finalhandler,
 	}
 
+	go cfg.runScheduler()
+	go cfg.runOutboxWorker()
+
 	cfg.logger.Info("Server starting", "address", srv.Addr)
 	err = srv.ListenAndServe()
 	if err != nil {
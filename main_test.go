@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+	"github.com/tunedev/bts2025/server/internal/email"
+)
+
+// newTestConfig returns an apiConfig backed by a fresh SQLite database, with
+// mail kept in the returned transport instead of being sent.
+func newTestConfig(t *testing.T) (*apiConfig, *email.MemoryTransport) {
+	t.Helper()
+
+	db, err := database.NewClient(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+
+	transport := email.NewMemoryTransport()
+	return &apiConfig{
+		db:            db,
+		jwtSecret:     "test-secret",
+		mailer:        email.NewMailer(transport, "Diamond & Babatunde", "rsvp@example.com"),
+		logger:        slog.Default(),
+		ticketKey:     ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)),
+		eventLocation: time.UTC,
+		outboxWake:    make(chan struct{}, 1),
+	}, transport
+}
+
+// newTestCouple creates a couple on side along with one category for their guests.
+func newTestCouple(t *testing.T, cfg *apiConfig, side string) (database.Couple, database.GuestCategory) {
+	t.Helper()
+
+	couple, err := cfg.db.CreateCouple(database.CreateCoupleParams{Name: side + " couple", Email: uuid.NewString() + "@example.com", Side: side})
+	if err != nil {
+		t.Fatalf("CreateCouple: %v", err)
+	}
+	token := uuid.NewString()
+	category, err := cfg.db.CreateCategory(database.CreateCategoryParams{
+		Name:            "Friends " + token[:8],
+		Side:            side,
+		MaxGuests:       100,
+		InvitationToken: &token,
+		CoupleID:        couple.ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	return couple, category
+}
+
+// newTestRSVP creates an RSVP with the given status and party size in category.
+func newTestRSVP(t *testing.T, cfg *apiConfig, category database.GuestCategory, status string, numberOfGuests int) database.RSVP {
+	t.Helper()
+
+	id := uuid.NewString()
+	rsvp, err := cfg.db.CreateRSVP(database.CreateRSVPParams{
+		GuestName:      "Guest " + id[:8],
+		NumberOfGuests: numberOfGuests,
+		Email:          id + "@example.com",
+		Phone:          id,
+		CategoryID:     uuid.NullUUID{UUID: category.ID, Valid: true},
+	}, status)
+	if err != nil {
+		t.Fatalf("CreateRSVP: %v", err)
+	}
+	return rsvp
+}
+
+// asCouple returns r as middlewareAuth would pass it on for a signed-in couple.
+func asCouple(r *http.Request, couple database.Couple) *http.Request {
+	ctx := context.WithValue(r.Context(), coupleIDKey, couple.ID)
+	ctx = context.WithValue(ctx, coupleAuthDetailsKey, couple)
+	return r.WithContext(ctx)
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +17,7 @@ type contextKey string
 
 const coupleIDKey = contextKey("coupleID")
 const coupleAuthDetailsKey = contextKey("coupleAuthDetailsKey")
+const guestRSVPKey = contextKey("guestRSVP")
 
 // MiddlewareAuth is a middleware that protects admin routes.
 // It validates the JWT and attaches the couple's ID to the request context.
@@ -45,12 +48,59 @@ func middlewareAuth(handler http.HandlerFunc, db database.Client, jwtSecret stri
 	}
 }
 
+// middlewareGuestAuth protects the guest RSVP management routes.
+// It validates the guest session and attaches the guest's RSVP to the request context.
+func middlewareGuestAuth(handler http.HandlerFunc, db database.Client, jwtSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tokenString, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, err.Error(), err)
+			return
+		}
+
+		rsvpID, err := auth.ValidateGuestJWT(tokenString, jwtSecret)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, "Invalid or expired token", err)
+			return
+		}
+
+		rsvp, err := db.GetRSVP(rsvpID)
+		if err != nil || rsvp.ID == uuid.Nil {
+			respondWithError(w, http.StatusUnauthorized, "RSVP not found", err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), guestRSVPKey, rsvp)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// middlewareRateLimit refuses requests from a client address that has used up
+// its allowance in limiter, so that public routes such as code sign-in cannot
+// be hammered.
+func middlewareRateLimit(handler http.HandlerFunc, limiter *rateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		if ok, retryAfter := limiter.allow(host, time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			respondWithError(w, http.StatusTooManyRequests, "Too many attempts, please try again later", nil)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	}
+}
+
 // middlewareCORS adds CORS headers to every request.
 func middlewareCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Set the allowed origin. Use "*" for development, or your specific frontend URL.
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, PATCH, DELETE")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		// Handle preflight requests (the browser sends an OPTIONS request first)
@@ -75,6 +125,12 @@ func GetCoupleDetailsFromCtx(ctx context.Context) (database.Couple, bool) {
 	return coupleDetails, ok
 }
 
+// GetGuestRSVPFromCtx retrieves the authenticated guest's RSVP from the context.
+func GetGuestRSVPFromCtx(ctx context.Context) (database.RSVP, bool) {
+	rsvp, ok := ctx.Value(guestRSVPKey).(database.RSVP)
+	return rsvp, ok
+}
+
 func middlewareLogger(next http.Handler, logger *slog.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
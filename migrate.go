@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/tunedev/bts2025/server/internal/database"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  status          list migrations and whether they have been applied
+  up              apply all pending migrations
+  down            revert the most recently applied migration
+  to <version>    migrate up or down to the given version`
+
+// runMigrate implements the "migrate" subcommand. It connects without applying
+// migrations automatically so that the schema can be inspected or rolled back.
+func runMigrate(dbURL string, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := database.Open(dbURL)
+	if err != nil {
+		return err
+	}
+	defer db.DB.Close()
+
+	switch args[0] {
+	case "status":
+		return printMigrationStatus(db)
+	case "up":
+		if err := db.MigrateUp(); err != nil {
+			return err
+		}
+	case "down":
+		if err := db.MigrateDown(); err != nil {
+			return err
+		}
+	case "to":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q: %w", args[1], err)
+		}
+		if err := db.MigrateTo(version); err != nil {
+			return err
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return printMigrationStatus(db)
+}
+
+func printMigrationStatus(db database.Client) error {
+	states, err := db.MigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, state := range states {
+		status, appliedAt := "pending", ""
+		if state.Applied {
+			status = "applied"
+			appliedAt = state.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if state.Unknown {
+			status = "unknown (newer binary)"
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\t%s\n", state.Version, state.Name, status, appliedAt)
+	}
+	return tw.Flush()
+}
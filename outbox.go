@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+	"github.com/tunedev/bts2025/server/internal/email"
+)
+
+const (
+	// outboxPollInterval is how often the outbox worker looks for due emails when
+	// it has not been woken up by a new one.
+	outboxPollInterval = 15 * time.Second
+	// outboxBatchSize is how many emails the worker claims at a time.
+	outboxBatchSize = 20
+)
+
+// errEmailSkipped is returned by deliverEmail when an email no longer applies.
+var errEmailSkipped = errors.New("email no longer applies")
+
+// runOutboxWorker delivers queued emails until the process exits. It wakes every
+// outboxPollInterval, or straight away after wakeOutbox.
+func (cfg *apiConfig) runOutboxWorker() {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		cfg.deliverDueEmails()
+		select {
+		case <-ticker.C:
+		case <-cfg.outboxWake:
+		}
+	}
+}
+
+// wakeOutbox asks the outbox worker to deliver newly queued emails now rather
+// than at its next poll.
+func (cfg *apiConfig) wakeOutbox() {
+	select {
+	case cfg.outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDueEmails sends every email that is due, a batch at a time.
+func (cfg *apiConfig) deliverDueEmails() {
+	for {
+		emails, err := cfg.db.ClaimDueEmails(outboxBatchSize)
+		if err != nil {
+			cfg.logger.Error("failed to claim outbox emails", "error", err)
+			return
+		}
+
+		for _, queued := range emails {
+			cfg.deliverQueuedEmail(queued)
+		}
+		if len(emails) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// deliverQueuedEmail sends one claimed email and records the outcome.
+func (cfg *apiConfig) deliverQueuedEmail(queued database.OutboxEmail) {
+	messageID, err := cfg.deliverEmail(queued)
+	switch {
+	case err == nil:
+		err = cfg.db.MarkEmailSent(queued.ID, messageID)
+	case errors.Is(err, errEmailSkipped):
+		err = cfg.db.MarkEmailSkipped(queued.ID, err.Error())
+	default:
+		cfg.logger.Error("failed to deliver email", "email_id", queued.ID, "kind", queued.Kind, "attempt", queued.Attempts, "error", err)
+		err = cfg.db.MarkEmailFailed(queued, err)
+	}
+	if err != nil {
+		cfg.logger.Error("failed to record email delivery", "email_id", queued.ID, "error", err)
+	}
+}
+
+// deliverEmail renders and sends a queued email, returning the ID it was sent
+// under. Emails about an RSVP are written from its current state, so a guest
+// whose RSVP changed again before delivery gets up-to-date details.
+func (cfg *apiConfig) deliverEmail(queued database.OutboxEmail) (string, error) {
+	switch queued.Kind {
+	case database.EmailRSVPConfirmed:
+		rsvp, err := cfg.queuedRSVP(queued, "APPROVED")
+		if err != nil {
+			return "", err
+		}
+		return cfg.sendRSVPConfirmed(rsvp)
+
+	case database.EmailRSVPWaitlisted:
+		rsvp, err := cfg.queuedRSVP(queued, "WAITLISTED")
+		if err != nil {
+			return "", err
+		}
+		position, err := cfg.db.GetWaitlistPosition(rsvp)
+		if err != nil {
+			return "", err
+		}
+		mailer, err := cfg.categoryMailer(rsvp.CategoryID)
+		if err != nil {
+			return "", err
+		}
+		return mailer.SendRSVPWaitlisted(rsvp.Email, rsvp.GuestName, position)
+
+	case database.EmailRSVPReceived, database.EmailRSVPRejected:
+		var payload database.RSVPEmailPayload
+		if err := json.Unmarshal(queued.Payload, &payload); err != nil {
+			return "", err
+		}
+		mailer, err := cfg.queuedRSVPMailer(queued)
+		if err != nil {
+			return "", err
+		}
+		if queued.Kind == database.EmailRSVPReceived {
+			return mailer.SendRSVPReceived(queued.Recipient, payload.GuestName)
+		}
+		return mailer.SendRSVPRejected(queued.Recipient, payload.GuestName)
+
+	case database.EmailRSVPChanged:
+		var param email.SendRSVPChangedParam
+		if err := json.Unmarshal(queued.Payload, &param); err != nil {
+			return "", err
+		}
+		mailer := cfg.mailer
+		couple, err := cfg.db.GetCoupleByEmail(queued.Recipient)
+		if err != nil {
+			return "", err
+		}
+		if couple.ID != uuid.Nil {
+			if mailer, err = cfg.coupleMailer(couple.ID); err != nil {
+				return "", err
+			}
+		}
+		return mailer.SendRSVPChanged(queued.Recipient, param)
+
+	default:
+		return "", errors.New("unknown email kind " + queued.Kind)
+	}
+}
+
+// queuedRSVPMailer returns the Mailer for the guest a queued email is about.
+// If their RSVP has since been deleted, the built-in wording is used.
+func (cfg *apiConfig) queuedRSVPMailer(queued database.OutboxEmail) (email.Mailer, error) {
+	if !queued.RSVPID.Valid {
+		return cfg.mailer, nil
+	}
+	rsvp, err := cfg.db.GetRSVP(queued.RSVPID.UUID)
+	if err != nil {
+		return email.Mailer{}, err
+	}
+	return cfg.categoryMailer(rsvp.CategoryID)
+}
+
+// queuedRSVP loads the RSVP a queued email is about, returning errEmailSkipped
+// if it has been deleted or no longer has the status the email announces.
+func (cfg *apiConfig) queuedRSVP(queued database.OutboxEmail, status string) (database.RSVP, error) {
+	if !queued.RSVPID.Valid {
+		return database.RSVP{}, errors.New("email is not linked to an RSVP")
+	}
+
+	rsvp, err := cfg.db.GetRSVP(queued.RSVPID.UUID)
+	if err != nil {
+		return database.RSVP{}, err
+	}
+	if rsvp.ID == uuid.Nil || rsvp.Status != status {
+		return database.RSVP{}, errEmailSkipped
+	}
+	return rsvp, nil
+}
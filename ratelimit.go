@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter allows each key a fixed number of requests per window.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]rateWindow
+	// swept is when expired windows were last dropped from the map.
+	swept time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, windows: map[string]rateWindow{}}
+}
+
+// allow records a request for key at now. It reports whether the request is
+// within the limit and, if not, how long until the key's window resets.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) >= l.window {
+		for k, w := range l.windows {
+			if now.Sub(w.start) >= l.window {
+				delete(l.windows, k)
+			}
+		}
+		l.swept = now
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = rateWindow{start: now}
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	l.windows[key] = w
+	return true, 0
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(3, time.Minute)
+	start := time.Now()
+
+	for i := range 3 {
+		if ok, _ := limiter.allow("10.0.0.1", start); !ok {
+			t.Fatalf("request %d refused, want allowed", i+1)
+		}
+	}
+	ok, retryAfter := limiter.allow("10.0.0.1", start.Add(10*time.Second))
+	if ok {
+		t.Fatalf("fourth request allowed, want refused")
+	}
+	if retryAfter != 50*time.Second {
+		t.Errorf("retry after %s, want 50s", retryAfter)
+	}
+
+	if ok, _ := limiter.allow("10.0.0.2", start); !ok {
+		t.Errorf("another address was refused")
+	}
+	if ok, _ := limiter.allow("10.0.0.1", start.Add(time.Minute)); !ok {
+		t.Errorf("request after the window was refused")
+	}
+}
+
+func TestMiddlewareRateLimit(t *testing.T) {
+	limiter := newRateLimiter(2, time.Minute)
+	handler := middlewareRateLimit(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, limiter)
+
+	codes := []int{}
+	for range 3 {
+		r := httptest.NewRequest(http.MethodPost, "/api/rsvp/manage/verify", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		handler(w, r)
+		codes = append(codes, w.Code)
+		if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+			t.Errorf("429 without a Retry-After header")
+		}
+	}
+
+	want := []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests}
+	for i := range want {
+		if codes[i] != want[i] {
+			t.Errorf("status codes = %v, want %v", codes, want)
+			break
+		}
+	}
+}
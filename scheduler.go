@@ -0,0 +1,388 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tunedev/bts2025/server/internal/database"
+	"github.com/tunedev/bts2025/server/internal/email"
+)
+
+// schedulerInterval is how often the scheduler looks for messages that are due.
+const schedulerInterval = 5 * time.Minute
+
+// scheduleConfig controls the messages sent by the background scheduler.
+type scheduleConfig struct {
+	// reminderOffsets are how long before the wedding approved guests are
+	// reminded, longest first. Empty disables reminders.
+	reminderOffsets []time.Duration
+	// nudgeAfter is how long an RSVP can stay pending before the couple is
+	// reminded to review it. Zero disables nudges.
+	nudgeAfter time.Duration
+	// digestHour is the hour of the day, in the wedding's time zone, that each
+	// couple's daily digest goes out. Negative disables digests.
+	digestHour int
+}
+
+// runScheduler sends due reminders, nudges and digests every schedulerInterval.
+// It never returns. Every message is recorded as a job before it is sent, so a
+// restart, or a second server instance, does not send it again.
+func (cfg *apiConfig) runScheduler() {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		cfg.runScheduledJobs(time.Now())
+		<-ticker.C
+	}
+}
+
+// runScheduledJobs sends whatever is due at now.
+func (cfg *apiConfig) runScheduledJobs(now time.Time) {
+	if err := cfg.sendReminders(now); err != nil {
+		cfg.logger.Error("failed to send scheduled reminders", "error", err)
+	}
+	if err := cfg.sendPendingNudges(now); err != nil {
+		cfg.logger.Error("failed to send pending RSVP nudges", "error", err)
+	}
+	if err := cfg.sendDailyDigests(now); err != nil {
+		cfg.logger.Error("failed to send daily digests", "error", err)
+	}
+}
+
+// sendReminders emails approved guests as each reminder offset before the
+// wedding passes. When several offsets have passed, for instance because the
+// guest was approved late or the server was down, only the latest is sent.
+func (cfg *apiConfig) sendReminders(now time.Time) error {
+	if len(cfg.schedule.reminderOffsets) == 0 {
+		return nil
+	}
+
+	start, err := cfg.weddingStart()
+	if err != nil {
+		return err
+	}
+	if !now.Before(start) {
+		return nil
+	}
+
+	var due time.Duration
+	for _, offset := range cfg.schedule.reminderOffsets {
+		if !now.Before(start.Add(-offset)) {
+			due = offset
+		}
+	}
+	if due == 0 {
+		return nil
+	}
+
+	rsvps, err := cfg.db.ListRSVPsByStatus("APPROVED")
+	if err != nil {
+		return err
+	}
+
+	for _, rsvp := range rsvps {
+		key := fmt.Sprintf("reminder:%s:%s", formatOffset(due), rsvp.ID)
+		cfg.runJob(key, "reminder", func() error {
+			events, err := cfg.db.ListRSVPEvents(rsvp.ID)
+			if err != nil {
+				return err
+			}
+			mailer, err := cfg.categoryMailer(rsvp.CategoryID)
+			if err != nil {
+				return err
+			}
+			_, err = mailer.SendRSVPReminder(rsvp.Email, email.SendRSVPReminderParam{
+				GuestName:      rsvp.GuestName,
+				NumberOfGuests: rsvp.NumberOfGuests,
+				Countdown:      countdown(start.Sub(now)),
+				Events:         cfg.emailEvents(events),
+			})
+			return err
+		})
+	}
+
+	return nil
+}
+
+// sendPendingNudges emails each couple the RSVPs that have been pending for
+// longer than nudgeAfter. Each RSVP is brought up at most once per couple.
+func (cfg *apiConfig) sendPendingNudges(now time.Time) error {
+	if cfg.schedule.nudgeAfter <= 0 {
+		return nil
+	}
+
+	pending, err := cfg.db.ListRSVPsByStatus("PENDING")
+	if err != nil {
+		return err
+	}
+	var stale []database.RSVP
+	for _, rsvp := range pending {
+		if rsvp.SubmittedAt.Before(now.Add(-cfg.schedule.nudgeAfter)) {
+			stale = append(stale, rsvp)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	couples, byCouple, err := cfg.rsvpsByCouple(stale)
+	if err != nil {
+		return err
+	}
+
+	for _, couple := range couples {
+		var keys []string
+		var summaries []email.RSVPSummary
+		for _, rsvp := range byCouple[couple.ID] {
+			key := fmt.Sprintf("nudge:%s:%s", couple.ID, rsvp.ID)
+			claimed, err := cfg.db.ClaimJob(key, "nudge")
+			if err != nil {
+				cfg.logger.Error("failed to claim scheduled job", "job", key, "error", err)
+				continue
+			}
+			if !claimed {
+				continue
+			}
+			keys = append(keys, key)
+			summaries = append(summaries, cfg.rsvpSummary(rsvp))
+		}
+		if len(keys) == 0 {
+			continue
+		}
+
+		mailer, err := cfg.coupleMailer(couple.ID)
+		if err == nil {
+			_, err = mailer.SendPendingNudge(couple.Email, email.SendPendingNudgeParam{
+				CoupleName: couple.Name,
+				RSVPs:      summaries,
+			})
+		}
+		cfg.finishJobs(keys, err)
+	}
+
+	return nil
+}
+
+// sendDailyDigests emails each couple the RSVPs submitted in the day leading up
+// to today's digest hour. Couples without new RSVPs get no digest.
+func (cfg *apiConfig) sendDailyDigests(now time.Time) error {
+	if cfg.schedule.digestHour < 0 {
+		return nil
+	}
+
+	local := now.In(cfg.eventLocation)
+	digestAt := time.Date(local.Year(), local.Month(), local.Day(), cfg.schedule.digestHour, 0, 0, 0, cfg.eventLocation)
+	if local.Before(digestAt) {
+		return nil
+	}
+
+	submitted, err := cfg.db.ListRSVPsSubmittedBetween(digestAt.AddDate(0, 0, -1), digestAt)
+	if err != nil {
+		return err
+	}
+	if len(submitted) == 0 {
+		return nil
+	}
+	pending, err := cfg.db.ListRSVPsByStatus("PENDING")
+	if err != nil {
+		return err
+	}
+
+	couples, newByCouple, err := cfg.rsvpsByCouple(submitted)
+	if err != nil {
+		return err
+	}
+	_, pendingByCouple, err := cfg.rsvpsByCouple(pending)
+	if err != nil {
+		return err
+	}
+
+	date := digestAt.Format(time.DateOnly)
+	for _, couple := range couples {
+		rsvps := newByCouple[couple.ID]
+		if len(rsvps) == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("digest:%s:%s", couple.ID, date)
+		cfg.runJob(key, "digest", func() error {
+			summaries := make([]email.RSVPSummary, len(rsvps))
+			for i, rsvp := range rsvps {
+				summaries[i] = cfg.rsvpSummary(rsvp)
+			}
+			mailer, err := cfg.coupleMailer(couple.ID)
+			if err != nil {
+				return err
+			}
+			_, err = mailer.SendDailyDigest(couple.Email, email.SendDailyDigestParam{
+				CoupleName: couple.Name,
+				Date:       digestAt.Format("Monday, January 2"),
+				RSVPs:      summaries,
+				Pending:    len(pendingByCouple[couple.ID]),
+			})
+			return err
+		})
+	}
+
+	return nil
+}
+
+// runJob performs fn unless the job identified by key has already been claimed,
+// recording whether it succeeded.
+func (cfg *apiConfig) runJob(key, kind string, fn func() error) {
+	claimed, err := cfg.db.ClaimJob(key, kind)
+	if err != nil {
+		cfg.logger.Error("failed to claim scheduled job", "job", key, "error", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	cfg.finishJobs([]string{key}, fn())
+}
+
+// finishJobs records the outcome of claimed jobs: completed if err is nil,
+// failed (and so retried later) otherwise.
+func (cfg *apiConfig) finishJobs(keys []string, err error) {
+	for _, key := range keys {
+		if err != nil {
+			cfg.logger.Error("scheduled job failed", "job", key, "error", err)
+			if err := cfg.db.FailJob(key, err); err != nil {
+				cfg.logger.Error("failed to record scheduled job failure", "job", key, "error", err)
+			}
+			continue
+		}
+		if err := cfg.db.CompleteJob(key); err != nil {
+			cfg.logger.Error("failed to record scheduled job completion", "job", key, "error", err)
+		}
+	}
+}
+
+// rsvpsByCouple groups RSVPs by the couple who owns their category. RSVPs
+// without a category belong to every couple.
+func (cfg *apiConfig) rsvpsByCouple(rsvps []database.RSVP) ([]database.Couple, map[uuid.UUID][]database.RSVP, error) {
+	couples, err := cfg.db.ListCouples()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	owners := map[uuid.UUID]uuid.UUID{}
+	for _, couple := range couples {
+		categories, err := cfg.db.ListCategoriesByCouple(couple.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, category := range categories {
+			owners[category.ID] = couple.ID
+		}
+	}
+
+	byCouple := map[uuid.UUID][]database.RSVP{}
+	for _, rsvp := range rsvps {
+		if !rsvp.CategoryID.Valid {
+			for _, couple := range couples {
+				byCouple[couple.ID] = append(byCouple[couple.ID], rsvp)
+			}
+			continue
+		}
+		if owner, ok := owners[rsvp.CategoryID.UUID]; ok {
+			byCouple[owner] = append(byCouple[owner], rsvp)
+		}
+	}
+
+	return couples, byCouple, nil
+}
+
+// rsvpSummary describes an RSVP for a couple's digest or nudge.
+func (cfg *apiConfig) rsvpSummary(rsvp database.RSVP) email.RSVPSummary {
+	return email.RSVPSummary{
+		GuestName:      rsvp.GuestName,
+		NumberOfGuests: rsvp.NumberOfGuests,
+		Status:         rsvp.Status,
+		SubmittedAt:    rsvp.SubmittedAt.In(cfg.eventLocation).Format("Jan 2, 3:04 PM"),
+	}
+}
+
+// weddingStart is when the first event begins or, without any events, the
+// start of the wedding day in the wedding's time zone.
+func (cfg *apiConfig) weddingStart() (time.Time, error) {
+	events, err := cfg.db.ListEvents()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(events) > 0 {
+		return events[0].StartsAt, nil
+	}
+
+	return time.Date(cfg.eventDate.Year(), cfg.eventDate.Month(), cfg.eventDate.Day(), 0, 0, 0, 0, cfg.eventLocation), nil
+}
+
+// countdown describes how far away the wedding is for a reminder subject line,
+// e.g. "Tomorrow" or "in 7 Days".
+func countdown(left time.Duration) string {
+	days := int(math.Round(left.Hours() / 24))
+	switch {
+	case days <= 0:
+		return "Today"
+	case days == 1:
+		return "Tomorrow"
+	default:
+		return fmt.Sprintf("in %d Days", days)
+	}
+}
+
+// parseOffsets parses a comma-separated list of durations such as "7d,1d" or
+// "36h", returning them longest first. "off" disables them.
+func parseOffsets(s string) ([]time.Duration, error) {
+	if strings.TrimSpace(s) == "off" {
+		return nil, nil
+	}
+
+	var offsets []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		offset, err := parseOffset(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if offset <= 0 {
+			return nil, fmt.Errorf("offset %q must be positive", part)
+		}
+		offsets = append(offsets, offset)
+	}
+
+	slices.SortFunc(offsets, func(a, b time.Duration) int { return cmp.Compare(b, a) })
+	return slices.Compact(offsets), nil
+}
+
+// parseOffset parses a duration, also accepting whole days such as "7d".
+func parseOffset(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset %q (use e.g. 7d or 12h)", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset %q (use e.g. 7d or 12h)", s)
+	}
+	return d, nil
+}
+
+// formatOffset names a reminder offset in job keys, e.g. "7d" or "12h0m0s".
+func formatOffset(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	}
+	return d.String()
+}
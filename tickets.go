@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/tunedev/bts2025/server/internal/auth"
+	"github.com/tunedev/bts2025/server/internal/database"
+	"github.com/tunedev/bts2025/server/internal/email"
+)
+
+// ticketGracePeriod is how long after the event date a ticket remains valid.
+const ticketGracePeriod = 48 * time.Hour
+
+// makeTicket signs the QR ticket for an approved RSVP.
+func (cfg *apiConfig) makeTicket(rsvp database.RSVP) (string, error) {
+	return auth.MakeTicketToken(rsvp.ID, cfg.ticketKey, cfg.eventDate.Add(ticketGracePeriod))
+}
+
+// sendRSVPConfirmed issues a ticket for the RSVP and emails it to the guest,
+// returning the ID the email was sent under.
+func (cfg *apiConfig) sendRSVPConfirmed(rsvp database.RSVP) (string, error) {
+	ticket, err := cfg.makeTicket(rsvp)
+	if err != nil {
+		return "", err
+	}
+
+	tables, err := cfg.db.GetRSVPTableNumbers(rsvp.ID)
+	if err != nil {
+		return "", err
+	}
+
+	events, err := cfg.db.ListRSVPEvents(rsvp.ID)
+	if err != nil {
+		return "", err
+	}
+
+	ics, err := cfg.rsvpCalendar(rsvp)
+	if err != nil {
+		return "", err
+	}
+
+	mailer, err := cfg.categoryMailer(rsvp.CategoryID)
+	if err != nil {
+		return "", err
+	}
+	return mailer.SendRSVPConfirmed(rsvp.Email, email.SendRSVPConfirmedParam{
+		GuestName:      rsvp.GuestName,
+		Phone:          rsvp.Phone,
+		NumberOfGuests: rsvp.NumberOfGuests,
+		TicketToken:    ticket,
+		TableNumber:    formatTableNumbers(tables),
+		Events:         cfg.emailEvents(events),
+		Calendar:       ics,
+	})
+}
+
+// emailEvents describes events for an email footer, with start times in the
+// wedding's time zone.
+func (cfg *apiConfig) emailEvents(events []database.Event) []email.EventDetails {
+	details := make([]email.EventDetails, len(events))
+	for i, event := range events {
+		details[i] = email.EventDetails{
+			Name:    event.Name,
+			Venue:   event.Venue,
+			When:    event.StartsAt.In(cfg.eventLocation).Format("Monday, January 2, 2006 at 3:04 PM"),
+			Address: event.Address,
+			MapURL:  event.MapURL,
+		}
+	}
+	return details
+}
+
+// handlerTicketPublicKey exposes the key scanner apps use to verify tickets offline.
+func (cfg *apiConfig) handlerTicketPublicKey(w http.ResponseWriter, r *http.Request) {
+	publicKey := cfg.ticketKey.Public().(ed25519.PublicKey)
+
+	respondWithJSON(w, http.StatusOK, responseStructure{
+		Data: map[string]any{
+			"algorithm": "EdDSA",
+			"publicKey": base64.StdEncoding.EncodeToString(publicKey),
+		},
+		Message: "Ticket verification key retrieved successfully",
+		Success: true,
+	})
+}